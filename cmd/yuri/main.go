@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	_ "time/tzdata"
 
 	"github.com/sirupsen/logrus"
 	"github.com/zekrotja/yuri69/pkg/config"
@@ -11,7 +12,11 @@ import (
 	"github.com/zekrotja/yuri69/pkg/debug"
 	"github.com/zekrotja/yuri69/pkg/discord"
 	"github.com/zekrotja/yuri69/pkg/player"
+	"github.com/zekrotja/yuri69/pkg/presets"
+	"github.com/zekrotja/yuri69/pkg/scheduler"
+	"github.com/zekrotja/yuri69/pkg/sources"
 	"github.com/zekrotja/yuri69/pkg/storage"
+	"github.com/zekrotja/yuri69/pkg/tts"
 	"github.com/zekrotja/yuri69/pkg/twitch"
 	"github.com/zekrotja/yuri69/pkg/util"
 	"github.com/zekrotja/yuri69/pkg/webserver"
@@ -86,7 +91,7 @@ func main() {
 	}()
 
 	// --- Setup Player ---
-	pl, err := player.NewPlayer(cfg.Player, dc, st)
+	pl, err := player.NewPlayer(cfg.Player, dc, st, db)
 	if err != nil {
 		logrus.WithError(err).Fatal("Player creation failed")
 	}
@@ -112,14 +117,43 @@ func main() {
 		logrus.Info("Twitch client initialized")
 	}
 
+	// --- TTS Engine ---
+	tt, err := tts.New(cfg.TTS)
+	if err != nil {
+		logrus.WithError(err).Warn("TTS engine initialization failed; text to speech is disabled")
+		tt = nil
+	} else {
+		logrus.WithField("engine", cfg.TTS.Engine).Info("TTS engine initialized")
+	}
+
+	// --- Processing Presets ---
+	pr, err := presets.New(cfg.Presets)
+	if err != nil {
+		logrus.WithError(err).Fatal("Processing presets initialization failed")
+	}
+
+	so, err := sources.New(cfg.Sources)
+	if err != nil {
+		logrus.WithError(err).Fatal("Source providers initialization failed")
+	}
+
 	// --- Setup Controller ---
-	ct, err := controller.New(db, st, pl, dc, tw, cfg.Discord.OwnerID)
+	ct, err := controller.New(db, st, pl, dc, tw, tt, pr, so, cfg.Sounds, cfg.Discord.OwnerID)
 	if err != nil {
 		logrus.WithError(err).Fatal("Controller initialization failed")
 	}
 	defer ct.Close()
 	logrus.Info("Controller initialized")
 
+	// --- Setup Scheduler ---
+	sc := scheduler.New(db, ct.RunSchedule)
+	sc.Start()
+	defer func() {
+		logrus.Info("Shutting down scheduler ...")
+		sc.Close()
+	}()
+	logrus.Info("Scheduler started")
+
 	// --- Setup Web Server ---
 	ws, err := webserver.New(cfg.Webserver, ct)
 	if err != nil {
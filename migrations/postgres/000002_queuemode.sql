@@ -0,0 +1,9 @@
+-- +goose Up
+
+ALTER TABLE guilds
+  ADD COLUMN IF NOT EXISTS queuemode VARCHAR(16) NOT NULL DEFAULT '';
+
+-- +goose Down
+
+ALTER TABLE guilds
+  DROP COLUMN IF EXISTS queuemode;
@@ -0,0 +1,11 @@
+-- +goose Up
+
+ALTER TABLE guilds
+  ADD COLUMN IF NOT EXISTS autoleavetimeout INT NOT NULL DEFAULT '0',
+  ADD COLUMN IF NOT EXISTS idletimeout INT NOT NULL DEFAULT '0';
+
+-- +goose Down
+
+ALTER TABLE guilds
+  DROP COLUMN IF EXISTS autoleavetimeout,
+  DROP COLUMN IF EXISTS idletimeout;
@@ -0,0 +1,21 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS user_sounds (
+  userid VARCHAR(32) NOT NULL,
+  guildid VARCHAR(32) NOT NULL DEFAULT '',
+  entrance TEXT NOT NULL DEFAULT '',
+  exitsound TEXT NOT NULL DEFAULT '',
+  PRIMARY KEY (userid, guildid)
+);
+
+ALTER TABLE guilds
+  ADD COLUMN IF NOT EXISTS usersoundsenabled BOOLEAN NOT NULL DEFAULT 'true',
+  ADD COLUMN IF NOT EXISTS usersoundscooldown INT NOT NULL DEFAULT '60';
+
+-- +goose Down
+
+DROP TABLE IF EXISTS user_sounds;
+
+ALTER TABLE guilds
+  DROP COLUMN IF EXISTS usersoundsenabled,
+  DROP COLUMN IF EXISTS usersoundscooldown;
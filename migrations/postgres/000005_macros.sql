@@ -0,0 +1,28 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS macros (
+  uid VARCHAR(30) NOT NULL,
+  displayname TEXT NOT NULL DEFAULT '',
+  created TIMESTAMP NOT NULL,
+  creatorid TEXT NOT NULL,
+  PRIMARY KEY (uid)
+);
+
+CREATE TABLE IF NOT EXISTS macros_steps (
+  id INT GENERATED ALWAYS AS IDENTITY,
+  macro VARCHAR(30) NOT NULL,
+  position INT NOT NULL,
+  sound VARCHAR(30) NOT NULL,
+  delay INT NOT NULL DEFAULT '0',
+  volume INT NOT NULL DEFAULT '0',
+  PRIMARY KEY (id),
+  CONSTRAINT fk_steps
+    FOREIGN KEY (macro)
+    REFERENCES macros(uid)
+    ON DELETE CASCADE
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS macros_steps;
+DROP TABLE IF EXISTS macros;
@@ -0,0 +1,27 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS schedules (
+  id VARCHAR(20) NOT NULL,
+  guildid VARCHAR(32) NOT NULL,
+  channelid VARCHAR(32) NOT NULL,
+  ident TEXT NOT NULL,
+  cron TEXT NOT NULL DEFAULT '',
+  at TIMESTAMPTZ,
+  timezone TEXT NOT NULL DEFAULT '',
+  onlyifoccupied BOOLEAN NOT NULL DEFAULT 'false',
+  enabled BOOLEAN NOT NULL DEFAULT 'true',
+  created TIMESTAMP NOT NULL,
+  creatorid TEXT NOT NULL,
+  lastrun TIMESTAMPTZ,
+  PRIMARY KEY (id)
+);
+
+ALTER TABLE playbacklog
+  ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+
+DROP TABLE IF EXISTS schedules;
+
+ALTER TABLE playbacklog
+  DROP COLUMN IF EXISTS source;
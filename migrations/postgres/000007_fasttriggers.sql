@@ -0,0 +1,14 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS user_fasttriggers (
+  userid VARCHAR(32) NOT NULL,
+  guildid VARCHAR(32) NOT NULL DEFAULT '',
+  mute TEXT NOT NULL DEFAULT '',
+  deafen TEXT NOT NULL DEFAULT '',
+  doubletoggle TEXT NOT NULL DEFAULT '',
+  PRIMARY KEY (userid, guildid)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS user_fasttriggers;
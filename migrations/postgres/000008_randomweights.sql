@@ -0,0 +1,20 @@
+-- +goose Up
+
+ALTER TABLE guilds
+  ADD COLUMN IF NOT EXISTS randomfavorites REAL NOT NULL DEFAULT '0',
+  ADD COLUMN IF NOT EXISTS randomrarelyplayed REAL NOT NULL DEFAULT '0';
+
+CREATE TABLE IF NOT EXISTS guild_random_tags (
+  guildid VARCHAR(32) NOT NULL,
+  tag TEXT NOT NULL,
+  weight REAL NOT NULL DEFAULT '0',
+  PRIMARY KEY (guildid, tag)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS guild_random_tags;
+
+ALTER TABLE guilds
+  DROP COLUMN IF EXISTS randomfavorites,
+  DROP COLUMN IF EXISTS randomrarelyplayed;
@@ -0,0 +1,18 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS sound_versions (
+  id VARCHAR(32) NOT NULL,
+  sound VARCHAR(30) NOT NULL,
+  displayname TEXT NOT NULL DEFAULT '',
+  tags TEXT NOT NULL DEFAULT '',
+  soundcreated TIMESTAMP NOT NULL,
+  soundcreatorid TEXT NOT NULL,
+  created TIMESTAMP NOT NULL,
+  creatorid TEXT NOT NULL,
+  change TEXT NOT NULL DEFAULT '',
+  PRIMARY KEY (id)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS sound_versions;
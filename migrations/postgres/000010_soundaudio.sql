@@ -0,0 +1,15 @@
+-- +goose Up
+
+ALTER TABLE sounds
+  ADD COLUMN IF NOT EXISTS duration REAL,
+  ADD COLUMN IF NOT EXISTS samplerate INTEGER,
+  ADD COLUMN IF NOT EXISTS loudness REAL,
+  ADD COLUMN IF NOT EXISTS peaks TEXT;
+
+-- +goose Down
+
+ALTER TABLE sounds
+  DROP COLUMN IF EXISTS duration,
+  DROP COLUMN IF EXISTS samplerate,
+  DROP COLUMN IF EXISTS loudness,
+  DROP COLUMN IF EXISTS peaks;
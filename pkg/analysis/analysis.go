@@ -0,0 +1,162 @@
+// Package analysis extracts properties like the duration,
+// loudness and waveform peaks from audio using ffmpeg.
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"github.com/zekrotja/yuri69/pkg/models"
+)
+
+const (
+	// PeaksCount is the number of peaks the waveform of a
+	// sound is downsampled to.
+	PeaksCount = 100
+
+	// MinLoudness is reported for silent audio, where the
+	// integrated loudness is -inf.
+	MinLoudness = -70
+
+	// decodeSampleRate is the sample rate the audio is
+	// decoded with to calculate the duration and peaks.
+	decodeSampleRate = 8000
+	// blockSize is the number of samples of which the peak
+	// is determined before downsampling the peaks.
+	blockSize = decodeSampleRate / 100
+)
+
+var (
+	sampleRateRx = regexp.MustCompile(`Audio: [^,]+, (\d+) Hz`)
+	loudnessRx   = regexp.MustCompile(`I:\s+(-?[\d.]+|-inf) LUFS`)
+)
+
+// Analyze decodes the audio of the given type from r using
+// the ffmpeg executable and returns its properties.
+func Analyze(ffmpegExec string, r io.Reader, typ string) (models.AudioInfo, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(ffmpegExec,
+		"-hide_banner", "-nostats",
+		"-f", typ, "-i", "pipe:", "-map", "0:a:0",
+		"-af", "ebur128=framelog=quiet",
+		"-ac", "1", "-ar", strconv.Itoa(decodeSampleRate),
+		"-f", "s16le", "pipe:")
+	cmd.Stdin = r
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return models.AudioInfo{}, err
+	}
+
+	if err = cmd.Start(); err != nil {
+		return models.AudioInfo{}, err
+	}
+
+	blocks, samples, readErr := readBlockPeaks(stdout)
+
+	err = cmd.Wait()
+	if cmd.ProcessState != nil && cmd.ProcessState.ExitCode() != 0 {
+		return models.AudioInfo{}, errors.New(stderr.String())
+	}
+	if err != nil {
+		return models.AudioInfo{}, err
+	}
+	if readErr != nil {
+		return models.AudioInfo{}, readErr
+	}
+
+	info := parseOutput(stderr.String())
+	info.Duration = float64(samples) / decodeSampleRate
+	info.Peaks = downsample(blocks, PeaksCount)
+
+	return info, nil
+}
+
+// readBlockPeaks reads signed 16 bit little endian mono
+// samples from r and returns the absolute peak of each block
+// of blockSize samples and the total number of samples.
+func readBlockPeaks(r io.Reader) (blocks []float64, samples int, err error) {
+	br := bufio.NewReader(r)
+
+	var (
+		buf  [2]byte
+		peak float64
+	)
+	for {
+		_, err = io.ReadFull(br, buf[:])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		v := math.Abs(float64(int16(binary.LittleEndian.Uint16(buf[:])))) / math.MaxInt16
+		peak = math.Max(peak, v)
+
+		samples++
+		if samples%blockSize == 0 {
+			blocks = append(blocks, peak)
+			peak = 0
+		}
+	}
+
+	if samples%blockSize != 0 {
+		blocks = append(blocks, peak)
+	}
+
+	return blocks, samples, nil
+}
+
+// downsample reduces the block peaks to n peaks in the range
+// of [0, 100], where each peak is the maximum of the blocks
+// it covers.
+func downsample(blocks []float64, n int) []int {
+	if len(blocks) == 0 {
+		return []int{}
+	}
+	if len(blocks) < n {
+		n = len(blocks)
+	}
+
+	peaks := make([]int, n)
+	for i := range peaks {
+		from := i * len(blocks) / n
+		to := (i + 1) * len(blocks) / n
+
+		var peak float64
+		for _, v := range blocks[from:to] {
+			peak = math.Max(peak, v)
+		}
+		peaks[i] = int(math.Round(math.Min(peak, 1) * 100))
+	}
+
+	return peaks
+}
+
+// parseOutput extracts the sample rate of the input and the
+// integrated loudness from the log output of ffmpeg.
+func parseOutput(output string) (info models.AudioInfo) {
+	if m := sampleRateRx.FindStringSubmatch(output); m != nil {
+		info.SampleRate, _ = strconv.Atoi(m[1])
+	}
+
+	info.Loudness = MinLoudness
+	if m := loudnessRx.FindAllStringSubmatch(output, -1); m != nil {
+		// The summary is printed after the frame logs, so the
+		// last match is the integrated loudness of the audio.
+		if v, err := strconv.ParseFloat(m[len(m)-1][1], 64); err == nil && v > MinLoudness {
+			info.Loudness = v
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBlockPeaks(t *testing.T) {
+	var buf bytes.Buffer
+	for i := 0; i < blockSize*2+10; i++ {
+		v := int16(0)
+		switch i {
+		case 5:
+			v = -16384
+		case blockSize + 1:
+			v = 32767
+		}
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+
+	blocks, samples, err := readBlockPeaks(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, blockSize*2+10, samples)
+	assert.Equal(t, 3, len(blocks))
+	assert.InDelta(t, 0.5, blocks[0], 0.001)
+	assert.InDelta(t, 1, blocks[1], 0.001)
+	assert.Equal(t, 0.0, blocks[2])
+}
+
+func TestDownsample(t *testing.T) {
+	assert.Equal(t, []int{}, downsample(nil, 10))
+	assert.Equal(t, []int{10, 50}, downsample([]float64{0.1, 0.5}, 10))
+	assert.Equal(t, []int{50, 100}, downsample([]float64{0.1, 0.5, 1, 0.2}, 2))
+	assert.Equal(t, []int{30, 100, 0}, downsample([]float64{0.3, 0.2, 1, 0.4, 0, 0}, 3))
+}
+
+func TestParseOutput(t *testing.T) {
+	output := `Input #0, ogg, from 'pipe:':
+  Duration: N/A, start: 0.000000, bitrate: N/A
+  Stream #0:0: Audio: opus, 48000 Hz, stereo, fltp
+Stream mapping:
+  Stream #0:0 -> #0:0 (opus (native) -> pcm_s16le (native))
+Output #0, s16le, to 'pipe:':
+  Stream #0:0: Audio: pcm_s16le, 8000 Hz, mono, s16, 128 kb/s
+[Parsed_ebur128_0 @ 0x55d4] Summary:
+
+  Integrated loudness:
+    I:         -16.3 LUFS
+    Threshold: -26.6 LUFS
+
+  Loudness range:
+    LRA:         4.1 LU
+`
+
+	info := parseOutput(output)
+	assert.Equal(t, 48000, info.SampleRate)
+	assert.Equal(t, -16.3, info.Loudness)
+
+	info = parseOutput("I:         -inf LUFS")
+	assert.Equal(t, 0, info.SampleRate)
+	assert.Equal(t, float64(MinLoudness), info.Loudness)
+}
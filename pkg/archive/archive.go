@@ -0,0 +1,192 @@
+// Package archive reads the files of sound archives and
+// infers sound metadata from the paths of loose files.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatTarGzip = Format("tar+gzip")
+	FormatZip     = Format("zip")
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported archive format")
+)
+
+var (
+	invalidUidCharsRx = regexp.MustCompile(`[^a-z0-9_.-]+`)
+	invalidTagCharsRx = regexp.MustCompile(`[^\p{L}\p{N}_.-]+`)
+)
+
+// maxUidLength must match the maximum length of sound uids.
+const maxUidLength = 30
+
+var audioExtensions = []string{
+	".mp3", ".ogg", ".oga", ".opus", ".wav", ".flac", ".m4a",
+	".aac", ".webm", ".wma", ".aif", ".aiff", ".mp4", ".mkv",
+}
+
+// Entry is a regular file in an archive.
+type Entry struct {
+	// Name is the cleaned slash separated path of the file.
+	Name string
+	// Size is the uncompressed size of the file.
+	Size int64
+}
+
+// FormatFromMime returns the archive format of the given
+// mime type.
+func FormatFromMime(mimeType string) (Format, bool) {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	switch strings.TrimSpace(strings.ToLower(mimeType)) {
+	case "application/tar+gzip", "application/gzip", "application/x-gzip",
+		"application/x-tar+gzip", "application/x-compressed-tar":
+		return FormatTarGzip, true
+	case "application/zip", "application/x-zip", "application/x-zip-compressed":
+		return FormatZip, true
+	default:
+		return "", false
+	}
+}
+
+// Walk calls fn for each regular file in the archive of the
+// given size read from r. Hidden files and directories, like
+// ".DS_Store" or "__MACOSX", are skipped. When fn returns an
+// error, walking is stopped and the error is returned.
+func Walk(r io.ReaderAt, size int64, format Format, fn func(e Entry, r io.Reader) error) error {
+	switch format {
+	case FormatTarGzip:
+		return walkTarGzip(io.NewSectionReader(r, 0, size), fn)
+	case FormatZip:
+		return walkZip(r, size, fn)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// IsAudioFile returns true when the file extension of name
+// is a known audio or video format.
+func IsAudioFile(name string) bool {
+	return contains(audioExtensions, strings.ToLower(path.Ext(name)))
+}
+
+// InferSound derives the uid of a sound from the file name
+// and its tags from the names of the folders containing the
+// file. The display name is the file name without extension.
+func InferSound(name string) (uid, displayName string, tags []string) {
+	dir, file := path.Split(cleanName(name))
+
+	displayName = strings.TrimSuffix(file, path.Ext(file))
+
+	uid = invalidUidCharsRx.ReplaceAllString(strings.ToLower(displayName), "_")
+	uid = strings.Trim(uid, "_.-")
+	if len(uid) > maxUidLength {
+		uid = strings.TrimRight(uid[:maxUidLength], "_.-")
+	}
+
+	tags = []string{}
+	for _, folder := range strings.Split(dir, "/") {
+		tag := invalidTagCharsRx.ReplaceAllString(strings.ToLower(folder), "-")
+		tag = strings.Trim(tag, "-")
+		if tag == "" || contains(tags, tag) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	return uid, displayName, tags
+}
+
+// --- Internal stuff ---
+
+func walkTarGzip(r io.Reader, fn func(e Entry, r io.Reader) error) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gzr.Close()
+
+	tarr := tar.NewReader(gzr)
+	for {
+		header, err := tarr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !header.FileInfo().Mode().IsRegular() || isHidden(header.Name) {
+			continue
+		}
+
+		err = fn(Entry{Name: cleanName(header.Name), Size: header.Size}, tarr)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func walkZip(r io.ReaderAt, size int64, fn func(e Entry, r io.Reader) error) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() || isHidden(f.Name) {
+			continue
+		}
+
+		err = walkZipFile(f, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walkZipFile(f *zip.File, fn func(e Entry, r io.Reader) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return fn(Entry{Name: cleanName(f.Name), Size: int64(f.UncompressedSize64)}, rc)
+}
+
+func cleanName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+func isHidden(name string) bool {
+	for _, part := range strings.Split(cleanName(name), "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
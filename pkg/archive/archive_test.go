@@ -0,0 +1,127 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFiles = map[string]string{
+	"./memes/Bruh Moment.mp3": "bruh",
+	"__MACOSX/._bruh.mp3":     "resource fork",
+	"memes/.DS_Store":         "finder",
+	"readme.txt":              "hello",
+}
+
+func TestFormatFromMime(t *testing.T) {
+	f, ok := FormatFromMime("application/zip")
+	assert.True(t, ok)
+	assert.Equal(t, FormatZip, f)
+
+	f, ok = FormatFromMime("Application/X-Gzip; charset=binary")
+	assert.True(t, ok)
+	assert.Equal(t, FormatTarGzip, f)
+
+	_, ok = FormatFromMime("application/x-7z-compressed")
+	assert.False(t, ok)
+}
+
+func TestWalk(t *testing.T) {
+	for format, data := range map[Format][]byte{
+		FormatTarGzip: tarGzipArchive(t),
+		FormatZip:     zipArchive(t),
+	} {
+		files := map[string]string{}
+		err := Walk(bytes.NewReader(data), int64(len(data)), format, func(e Entry, r io.Reader) error {
+			content, err := io.ReadAll(r)
+			assert.Nil(t, err)
+			assert.Equal(t, int64(len(content)), e.Size)
+			files[e.Name] = string(content)
+			return nil
+		})
+		assert.Nil(t, err, format)
+		assert.Equal(t, map[string]string{
+			"memes/Bruh Moment.mp3": "bruh",
+			"readme.txt":            "hello",
+		}, files, format)
+	}
+
+	err := Walk(bytes.NewReader([]byte("foo")), 3, FormatZip, func(Entry, io.Reader) error {
+		return nil
+	})
+	assert.NotNil(t, err)
+
+	err = Walk(bytes.NewReader(nil), 0, Format("rar"), nil)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestIsAudioFile(t *testing.T) {
+	assert.True(t, IsAudioFile("memes/bruh.MP3"))
+	assert.True(t, IsAudioFile("bruh.opus"))
+	assert.False(t, IsAudioFile("readme.txt"))
+	assert.False(t, IsAudioFile("bruh"))
+}
+
+func TestInferSound(t *testing.T) {
+	uid, displayName, tags := InferSound("Memes/Anime Stuff/Bruh Moment!.mp3")
+	assert.Equal(t, "bruh_moment", uid)
+	assert.Equal(t, "Bruh Moment!", displayName)
+	assert.Equal(t, []string{"memes", "anime-stuff"}, tags)
+
+	uid, displayName, tags = InferSound("./bruh.ogg")
+	assert.Equal(t, "bruh", uid)
+	assert.Equal(t, "bruh", displayName)
+	assert.Equal(t, []string{}, tags)
+
+	uid, _, tags = InferSound("a/A/this is a very long file name of a sound.wav")
+	assert.Equal(t, "this_is_a_very_long_file_name", uid)
+	assert.Equal(t, []string{"a"}, tags)
+
+	uid, _, _ = InferSound("ÄÖÜ.mp3")
+	assert.Equal(t, "", uid)
+}
+
+func tarGzipArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tarw := tar.NewWriter(gzw)
+
+	err := tarw.WriteHeader(&tar.Header{Name: "memes/", Typeflag: tar.TypeDir, Mode: 0755})
+	assert.Nil(t, err)
+
+	for name, content := range testFiles {
+		err = tarw.WriteHeader(&tar.Header{Name: name, Size: int64(len(content)), Mode: 0644})
+		assert.Nil(t, err)
+		_, err = tarw.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+
+	assert.Nil(t, tarw.Close())
+	assert.Nil(t, gzw.Close())
+
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zipw := zip.NewWriter(&buf)
+
+	_, err := zipw.Create("memes/")
+	assert.Nil(t, err)
+
+	for name, content := range testFiles {
+		w, err := zipw.Create(name)
+		assert.Nil(t, err)
+		_, err = w.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+
+	assert.Nil(t, zipw.Close())
+
+	return buf.Bytes()
+}
@@ -3,12 +3,17 @@ package config
 import (
 	"time"
 
+	"github.com/zekrotja/yuri69/pkg/controller"
 	"github.com/zekrotja/yuri69/pkg/database"
 	"github.com/zekrotja/yuri69/pkg/database/nuts"
 	"github.com/zekrotja/yuri69/pkg/database/postgres"
 	"github.com/zekrotja/yuri69/pkg/discord"
+	"github.com/zekrotja/yuri69/pkg/lavalink"
 	"github.com/zekrotja/yuri69/pkg/player"
+	"github.com/zekrotja/yuri69/pkg/presets"
+	"github.com/zekrotja/yuri69/pkg/sources"
 	"github.com/zekrotja/yuri69/pkg/storage"
+	"github.com/zekrotja/yuri69/pkg/tts"
 	"github.com/zekrotja/yuri69/pkg/twitch"
 	"github.com/zekrotja/yuri69/pkg/webserver"
 	"github.com/zekrotja/yuri69/pkg/webserver/auth"
@@ -39,7 +44,71 @@ var DefaultConfig = Config{
 		},
 	},
 	Player: player.PlayerConfig{
-		FastTriggerTime: 300 * time.Millisecond,
+		Backend:           player.BackendLavalink,
+		FastTriggerTime:   1500 * time.Millisecond,
+		DoubleTriggerTime: 750 * time.Millisecond,
+		AutoLeaveTimeout:  5 * time.Second,
+		FileServer: player.FileServerConfig{
+			BindAddress: "0.0.0.0:6969",
+			URLLifetime: 10 * time.Minute,
+		},
+		Effects: map[string]lavalink.Filters{
+			"chipmunk": {
+				Timescale: &lavalink.Timescale{Speed: 1.05, Pitch: 1.6},
+			},
+			"nightcore": {
+				Timescale: &lavalink.Timescale{Speed: 1.25, Pitch: 1.25},
+			},
+			"slowed": {
+				Timescale: &lavalink.Timescale{Speed: 0.8, Pitch: 0.85},
+			},
+			"bassboost": {
+				Equalizer: []lavalink.EqualizerBand{
+					{Band: 0, Gain: 0.3},
+					{Band: 1, Gain: 0.25},
+					{Band: 2, Gain: 0.2},
+					{Band: 3, Gain: 0.1},
+				},
+			},
+			"karaoke": {
+				Karaoke: &lavalink.Karaoke{Level: 1, MonoLevel: 1, FilterBand: 220, FilterWidth: 100},
+			},
+			"tremolo": {
+				Tremolo: &lavalink.Tremolo{Frequency: 4, Depth: 0.75},
+			},
+			"8d": {
+				Rotation: &lavalink.Rotation{RotationHz: 0.2},
+			},
+		},
+	},
+	TTS: tts.TTSConfig{
+		MaxLength: 300,
+		Timeout:   30 * time.Second,
+	},
+	Sounds: controller.SoundsConfig{
+		VersionRetention: 10,
+		MaxUploadSize:    25 << 20,
+		MaxImportSize:    1 << 30,
+		MaxDuration:      5 * time.Minute,
+		MaxTranscodes:    2,
+	},
+	Sources: sources.SourcesConfig{
+		Providers: []string{sources.ProviderYouTube, sources.ProviderHTTP},
+		HTTP: sources.HTTPConfig{
+			Timeout: 30 * time.Second,
+		},
+	},
+	Presets: presets.PresetsConfig{
+		"overdrive": "volume=12dB,acrusher=bits=12:mode=log:aa=1,alimiter=limit=0.9",
+		"reverse":   "areverse",
+		"speedup":   "atempo=1.5",
+		"slowdown":  "atempo=0.75",
+		"pitchup":   "aresample=48000,asetrate=60000,aresample=48000,atempo=0.8",
+		"pitchdown": "aresample=48000,asetrate=38400,aresample=48000,atempo=1.25",
+		"trim": "silenceremove=start_periods=1:start_threshold=-50dB,areverse," +
+			"silenceremove=start_periods=1:start_threshold=-50dB,areverse",
+		"fadein":  "afade=t=in:d=0.5",
+		"fadeout": "areverse,afade=t=in:d=0.5,areverse",
 	},
 }
 
@@ -50,4 +119,8 @@ type Config struct {
 	Discord   discord.DiscordConfig
 	Player    player.PlayerConfig
 	Twitch    *twitch.TwitchConfig
+	TTS       tts.TTSConfig
+	Sounds    controller.SoundsConfig
+	Presets   presets.PresetsConfig
+	Sources   sources.SourcesConfig
 }
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+)
+
+// chunkedUploadLifetime is the time after the last received
+// chunk after which an unfinished upload is discarded.
+const chunkedUploadLifetime = 30 * time.Minute
+
+// StartChunkedUpload starts an upload of a file with the
+// given size which is sent in chunks via PutUploadChunk.
+// When the upload is completed, the upload ID can be used to
+// create a sound like with UploadSound.
+func (t *Controller) StartChunkedUpload(req ChunkedUploadRequest) (ChunkedUploadResponse, error) {
+	err := req.Check()
+	if err != nil {
+		return ChunkedUploadResponse{}, err
+	}
+
+	err = t.checkUploadSize(req.Size)
+	if err != nil {
+		return ChunkedUploadResponse{}, err
+	}
+
+	f, err := newTempFile()
+	if err != nil {
+		return ChunkedUploadResponse{}, err
+	}
+
+	upload := &chunkedUpload{
+		id:       xid.New().String(),
+		file:     f,
+		hash:     sha256.New(),
+		size:     req.Size,
+		checksum: strings.ToLower(req.Checksum),
+		deadline: time.Now().Add(chunkedUploadLifetime),
+	}
+	t.chunkedUploads.Set(upload.id, upload, chunkedUploadLifetime, func(v *chunkedUpload) {
+		// Expiry callbacks are run while the map is locked and
+		// the upload might be locked by a running chunk, which
+		// accesses the map as well. So the upload is only
+		// marked as discarded and closed in the background.
+		v.discarded.Store(true)
+		go v.discard()
+	})
+
+	return upload.response(), nil
+}
+
+// GetChunkedUpload returns the state of the upload, which
+// contains the offset at which the upload can be resumed.
+func (t *Controller) GetChunkedUpload(id string) (ChunkedUploadResponse, error) {
+	upload, err := t.getChunkedUpload(id)
+	if err != nil {
+		return ChunkedUploadResponse{}, err
+	}
+
+	upload.mtx.Lock()
+	defer upload.mtx.Unlock()
+
+	return upload.response(), nil
+}
+
+// PutUploadChunk appends the chunk read from r to the upload
+// at the given offset, which must match the current offset
+// of the upload. When the chunk is interrupted, the upload
+// can be resumed at the offset returned by GetChunkedUpload.
+//
+// When the last chunk has been received, the checksum of the
+// assembled file is verified and the upload is completed.
+func (t *Controller) PutUploadChunk(id string, offset int64, r io.Reader) (ChunkedUploadResponse, error) {
+	upload, err := t.getChunkedUpload(id)
+	if err != nil {
+		return ChunkedUploadResponse{}, err
+	}
+
+	upload.mtx.Lock()
+	defer upload.mtx.Unlock()
+
+	if upload.completed {
+		return ChunkedUploadResponse{}, errs.WrapUserError(
+			"upload has already been completed", http.StatusConflict)
+	}
+	if upload.discarded.Load() {
+		return ChunkedUploadResponse{}, errUploadNotFound()
+	}
+	if offset != upload.offset {
+		return ChunkedUploadResponse{}, errs.WrapUserError(
+			"offset does not match the current offset of the upload", http.StatusConflict)
+	}
+
+	t.extendChunkedUpload(upload)
+
+	// Data received before the chunk was interrupted is kept,
+	// so that the upload can be resumed from there.
+	n, err := io.Copy(io.MultiWriter(upload.file, upload.hash),
+		io.LimitReader(r, upload.size-upload.offset+1))
+	upload.offset += n
+
+	// The upload might have expired while the chunk has been
+	// received, which takes arbitrarily long on slow clients.
+	if upload.discarded.Load() {
+		return ChunkedUploadResponse{}, errUploadNotFound()
+	}
+	t.extendChunkedUpload(upload)
+
+	if err != nil {
+		return ChunkedUploadResponse{}, err
+	}
+	if upload.offset > upload.size {
+		t.removeChunkedUpload(upload)
+		return ChunkedUploadResponse{}, errs.WrapUserError(
+			"received more data than the announced size of the upload")
+	}
+
+	if upload.offset < upload.size {
+		return upload.response(), nil
+	}
+
+	if hex.EncodeToString(upload.hash.Sum(nil)) != upload.checksum {
+		t.removeChunkedUpload(upload)
+		return ChunkedUploadResponse{}, errs.WrapUserError(
+			"checksum of the uploaded file does not match, please restart the upload")
+	}
+
+	// The file is handed over to the pending creations with
+	// the same ID, so it is not needed anymore afterwards.
+	upload.deadline, err = t.storePendingUpload(upload.id, upload.file)
+	t.removeChunkedUpload(upload)
+	if err != nil {
+		return ChunkedUploadResponse{}, err
+	}
+
+	upload.completed = true
+	return upload.response(), nil
+}
+
+// --- Internal stuff ---
+
+type chunkedUpload struct {
+	mtx sync.Mutex
+
+	id        string
+	file      *tempFile
+	hash      hash.Hash
+	size      int64
+	offset    int64
+	checksum  string
+	deadline  time.Time
+	completed bool
+	discarded atomic.Bool
+	closed    bool
+}
+
+func (t *chunkedUpload) response() ChunkedUploadResponse {
+	return ChunkedUploadResponse{
+		UploadId:  t.id,
+		Offset:    t.offset,
+		Size:      t.size,
+		Completed: t.completed,
+		Deadline:  t.deadline,
+	}
+}
+
+// discard removes the temporary file of the upload as soon
+// as no chunk is received anymore. It is called when the
+// upload has expired.
+func (t *chunkedUpload) discard() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.close()
+}
+
+func (t *chunkedUpload) close() {
+	t.discarded.Store(true)
+	if t.closed {
+		return
+	}
+	t.closed = true
+	t.file.Close()
+}
+
+func (t *Controller) getChunkedUpload(id string) (*chunkedUpload, error) {
+	upload := t.chunkedUploads.GetValue(id)
+	if upload == nil {
+		return nil, errUploadNotFound()
+	}
+	return upload, nil
+}
+
+// extendChunkedUpload moves the deadline of the upload to
+// one lifetime from now. The lock of the upload must be held.
+func (t *Controller) extendChunkedUpload(upload *chunkedUpload) {
+	upload.deadline = time.Now().Add(chunkedUploadLifetime)
+	t.chunkedUploads.SetExpires(upload.id, chunkedUploadLifetime)
+}
+
+// removeChunkedUpload removes the upload and its temporary
+// file. The lock of the upload must be held.
+func (t *Controller) removeChunkedUpload(upload *chunkedUpload) {
+	t.chunkedUploads.Remove(upload.id)
+	upload.close()
+}
+
+func errUploadNotFound() error {
+	return errs.WrapUserError("upload does not exist or has expired", http.StatusNotFound)
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zekroTJA/timedmap"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+)
+
+// blockingReader returns its data only after release has
+// been closed.
+type blockingReader struct {
+	r       io.Reader
+	reading chan struct{}
+	release chan struct{}
+}
+
+func (t *blockingReader) Read(p []byte) (int, error) {
+	select {
+	case <-t.reading:
+	default:
+		close(t.reading)
+	}
+	<-t.release
+	return t.r.Read(p)
+}
+
+func TestChunkedUploadExpiresDuringChunk(t *testing.T) {
+	ct := &Controller{
+		chunkedUploads: timedmap.New[string, *chunkedUpload](time.Hour),
+	}
+
+	res, err := ct.StartChunkedUpload(ChunkedUploadRequest{
+		Size:     4,
+		Checksum: strings.Repeat("0", 64),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &blockingReader{
+		r:       strings.NewReader("ab"),
+		reading: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	done := make(chan error)
+	go func() {
+		_, err := ct.PutUploadChunk(res.UploadId, 0, r)
+		done <- err
+	}()
+	<-r.reading
+
+	// Expiring the upload while the chunk is received must
+	// neither block the map nor the chunk.
+	ct.chunkedUploads.SetExpires(res.UploadId, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	expired := make(chan bool)
+	go func() {
+		expired <- ct.chunkedUploads.GetValue(res.UploadId) == nil
+	}()
+
+	select {
+	case ok := <-expired:
+		assert.True(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expiring the upload blocked")
+	}
+
+	close(r.release)
+
+	select {
+	case err = <-done:
+		e, ok := errs.As[errs.StatusError](err)
+		if assert.True(t, ok, err) {
+			assert.Equal(t, http.StatusNotFound, e.Status)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("receiving the chunk blocked")
+	}
+}
@@ -0,0 +1,25 @@
+package controller
+
+import "time"
+
+type SoundsConfig struct {
+	// VersionRetention is the number of previous versions
+	// which are kept per sound. 0 disables sound versions.
+	VersionRetention int
+
+	// MaxUploadSize is the maximum size in bytes of uploaded
+	// and downloaded sound sources. 0 disables the limit.
+	MaxUploadSize int64
+
+	// MaxImportSize is the maximum size in bytes of imported
+	// archives. 0 disables the limit.
+	MaxImportSize int64
+
+	// MaxDuration is the maximum duration of the source of
+	// a sound. 0 disables the limit.
+	MaxDuration time.Duration
+
+	// MaxTranscodes is the maximum number of ffmpeg processes
+	// which run at once. 0 disables the limit.
+	MaxTranscodes int
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"math/rand"
 	"os/exec"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -15,13 +16,16 @@ import (
 	"github.com/zekrotja/yuri69/pkg/generic"
 	. "github.com/zekrotja/yuri69/pkg/models"
 	"github.com/zekrotja/yuri69/pkg/player"
+	"github.com/zekrotja/yuri69/pkg/presets"
+	"github.com/zekrotja/yuri69/pkg/sources"
 	"github.com/zekrotja/yuri69/pkg/static"
 	"github.com/zekrotja/yuri69/pkg/storage"
+	"github.com/zekrotja/yuri69/pkg/tts"
 	"github.com/zekrotja/yuri69/pkg/twitch"
 )
 
 var (
-	reservedUids = []string{"random", "upload", "create", "downloadall"}
+	reservedUids = []string{"random", "upload", "create", "downloadall", "presets", "sources"}
 )
 
 type ControllerEvent struct {
@@ -39,11 +43,22 @@ type Controller struct {
 	pl      *player.Player
 	dg      *discord.Discord
 	tw      *twitch.Twitch
-
-	ffmpegExec string
-
-	pendingCrations *timedmap.TimedMap[string, string]
-	history         *generic.RingQueue[string]
+	tts     tts.Engine
+	presets *presets.Presets
+	sources *sources.Sources
+	sounds  SoundsConfig
+
+	ffmpegExec  string
+	ffmpegSlots chan struct{}
+
+	pendingCrations    *timedmap.TimedMap[string, pendingUpload]
+	tempSounds         *timedmap.TimedMap[string, struct{}]
+	chunkedUploads     *timedmap.TimedMap[string, *chunkedUpload]
+	userSoundCooldowns *timedmap.TimedMap[string, struct{}]
+	histories          generic.SyncMap[string, *generic.RingQueue[string]]
+	historySize        atomic.Int64
+	stop               chan struct{}
+	jobs               generic.SyncMap[string, *job]
 }
 
 func New(
@@ -52,6 +67,10 @@ func New(
 	pl *player.Player,
 	dg *discord.Discord,
 	tw *twitch.Twitch,
+	tt tts.Engine,
+	pr *presets.Presets,
+	so *sources.Sources,
+	sc SoundsConfig,
 	ownerID string,
 ) (*Controller, error) {
 
@@ -70,10 +89,20 @@ func New(
 	t.pl = pl
 	t.dg = dg
 	t.tw = tw
+	t.tts = tt
+	t.presets = pr
+	t.sources = so
+	t.sounds = sc
+	t.stop = make(chan struct{})
+	if sc.MaxTranscodes > 0 {
+		t.ffmpegSlots = make(chan struct{}, sc.MaxTranscodes)
+	}
 
-	t.pendingCrations = timedmap.New[string, string](5 * time.Minute)
+	t.pendingCrations = timedmap.New[string, pendingUpload](5 * time.Minute)
+	t.tempSounds = timedmap.New[string, struct{}](5 * time.Minute)
+	t.chunkedUploads = timedmap.New[string, *chunkedUpload](5 * time.Minute)
+	t.userSoundCooldowns = timedmap.New[string, struct{}](5 * time.Minute)
 
-	t.history = generic.NewRingQueue[string](1)
 	if err = t.resizeHistoryBuffer(); err != nil {
 		return nil, err
 	}
@@ -88,16 +117,32 @@ func New(
 		t.tw.SubscribeFunc(t.twitchHandler)
 	}
 
+	go t.backfillAudioInfo()
+
 	return &t, nil
 }
 
 func (t *Controller) Close() error {
+	close(t.stop)
+	t.jobs.Range(func(_ string, j *job) bool {
+		j.cancel()
+		return true
+	})
 	for k := range t.pendingCrations.Snapshot() {
 		err := t.st.DeleteObject(static.BucketTemp, k)
 		if err != nil {
 			logrus.WithError(err).WithField("id", k).Error("Failed removing temp uploaded sound")
 		}
 	}
+	for _, upload := range t.chunkedUploads.Snapshot() {
+		upload.discard()
+	}
+	for k := range t.tempSounds.Snapshot() {
+		err := t.st.DeleteObject(static.BucketTemp, k)
+		if err != nil {
+			logrus.WithError(err).WithField("id", k).Error("Failed removing temp sound")
+		}
+	}
 	return nil
 }
 
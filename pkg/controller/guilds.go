@@ -44,3 +44,80 @@ func (t *Controller) SetGuildFilter(userID string, f GuildFilters) error {
 		Payload: f,
 	})
 }
+
+func (t *Controller) GetGuildAutoLeave(userID string) (GuildAutoLeave, error) {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return GuildAutoLeave{},
+			errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	s, err := t.db.GetGuildAutoLeave(vs.GuildID)
+	if err == dberrors.ErrNotFound {
+		err = nil
+	}
+
+	return s, err
+}
+
+func (t *Controller) SetGuildAutoLeave(userID string, s GuildAutoLeave) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	err := s.Check()
+	if err != nil {
+		return err
+	}
+
+	err = t.db.SetGuildAutoLeave(vs.GuildID, s)
+	if err != nil {
+		return err
+	}
+
+	return t.publishToGuildUsers(vs.GuildID, Event[any]{
+		Type:    EventGuildAutoLeaveUpdated,
+		Origin:  EventSenderController,
+		Payload: s,
+	})
+}
+
+func (t *Controller) GetGuildRandomWeights(userID string) (RandomWeights, error) {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return RandomWeights{},
+			errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	w, err := t.db.GetGuildRandomWeights(vs.GuildID)
+	if err == dberrors.ErrNotFound {
+		err = nil
+	}
+
+	return w, err
+}
+
+func (t *Controller) SetGuildRandomWeights(userID string, w RandomWeights) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	w.Sanitize()
+	err := w.Check()
+	if err != nil {
+		return err
+	}
+
+	err = t.db.SetGuildRandomWeights(vs.GuildID, w)
+	if err != nil {
+		return err
+	}
+
+	return t.publishToGuildUsers(vs.GuildID, Event[any]{
+		Type:    EventGuildRandomWeightsUpdated,
+		Origin:  EventSenderController,
+		Payload: w,
+	})
+}
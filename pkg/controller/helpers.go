@@ -1,10 +1,17 @@
 package controller
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"math/rand"
+	"net/http"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,12 +20,15 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/zekrotja/yuri69/pkg/database/dberrors"
 	"github.com/zekrotja/yuri69/pkg/errs"
+	"github.com/zekrotja/yuri69/pkg/generic"
 	. "github.com/zekrotja/yuri69/pkg/models"
 	"github.com/zekrotja/yuri69/pkg/player"
 	"github.com/zekrotja/yuri69/pkg/twitch"
 	"github.com/zekrotja/yuri69/pkg/util"
 )
 
+const loudnormFilter = "loudnorm=I=-16:TP=-0.3:LRA=11"
+
 var extMappings = map[string]string{
 	".oga": ".ogg",
 	".ogv": ".ogg",
@@ -48,17 +58,76 @@ func (t *Controller) ffmpeg(
 	outTyp string,
 	args ...string,
 ) error {
+	return t.ffmpegContext(context.Background(), nil, in, inTyp, out, outTyp, args...)
+}
+
+// ffmpegContext is like ffmpeg, but the process is killed
+// when ctx is done. When progress is set, it is called with
+// the time of the output which has been transcoded so far.
+func (t *Controller) ffmpegContext(
+	ctx context.Context,
+	progress func(time.Duration),
+	in io.Reader,
+	inTyp string,
+	out io.Writer,
+	outTyp string,
+	args ...string,
+) error {
+	release, err := t.acquireFfmpeg(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	var cmdArgs []string
+	if progress != nil {
+		// The progress is written to a separate pipe, so that
+		// it does not end up in the error output.
+		cmdArgs = append(cmdArgs, "-progress", "pipe:3", "-nostats")
+	}
 	cmdArgs = append(cmdArgs, "-f", inTyp, "-i", "pipe:", "-map", "0:a:0")
 	cmdArgs = append(cmdArgs, args...)
+	if outTyp == "ogg" {
+		// Opus with 20ms frames can be streamed to Discord
+		// without re-encoding by the native player backend.
+		cmdArgs = append(cmdArgs, "-c:a", "libopus", "-ar", "48000", "-ac", "2", "-frame_duration", "20")
+	}
 	cmdArgs = append(cmdArgs, "-f", outTyp, "pipe:")
 
 	var bufStdErr bytes.Buffer
-	cmd := exec.Command(t.ffmpegExec, cmdArgs...)
+	cmd := exec.CommandContext(ctx, t.ffmpegExec, cmdArgs...)
 	cmd.Stdin = in
 	cmd.Stdout = out
 	cmd.Stderr = &bufStdErr
-	err := cmd.Run()
+
+	if progress != nil {
+		var pr, pw *os.File
+		pr, pw, err = os.Pipe()
+		if err != nil {
+			return err
+		}
+		defer pr.Close()
+		cmd.ExtraFiles = []*os.File{pw}
+
+		progressDone := make(chan struct{})
+		go func() {
+			defer close(progressDone)
+			readFfmpegProgress(pr, progress)
+		}()
+
+		err = cmd.Start()
+		pw.Close()
+		if err == nil {
+			err = cmd.Wait()
+		}
+		<-progressDone
+	} else {
+		err = cmd.Run()
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
 	if cmd.ProcessState != nil && cmd.ProcessState.ExitCode() != 0 {
 		err = errors.New(bufStdErr.String())
@@ -67,6 +136,80 @@ func (t *Controller) ffmpeg(
 	return err
 }
 
+// acquireFfmpeg blocks until less than the maximum number of
+// ffmpeg processes are running. The returned function must be
+// called when the process has finished.
+func (t *Controller) acquireFfmpeg(ctx context.Context) (func(), error) {
+	if t.ffmpegSlots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case t.ffmpegSlots <- struct{}{}:
+		return func() { <-t.ffmpegSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readFfmpegProgress reads the progress reported by ffmpeg
+// via the -progress option from r and passes the time of
+// the transcoded output to progress.
+func readFfmpegProgress(r io.Reader, progress func(time.Duration)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || key != "out_time_us" {
+			continue
+		}
+		us, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || us < 0 {
+			continue
+		}
+		progress(time.Duration(us) * time.Microsecond)
+	}
+}
+
+// processingArgs returns the ffmpeg arguments which apply the
+// given processing presets and the normalization to a sound.
+// The filters of pre are applied before the presets.
+func (t *Controller) processingArgs(presetNames []string, normalize bool, pre ...string) ([]string, error) {
+	presetsFilter, err := t.presets.Filter(presetNames...)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := pre
+	if presetsFilter != "" {
+		filters = append(filters, presetsFilter)
+	}
+	if normalize {
+		filters = append(filters, loudnormFilter)
+	}
+
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	return []string{"-af", strings.Join(filters, ",")}, nil
+}
+
+// trimFilters returns the filters which trim a sound to the
+// given range in seconds. An end of 0 keeps the sound until
+// its end.
+func trimFilters(start, end float64) []string {
+	if start <= 0 && end <= 0 {
+		return nil
+	}
+
+	atrim := fmt.Sprintf("atrim=start=%.4f", start)
+	if end > 0 {
+		atrim += fmt.Sprintf(":end=%.4f", end)
+	}
+
+	return []string{atrim, "asetpts=PTS-STARTPTS"}
+}
+
 func (t *Controller) listSoundsFiltered(tagsMust []string, tagsNot []string) ([]Sound, error) {
 	sounds, err := t.db.GetSounds()
 	if err == dberrors.ErrNotFound {
@@ -102,56 +245,138 @@ func (t *Controller) resizeHistoryBuffer() error {
 		nBuff = 10
 	}
 
-	t.history.Resize(nBuff)
-	logrus.WithField("size", nBuff).Debug("Resized history buffer")
+	t.historySize.Store(int64(nBuff))
+	t.histories.Range(func(_ string, history *generic.RingQueue[string]) bool {
+		history.Resize(nBuff)
+		return true
+	})
+	logrus.WithField("size", nBuff).Debug("Resized history buffers")
 
 	return nil
 }
 
-func (t *Controller) play(vs discordgo.VoiceState, ident string) error {
-	isExternal := strings.HasPrefix(strings.ToLower(ident), "https://")
+// getHistory returns the history of randomly played sounds
+// in the given guild.
+func (t *Controller) getHistory(guildID string) *generic.RingQueue[string] {
+	history, ok := t.histories.Load(guildID)
+	if ok {
+		return history
+	}
 
-	if !isExternal {
-		filters, err := t.db.GetGuildFilters(vs.GuildID)
-		if err != nil && err != dberrors.ErrNotFound {
-			return err
+	history = generic.NewRingQueue[string](uint(t.historySize.Load()))
+	history, _ = t.histories.LoadOrStore(guildID, history)
+	return history
+}
+
+// pickRandomSound picks a random sound from sounds which has
+// not been played recently in the guild. When weights are
+// set, sounds are picked by their weights.
+func (t *Controller) pickRandomSound(
+	guildID, userID string,
+	sounds []Sound,
+	weights RandomWeights,
+) (Sound, error) {
+	history := t.getHistory(guildID).Snapshot()
+
+	candidates := make([]Sound, 0, len(sounds))
+	for _, sound := range sounds {
+		if !util.Contains(history, sound.Uid) {
+			candidates = append(candidates, sound)
 		}
+	}
+	// Play recently played sounds anyway when all sounds
+	// have been played recently.
+	if len(candidates) == 0 {
+		candidates = sounds
+	}
 
-		if len(filters.Exclude) > 0 {
-			sound, err := t.db.GetSound(ident)
-			if err != nil {
-				return err
-			}
+	if !weights.IsWeighted() {
+		return candidates[rand.Intn(len(candidates))], nil
+	}
 
-			if util.ContainsAny(filters.Exclude, sound.Tags) {
-				return errs.WrapUserError("you are not allowed to paly excluded sounds")
+	var favorites []string
+	if weights.Favorites > 0 {
+		var err error
+		favorites, err = t.db.GetFavorites(userID)
+		if err != nil && err != dberrors.ErrNotFound {
+			return Sound{}, err
+		}
+	}
+
+	plays := make(map[string]int)
+	maxPlays := 0
+	if weights.RarelyPlayed > 0 {
+		stats, err := t.db.GetPlaybackStats(guildID, "")
+		if err != nil && err != dberrors.ErrNotFound {
+			return Sound{}, err
+		}
+		for _, s := range stats {
+			plays[s.Ident] = s.Count
+			if s.Count > maxPlays {
+				maxPlays = s.Count
 			}
 		}
 	}
 
-	volume, err := t.db.GetGuildVolume(vs.GuildID)
-	if err == dberrors.ErrNotFound {
-		err = nil
-		volume = 50
+	soundWeights := make([]float64, len(candidates))
+	sum := 0.0
+	for i, sound := range candidates {
+		soundWeights[i] = weights.Weight(sound,
+			util.Contains(favorites, sound.Uid), plays[sound.Uid], maxPlays)
+		sum += soundWeights[i]
 	}
-	if err != nil {
-		return err
+
+	r := rand.Float64() * sum
+	for i, w := range soundWeights {
+		if r < w {
+			return candidates[i], nil
+		}
+		r -= w
 	}
 
-	if err = t.pl.Init(vs.GuildID, vs.ChannelID); err != nil {
-		return err
+	return candidates[len(candidates)-1], nil
+}
+
+func (t *Controller) play(vs discordgo.VoiceState, ident string, effects []string) error {
+	return t.playFrom(vs, ident, effects, "")
+}
+
+// playFrom plays the sound or macro with the given ident. The
+// source is recorded in the playback log to tell apart plays
+// which have not been requested by a user directly.
+func (t *Controller) playFrom(vs discordgo.VoiceState, ident string, effects []string, source string) error {
+	isExternal := strings.HasPrefix(strings.ToLower(ident), "https://")
+
+	if err := t.pl.CheckEffects(effects); err != nil {
+		return wrapPlayerErr(err)
 	}
 
-	if err = t.pl.SetVolume(vs.GuildID, uint16(volume)); err != nil {
-		return err
+	if !isExternal {
+		macro, err := t.db.GetMacro(ident)
+		if err == nil {
+			return t.playMacro(vs, macro, effects, source)
+		}
+		if err != dberrors.ErrNotFound {
+			return err
+		}
+
+		if err = t.checkExcluded(vs.GuildID, ident); err != nil {
+			return err
+		}
 	}
 
+	var url string
 	if isExternal {
-		err = t.pl.Play(vs.GuildID, vs.ChannelID, ident, ident)
-	} else {
-		err = t.pl.PlaySound(vs.GuildID, vs.ChannelID, ident)
+		url = ident
 	}
 
+	err := t.playEntry(vs, QueueEntry{
+		Id:      xid.New().String(),
+		Ident:   ident,
+		UserID:  vs.UserID,
+		Added:   time.Now(),
+		Effects: effects,
+	}, url)
 	if err != nil {
 		return err
 	}
@@ -162,26 +387,116 @@ func (t *Controller) play(vs discordgo.VoiceState, ident string) error {
 		GuildID:   vs.GuildID,
 		UserID:    vs.UserID,
 		Timestamp: time.Now(),
+		Source:    source,
 	})
 }
 
-func (t *Controller) execFastTrigger(guildID, userID string) {
-	ident, err := t.db.GetUserFastTrigger(userID)
+// playEntry plays or enqueues the entry depending on the
+// queue mode of the guild.
+func (t *Controller) playEntry(vs discordgo.VoiceState, entry QueueEntry, url string) error {
+	if err := t.initPlayer(vs); err != nil {
+		return err
+	}
+
+	queueMode, err := t.getQueueMode(vs.GuildID)
+	if err != nil {
+		return err
+	}
+
+	if queueMode == QueueModeEnqueue {
+		err = t.pl.Enqueue(vs.GuildID, entry, url)
+	} else {
+		err = t.pl.Play(vs.GuildID, entry, url)
+	}
+
+	return wrapPlayerErr(err)
+}
+
+// checkExcluded returns an error when the sound is excluded
+// by the filters of the guild.
+func (t *Controller) checkExcluded(guildID, ident string) error {
+	filters, err := t.db.GetGuildFilters(guildID)
 	if err != nil && err != dberrors.ErrNotFound {
+		return err
+	}
+
+	if len(filters.Exclude) == 0 {
+		return nil
+	}
+
+	sound, err := t.db.GetSound(ident)
+	if err != nil {
+		return err
+	}
+
+	if util.ContainsAny(filters.Exclude, sound.Tags) {
+		return errs.WrapUserError("you are not allowed to paly excluded sounds")
+	}
+
+	return nil
+}
+
+// initPlayer joins the voice channel and sets the volume
+// of the guild on the player.
+func (t *Controller) initPlayer(vs discordgo.VoiceState) error {
+	volume, err := t.db.GetGuildVolume(vs.GuildID)
+	if err == dberrors.ErrNotFound {
+		err = nil
+		volume = 50
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = t.pl.Init(vs.GuildID, vs.ChannelID); err != nil {
+		return err
+	}
+
+	return t.pl.SetVolume(vs.GuildID, uint16(volume))
+}
+
+func (t *Controller) getQueueMode(guildID string) (QueueMode, error) {
+	mode, err := t.db.GetGuildQueueMode(guildID)
+	if err == dberrors.ErrNotFound {
+		err = nil
+	}
+	if mode == "" {
+		mode = QueueModeInterrupt
+	}
+	return mode, err
+}
+
+// execFastTrigger plays the sound or macro bound to the
+// gesture by the user, where bindings set for the guild take
+// precedence over the global bindings.
+func (t *Controller) execFastTrigger(guildID, userID string, gesture player.Gesture) {
+	guildTriggers, err := t.GetFastTriggers(userID, guildID)
+	if err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
 			"guildid": guildID,
 			"userid":  userID,
 		}).Error("Getting fast trigger setting failed")
 		return
 	}
+
+	globalTriggers, err := t.GetFastTriggers(userID, "")
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"guildid": guildID,
+			"userid":  userID,
+		}).Error("Getting fast trigger setting failed")
+		return
+	}
+
+	ident := guildTriggers.Merge(globalTriggers).Get(string(gesture))
 	if ident == "" {
 		return
 	}
 
 	if strings.ToLower(ident) == "random" {
-		err = t.PlayRandom(userID, nil, nil)
+		err = t.PlayRandom(userID, nil, nil, nil, nil)
 	} else {
-		err = t.Play(userID, ident)
+		err = t.Play(userID, ident, nil)
 	}
 	if err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
@@ -212,10 +527,57 @@ func (t *Controller) publishToGuildUsers(guildID string, e Event[any]) error {
 	return nil
 }
 
+func (t *Controller) setPaused(userID string, paused bool) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	var err error
+	eventType := EventPlayerPaused
+	if paused {
+		err = t.pl.Pause(vs.GuildID)
+	} else {
+		eventType = EventPlayerResumed
+		err = t.pl.Resume(vs.GuildID)
+	}
+	if err != nil {
+		return wrapPlayerErr(err)
+	}
+
+	return t.publishNowPlaying(vs.GuildID, eventType)
+}
+
+func (t *Controller) publishNowPlaying(guildID string, eventType string) error {
+	np, ok := t.pl.NowPlaying(guildID)
+	if !ok {
+		return nil
+	}
+
+	return t.publishToGuildUsers(guildID, Event[any]{
+		Type:    eventType,
+		Origin:  EventSenderController,
+		Payload: np,
+	})
+}
+
 func (t *Controller) playerEventHandler(e player.Event) {
 	switch e.Type {
 	case player.EventFastTrigger:
-		t.execFastTrigger(e.GuildID, e.UserID)
+		t.execFastTrigger(e.GuildID, e.UserID, e.Gesture)
+
+	case player.EventUserEnter:
+		t.playUserSound(e.GuildID, e.ChannelID, e.UserID, false)
+
+	case player.EventUserExit:
+		t.playUserSound(e.GuildID, e.ChannelID, e.UserID, true)
+
+	case player.EventQueueUpdated:
+		t.publishToGuildUsers(e.GuildID, Event[any]{
+			Type:    EventQueueUpdated,
+			Origin:  EventSenderPlayer,
+			Payload: t.pl.Queue(e.GuildID),
+		})
 
 	case player.EventVoiceJoin:
 		ep, err := t.getVoiceJoinPayload(e.GuildID)
@@ -285,16 +647,49 @@ func (t *Controller) getVoiceJoinPayload(guildID string) (EventVoiceJoinPayload,
 	if err != nil && err != dberrors.ErrNotFound {
 		return EventVoiceJoinPayload{}, err
 	}
+	e.QueueMode, err = t.getQueueMode(guildID)
+	if err != nil {
+		return EventVoiceJoinPayload{}, err
+	}
+	e.Queue = t.pl.Queue(guildID)
+	if np, ok := t.pl.NowPlaying(guildID); ok {
+		e.NowPlaying = &np
+	}
 
 	return e, nil
 }
 
+func wrapPlayerErr(err error) error {
+	switch err {
+	case player.ErrNoGuildPlayer:
+		return errs.WrapUserError("the player is not connected to your voice channel")
+	case player.ErrQueueEntryNotFound:
+		return errs.WrapUserError("queue entry not found", http.StatusNotFound)
+	case player.ErrNotPlaying:
+		return errs.WrapUserError("nothing is currently playing")
+	case player.ErrNotSeekable:
+		return errs.WrapUserError("the current sound can not be seeked")
+	case player.ErrSeekOutOfRange:
+		return errs.WrapUserError("seek position is out of range")
+	case player.ErrUnknownEffect:
+		return errs.WrapUserError("unknown effect")
+	case player.ErrUnsupportedSource:
+		return errs.WrapUserError("external sources are not supported by the player backend")
+	case player.ErrUnsupportedFilters:
+		return errs.WrapUserError("effects are not supported by the player backend")
+	case player.ErrNotOpus:
+		return errs.WrapUserError("the sound is not encoded with opus and can not be played by the player backend")
+	default:
+		return err
+	}
+}
+
 func (t *Controller) twitchHandler(e twitch.PlayEvent) {
 	var err error
 	if e.Sound == "" {
-		err = t.PlayRandom(e.UserID, e.Filters.Include, e.Filters.Exclude)
+		err = t.PlayRandom(e.UserID, e.Filters.Include, e.Filters.Exclude, nil, nil)
 	} else {
-		err = t.Play(e.UserID, e.Sound)
+		err = t.Play(e.UserID, e.Sound, nil)
 	}
 	if err != nil {
 		logrus.WithError(err).Error("Twitch sound play failed")
@@ -0,0 +1,304 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/zekrotja/yuri69/pkg/archive"
+	"github.com/zekrotja/yuri69/pkg/database/dberrors"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+	"github.com/zekrotja/yuri69/pkg/util"
+)
+
+// plannedImport is a file of an archive which is imported as
+// the given sound.
+type plannedImport struct {
+	name  string
+	size  int64
+	sound Sound
+}
+
+// MaxImportSize returns the maximum size in bytes of imported
+// archives or 0 when the size is not limited.
+func (t *Controller) MaxImportSize() int64 {
+	return t.sounds.MaxImportSize
+}
+
+// ImportSounds reads the uploaded archive and starts a job
+// which imports the sounds contained in it. The result of the
+// job is an ImportResult.
+//
+// Archives can either be exports containing a meta.json or
+// tar+gzip and zip archives of loose audio files. For loose
+// files, the uid is derived from the file name and the tags
+// from the names of the containing folders.
+//
+// When dryRun is true, the archive is only checked and the
+// result lists the sounds which would be imported and the
+// conflicts without writing anything.
+func (t *Controller) ImportSounds(userID string, f io.ReadCloser, mimeType string, dryRun bool) (Job, error) {
+	ok, err := t.isAdmin(userID)
+	if err != nil {
+		return Job{}, err
+	}
+	if !ok {
+		return Job{}, errs.WrapUserError(
+			"you need admin privileges import sounds")
+	}
+
+	format, ok := archive.FormatFromMime(mimeType)
+	if !ok {
+		return Job{}, errs.WrapUserError(
+			"only archives of type 'application/tar+gzip' or 'application/zip' are supported")
+	}
+
+	// The archive is read before the request finishes, so
+	// that it can be processed in the background.
+	file, err := newTempFile()
+	if err != nil {
+		return Job{}, err
+	}
+	var r io.Reader = f
+	if t.sounds.MaxImportSize > 0 {
+		r = io.LimitReader(r, t.sounds.MaxImportSize+1)
+	}
+	size, err := io.Copy(file, r)
+	if err == nil && t.sounds.MaxImportSize > 0 && size > t.sounds.MaxImportSize {
+		err = errs.WrapUserError(fmt.Sprintf(
+			"the archive exceeds the maximum import size of %s",
+			formatBytes(t.sounds.MaxImportSize)))
+	}
+	if err == nil {
+		_, err = file.rewind()
+	}
+	if err != nil {
+		file.Close()
+		return Job{}, err
+	}
+
+	return t.startJob(userID, JobTypeImportSounds, func(ctx context.Context, progress func(float64)) (any, error) {
+		defer file.Close()
+		return t.importSounds(ctx, userID, file, size, format, dryRun, progress)
+	}), nil
+}
+
+// --- Internal stuff ---
+
+// importSounds imports the sounds of the archive read from r.
+// Sounds which could not be imported are listed in the result.
+func (t *Controller) importSounds(
+	ctx context.Context,
+	userID string,
+	r io.ReaderAt,
+	size int64,
+	format archive.Format,
+	dryRun bool,
+	progress func(float64),
+) (ImportResult, error) {
+	res := ImportResult{
+		DryRun:     dryRun,
+		Successful: []string{},
+		Failed:     []SoundImportError{},
+		Sounds:     []Sound{},
+	}
+
+	var (
+		meta    []Sound
+		entries []archive.Entry
+	)
+
+	err := archive.Walk(r, size, format, func(e archive.Entry, r io.Reader) error {
+		if e.Name == "meta.json" {
+			return json.NewDecoder(r).Decode(&meta)
+		}
+		entries = append(entries, e)
+		return ctx.Err()
+	})
+	if ctx.Err() != nil {
+		return res, ctx.Err()
+	}
+	if err != nil {
+		return res, errs.WrapUserError(err)
+	}
+
+	var plan []plannedImport
+	if meta != nil {
+		plan = planExportImport(meta, entries)
+	} else {
+		plan, res.Failed = planLooseImport(userID, entries)
+	}
+
+	planned := make(map[string]Sound, len(plan))
+	uids := make(map[string]bool, len(plan))
+	for _, p := range plan {
+		err = t.checkImport(&p.sound, uids)
+		if err == nil {
+			err = t.checkUploadSize(p.size)
+		}
+		if err != nil {
+			res.Failed = append(res.Failed, SoundImportError{
+				Uid:   p.sound.Uid,
+				File:  p.name,
+				Error: err.Error(),
+			})
+			continue
+		}
+		planned[p.name] = p.sound
+		uids[p.sound.Uid] = true
+	}
+
+	if dryRun {
+		for _, p := range plan {
+			if sound, ok := planned[p.name]; ok {
+				res.Successful = append(res.Successful, sound.Uid)
+				res.Sounds = append(res.Sounds, sound)
+			}
+		}
+		return res, nil
+	}
+
+	done := 0
+	err = archive.Walk(r, size, format, func(e archive.Entry, r io.Reader) error {
+		sound, ok := planned[e.Name]
+		if !ok {
+			return nil
+		}
+
+		err := t.importSound(ctx, &sound, r)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			res.Failed = append(res.Failed, SoundImportError{
+				Uid:   sound.Uid,
+				File:  e.Name,
+				Error: err.Error(),
+			})
+		} else {
+			res.Successful = append(res.Successful, sound.Uid)
+			res.Sounds = append(res.Sounds, sound)
+		}
+
+		done++
+		progress(float64(done) / float64(len(planned)))
+		return nil
+	})
+	if ctx.Err() != nil {
+		return res, ctx.Err()
+	}
+	if err != nil {
+		return res, err
+	}
+
+	if len(res.Successful) > 0 {
+		err = t.resizeHistoryBuffer()
+	}
+
+	return res, err
+}
+
+// checkImport returns an error when the sound can not be
+// imported. uids contains the uids of the sounds which are
+// imported from the same archive.
+func (t *Controller) checkImport(sound *Sound, uids map[string]bool) error {
+	sound.Sanitize()
+
+	err := sound.Check()
+	if err != nil {
+		return err
+	}
+
+	if util.Contains(reservedUids, sound.Uid) {
+		return fmt.Errorf("UID '%s' is reserved and can not be used", sound.Uid)
+	}
+
+	if uids[sound.Uid] {
+		return errors.New("archive contains multiple sounds with this uid")
+	}
+
+	s, err := t.db.GetSound(sound.Uid)
+	if s.Uid == sound.Uid {
+		return errors.New("sound with uid already exists")
+	}
+	if err != nil && err != dberrors.ErrNotFound {
+		return err
+	}
+
+	_, err = t.db.GetMacro(sound.Uid)
+	if err == nil {
+		return errors.New("macro with uid already exists")
+	}
+	if err != dberrors.ErrNotFound {
+		return err
+	}
+
+	return nil
+}
+
+// planExportImport returns the sounds of an archive created
+// by the export, for which metadata is available.
+func planExportImport(meta []Sound, entries []archive.Entry) []plannedImport {
+	metaMap := make(map[string]Sound, len(meta))
+	for _, sound := range meta {
+		metaMap[sound.Uid] = sound
+	}
+
+	plan := make([]plannedImport, 0, len(meta))
+	for _, e := range entries {
+		if path.Dir(e.Name) != "sounds" {
+			continue
+		}
+		sound, ok := metaMap[util.CleanBase(e.Name)]
+		if !ok {
+			continue
+		}
+		plan = append(plan, plannedImport{name: e.Name, size: e.Size, sound: sound})
+	}
+
+	return plan
+}
+
+// planLooseImport returns the sounds of an archive of loose
+// audio files, for which the metadata is inferred from the
+// file paths. Files which are no audio files are returned as
+// skipped.
+func planLooseImport(userID string, entries []archive.Entry) ([]plannedImport, []SoundImportError) {
+	now := time.Now()
+
+	plan := make([]plannedImport, 0, len(entries))
+	skipped := []SoundImportError{}
+	for _, e := range entries {
+		if !archive.IsAudioFile(e.Name) {
+			skipped = append(skipped, SoundImportError{
+				File:  e.Name,
+				Error: "not an audio file",
+			})
+			continue
+		}
+
+		uid, displayName, tags := archive.InferSound(e.Name)
+		if displayName == uid {
+			displayName = ""
+		}
+
+		plan = append(plan, plannedImport{
+			name: e.Name,
+			size: e.Size,
+			sound: Sound{
+				Uid:         uid,
+				DisplayName: displayName,
+				Tags:        tags,
+				Created:     now,
+				Creator:     UserSlim{ID: userID},
+			},
+		})
+	}
+
+	return plan, skipped
+}
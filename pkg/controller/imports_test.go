@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zekrotja/yuri69/pkg/archive"
+	"github.com/zekrotja/yuri69/pkg/errs"
+)
+
+func TestImportSoundsMaxSize(t *testing.T) {
+	ct := &Controller{ownerID: "owner"}
+	ct.sounds.MaxImportSize = 10
+
+	f := io.NopCloser(strings.NewReader(strings.Repeat("a", 11)))
+	_, err := ct.ImportSounds("owner", f, "application/zip", true)
+	_, ok := errs.As[errs.UserError](err)
+	assert.True(t, ok, err)
+	assert.ErrorContains(t, err, "maximum import size")
+}
+
+func TestPlanLooseImport(t *testing.T) {
+	plan, skipped := planLooseImport("user", []archive.Entry{
+		{Name: "memes/airhorn.mp3", Size: 10},
+		{Name: "readme.txt", Size: 20},
+	})
+
+	if assert.Len(t, plan, 1) {
+		assert.Equal(t, "memes/airhorn.mp3", plan[0].name)
+		assert.Equal(t, "airhorn", plan[0].sound.Uid)
+		assert.Equal(t, "user", plan[0].sound.Creator.ID)
+	}
+	if assert.Len(t, skipped, 1) {
+		assert.Equal(t, "readme.txt", skipped[0].File)
+		assert.NotEmpty(t, skipped[0].Error)
+	}
+}
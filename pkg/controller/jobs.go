@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+)
+
+// jobRetention is the time finished jobs are kept so that
+// their result can be retrieved.
+const jobRetention = 1 * time.Hour
+
+// ListJobs returns the jobs of the user, newest first.
+func (t *Controller) ListJobs(userID string) []Job {
+	jobs := []Job{}
+	t.jobs.Range(func(_ string, j *job) bool {
+		if j.userID == userID {
+			jobs = append(jobs, j.snapshot())
+		}
+		return true
+	})
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Created.After(jobs[j].Created)
+	})
+
+	return jobs
+}
+
+// GetJob returns the job with the given ID.
+func (t *Controller) GetJob(id, userID string) (Job, error) {
+	j, err := t.getJob(id, userID)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return j.snapshot(), nil
+}
+
+// CancelJob cancels the pending or running job. Work which
+// has been finished before, like sounds which have already
+// been imported, is kept.
+func (t *Controller) CancelJob(id, userID string) (Job, error) {
+	j, err := t.getJob(id, userID)
+	if err != nil {
+		return Job{}, err
+	}
+
+	if j.snapshot().IsFinished() {
+		return Job{}, errs.WrapUserError("job has already finished", http.StatusConflict)
+	}
+
+	j.cancel()
+
+	return j.snapshot(), nil
+}
+
+// --- Internal stuff ---
+
+// jobFunc executes the work of a job. It must stop when ctx
+// is done and reports its progress in the range of [0, 1].
+type jobFunc func(ctx context.Context, progress func(float64)) (any, error)
+
+type job struct {
+	mtx sync.Mutex
+
+	Job
+	userID string
+	cancel context.CancelFunc
+}
+
+func (t *job) snapshot() Job {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.Job
+}
+
+// update applies fn to the job and returns the new state.
+func (t *job) update(fn func(j *Job)) Job {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	fn(&t.Job)
+	return t.Job
+}
+
+// startJob runs fn in the background and returns the job
+// which reports its state to the user via events.
+func (t *Controller) startJob(userID string, typ JobType, fn jobFunc) Job {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	j := &job{
+		Job: Job{
+			Id:      xid.New().String(),
+			Type:    typ,
+			UserID:  userID,
+			Status:  JobStatusPending,
+			Created: time.Now(),
+		},
+		userID: userID,
+		cancel: cancel,
+	}
+	t.jobs.Store(j.Id, j)
+	t.publishJob(j.Job)
+
+	go t.runJob(ctx, j, fn)
+
+	return j.snapshot()
+}
+
+func (t *Controller) runJob(ctx context.Context, j *job, fn jobFunc) {
+	defer j.cancel()
+
+	t.publishJob(j.update(func(j *Job) {
+		j.Status = JobStatusRunning
+	}))
+
+	// Events are only published when the progress changed by
+	// at least a percent to not flood the clients.
+	var lastProgress float64
+	progress := func(p float64) {
+		p = math.Max(0, math.Min(1, p))
+		if p-lastProgress < 0.01 {
+			return
+		}
+		lastProgress = p
+		t.publishJob(j.update(func(j *Job) {
+			j.Progress = p
+		}))
+	}
+
+	res, err := fn(ctx, progress)
+
+	state := j.update(func(j *Job) {
+		now := time.Now()
+		j.Finished = &now
+		j.Result = res
+
+		switch {
+		case errors.Is(err, context.Canceled) || (err != nil && ctx.Err() != nil):
+			j.Status = JobStatusCanceled
+		case err != nil:
+			j.Status = JobStatusFailed
+			j.Error = err.Error()
+			if statusErr, ok := errs.As[errs.StatusError](err); ok {
+				j.Error = statusErr.Message
+			}
+		default:
+			j.Status = JobStatusSucceeded
+			j.Progress = 1
+		}
+	})
+
+	if state.Status == JobStatusFailed {
+		logrus.
+			WithError(err).
+			WithField("id", state.Id).
+			WithField("type", state.Type).
+			Error("Job failed")
+	}
+
+	t.publishJob(state)
+
+	time.AfterFunc(jobRetention, func() {
+		t.jobs.LoadAndDelete(state.Id)
+	})
+}
+
+func (t *Controller) getJob(id, userID string) (*job, error) {
+	j, ok := t.jobs.Load(id)
+	if !ok || j.userID != userID {
+		return nil, errs.WrapUserError("job does not exist", http.StatusNotFound)
+	}
+	return j, nil
+}
+
+func (t *Controller) publishJob(j Job) {
+	t.Publish(ControllerEvent{
+		Receivers: []string{j.UserID},
+		Event: Event[any]{
+			Type:    EventJobUpdated,
+			Origin:  EventSenderController,
+			Payload: j,
+		},
+	})
+}
+
+// durationProgress returns a function which reports the
+// progress of transcoding audio of the given total duration.
+// The progress is mapped into the range of [from, to].
+func durationProgress(total time.Duration, from, to float64, progress func(float64)) func(time.Duration) {
+	return func(d time.Duration) {
+		if total <= 0 {
+			return
+		}
+		progress(from + (to-from)*float64(d)/float64(total))
+	}
+}
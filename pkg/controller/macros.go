@@ -0,0 +1,276 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/xid"
+	"github.com/zekrotja/yuri69/pkg/database/dberrors"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+	"github.com/zekrotja/yuri69/pkg/util"
+)
+
+func (t *Controller) CreateMacro(macro Macro, userID string) (Macro, error) {
+	macro.Sanitize()
+
+	err := macro.Check()
+	if err != nil {
+		return Macro{}, err
+	}
+
+	if util.Contains(reservedUids, macro.Uid) {
+		return Macro{}, errs.WrapUserError(
+			fmt.Sprintf("UID '%s' is reserved and can not be used", macro.Uid))
+	}
+
+	_, err = t.db.GetMacro(macro.Uid)
+	if err == nil {
+		return Macro{}, errs.WrapUserError("macro with specified ID already exists")
+	}
+	if err != dberrors.ErrNotFound {
+		return Macro{}, err
+	}
+
+	s, err := t.db.GetSound(macro.Uid)
+	if s.Uid == macro.Uid {
+		return Macro{}, errs.WrapUserError("sound with specified ID already exists")
+	}
+	if err != nil && err != dberrors.ErrNotFound {
+		return Macro{}, err
+	}
+
+	if err = t.checkMacroSteps(macro.Steps); err != nil {
+		return Macro{}, err
+	}
+
+	macro.Created = time.Now()
+	macro.Creator = UserSlim{ID: userID}
+
+	err = t.db.PutMacro(macro)
+	if err != nil {
+		return Macro{}, err
+	}
+
+	t.Publish(ControllerEvent{
+		IsBroadcast: true,
+		Event: Event[any]{
+			Type:    EventMacroCreated,
+			Origin:  EventSenderController,
+			Payload: macro,
+		},
+	})
+
+	return macro, nil
+}
+
+func (t *Controller) GetMacro(uid string) (Macro, error) {
+	macro, err := t.db.GetMacro(uid)
+	if err != nil {
+		return Macro{}, err
+	}
+
+	user, err := t.dg.GetUser(macro.Creator.ID)
+	if err != nil {
+		return Macro{}, err
+	}
+
+	macro.Creator = UserSlimFromUser(*user)
+
+	return macro, nil
+}
+
+func (t *Controller) ListMacros(order string) ([]Macro, error) {
+	macros, err := t.db.GetMacros()
+	if err == dberrors.ErrNotFound {
+		return []Macro{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if order == "" {
+		order = string(SortOrderCreated)
+	}
+
+	var less func(i, j int) bool
+
+	switch SortOrder(strings.ToLower(order)) {
+	case SortOrderName:
+		less = func(i, j int) bool {
+			return macros[i].String() < macros[j].String()
+		}
+	case SortOrderCreated:
+		less = func(i, j int) bool {
+			return macros[i].Created.After(macros[j].Created)
+		}
+	default:
+		return nil, errs.WrapUserError("invalid sort order")
+	}
+
+	sort.Slice(macros, less)
+
+	return macros, nil
+}
+
+func (t *Controller) UpdateMacro(newMacro Macro, userID string) (Macro, error) {
+	oldMacro, err := t.db.GetMacro(newMacro.Uid)
+	if err != nil {
+		return Macro{}, err
+	}
+
+	if oldMacro.Creator.ID != userID {
+		ok, err := t.isAdmin(userID)
+		if err != nil {
+			return Macro{}, err
+		}
+		if !ok {
+			return Macro{}, errs.WrapUserError(
+				"you need admin privileges to edit a macro created by another user")
+		}
+	}
+
+	newMacro.Sanitize()
+	newMacro.Uid = oldMacro.Uid
+	newMacro.Created = oldMacro.Created
+	newMacro.Creator = UserSlim{ID: oldMacro.Creator.ID}
+
+	err = newMacro.Check()
+	if err != nil {
+		return Macro{}, err
+	}
+
+	if err = t.checkMacroSteps(newMacro.Steps); err != nil {
+		return Macro{}, err
+	}
+
+	err = t.db.PutMacro(newMacro)
+	if err != nil {
+		return Macro{}, err
+	}
+
+	t.Publish(ControllerEvent{
+		IsBroadcast: true,
+		Event: Event[any]{
+			Type:    EventMacroUpdated,
+			Origin:  EventSenderController,
+			Payload: newMacro,
+		},
+	})
+
+	return newMacro, nil
+}
+
+func (t *Controller) RemoveMacro(uid, userID string) error {
+	macro, err := t.db.GetMacro(uid)
+	if err != nil {
+		return err
+	}
+
+	if macro.Creator.ID != userID {
+		ok, err := t.isAdmin(userID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errs.WrapUserError(
+				"you need admin privileges to remove a macro created by another user")
+		}
+	}
+
+	err = t.db.RemoveMacro(uid)
+	if err != nil {
+		return err
+	}
+
+	t.Publish(ControllerEvent{
+		IsBroadcast: true,
+		Event: Event[any]{
+			Type:    EventMacroDeleted,
+			Origin:  EventSenderController,
+			Payload: macro,
+		},
+	})
+
+	return nil
+}
+
+// --- Internal stuff ---
+
+// checkMacroSteps ensures that all steps refer to existing
+// sounds. Macros can not be nested.
+func (t *Controller) checkMacroSteps(steps []MacroStep) error {
+	for _, step := range steps {
+		s, err := t.db.GetSound(step.Ident)
+		if err == dberrors.ErrNotFound || err == nil && s.Uid != step.Ident {
+			return errs.WrapUserError(
+				fmt.Sprintf("sound '%s' of step does not exist", step.Ident))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// playMacro plays the steps of the macro one after another.
+// Depending on the queue mode of the guild, the steps either
+// replace the currently played sound or are added to the queue.
+func (t *Controller) playMacro(
+	vs discordgo.VoiceState,
+	macro Macro,
+	effects []string,
+	source string,
+) error {
+	for _, step := range macro.Steps {
+		if err := t.checkExcluded(vs.GuildID, step.Ident); err != nil {
+			return err
+		}
+	}
+
+	if err := t.initPlayer(vs); err != nil {
+		return err
+	}
+
+	queueMode, err := t.getQueueMode(vs.GuildID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	entries := make([]QueueEntry, 0, len(macro.Steps))
+	for _, step := range macro.Steps {
+		entries = append(entries, QueueEntry{
+			Id:      xid.New().String(),
+			Ident:   step.Ident,
+			UserID:  vs.UserID,
+			Added:   now,
+			Effects: effects,
+			Delay:   step.Delay,
+			Volume:  step.Volume,
+			Macro:   macro.Uid,
+		})
+	}
+
+	if queueMode == QueueModeEnqueue {
+		err = t.pl.EnqueueAll(vs.GuildID, entries)
+	} else {
+		err = t.pl.PlayAll(vs.GuildID, entries)
+	}
+
+	if err != nil {
+		return wrapPlayerErr(err)
+	}
+
+	return t.db.PutPlaybackLog(PlaybackLogEntry{
+		Id:        xid.New().String(),
+		Ident:     macro.Uid,
+		GuildID:   vs.GuildID,
+		UserID:    vs.UserID,
+		Timestamp: now,
+		Source:    source,
+	})
+}
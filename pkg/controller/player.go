@@ -1,12 +1,11 @@
 package controller
 
 import (
-	"math/rand"
+	"time"
 
 	"github.com/zekrotja/yuri69/pkg/database/dberrors"
 	"github.com/zekrotja/yuri69/pkg/errs"
 	. "github.com/zekrotja/yuri69/pkg/models"
-	"github.com/zekrotja/yuri69/pkg/util"
 )
 
 func (t *Controller) JoinChannel(userID string) error {
@@ -30,16 +29,25 @@ func (t *Controller) LeaveChannel(guildID, userID string) error {
 	return t.pl.Destroy(guildID)
 }
 
-func (t *Controller) Play(userID, ident string) error {
+func (t *Controller) Play(userID, ident string, effects []string) error {
 	vs, ok := t.dg.FindUserVS(userID)
 	if !ok {
 		return errs.WrapUserError("you need to be in a voice channel to perform this action")
 	}
 
-	return t.play(vs, ident)
+	return t.play(vs, ident, effects)
 }
 
-func (t *Controller) PlayRandom(userID string, tagsMust []string, tagsNot []string) error {
+// PlayRandom plays a random sound matching the given tags in
+// the voice channel of the user. When weights is nil, the
+// random weights of the guild are used.
+func (t *Controller) PlayRandom(
+	userID string,
+	tagsMust []string,
+	tagsNot []string,
+	effects []string,
+	weights *RandomWeights,
+) error {
 	vs, ok := t.dg.FindUserVS(userID)
 	if !ok {
 		return errs.WrapUserError("you need to be in a voice channel to perform this action")
@@ -61,25 +69,29 @@ func (t *Controller) PlayRandom(userID string, tagsMust []string, tagsNot []stri
 		return nil
 	}
 
-	historySnapshot := t.history.Snapshot()
-
-	var sound Sound
-	// If no sound could be picked which is not in the history
-	// in 10 tries, play the last randomly picked sound anyway
-	// to prevent long wait times.
-	for i := 0; i < 10; i++ {
-		rng := rand.Intn(len(sounds))
-		sound = sounds[rng]
-		if !util.Contains(historySnapshot, sound.Uid) {
-			break
+	if weights == nil {
+		guildWeights, err := t.db.GetGuildRandomWeights(vs.GuildID)
+		if err != nil && err != dberrors.ErrNotFound {
+			return err
+		}
+		weights = &guildWeights
+	} else {
+		weights.Sanitize()
+		if err = weights.Check(); err != nil {
+			return err
 		}
 	}
 
-	if err = t.play(vs, sound.Uid); err != nil {
+	sound, err := t.pickRandomSound(vs.GuildID, userID, sounds, *weights)
+	if err != nil {
+		return err
+	}
+
+	if err = t.play(vs, sound.Uid, effects); err != nil {
 		return nil
 	}
 
-	t.history.Enqueue(sound.Uid)
+	t.getHistory(vs.GuildID).Enqueue(sound.Uid)
 	return nil
 }
 
@@ -92,6 +104,53 @@ func (t *Controller) Stop(userID string) error {
 	return t.pl.Stop(vs.GuildID)
 }
 
+func (t *Controller) Pause(userID string) error {
+	return t.setPaused(userID, true)
+}
+
+func (t *Controller) Resume(userID string) error {
+	return t.setPaused(userID, false)
+}
+
+func (t *Controller) Seek(userID string, position int64) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	err := t.pl.Seek(vs.GuildID, time.Duration(position)*time.Millisecond)
+	if err != nil {
+		return wrapPlayerErr(err)
+	}
+
+	return t.publishNowPlaying(vs.GuildID, EventPlayerSeeked)
+}
+
+func (t *Controller) GetEffects() []string {
+	return t.pl.Effects()
+}
+
+// GetPresets returns the names of the processing presets
+// which can be applied when creating sounds.
+func (t *Controller) GetPresets() []string {
+	return t.presets.Names()
+}
+
+func (t *Controller) GetPlayerState(userID string) (PlayerState, error) {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return PlayerState{}, errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	var res PlayerState
+	res.Joined = t.pl.HasPlayer(vs.GuildID)
+	if np, ok := t.pl.NowPlaying(vs.GuildID); ok {
+		res.NowPlaying = &np
+	}
+
+	return res, nil
+}
+
 func (t *Controller) GetVolume(userID string) (int, error) {
 	vs, ok := t.dg.FindUserVS(userID)
 	if !ok {
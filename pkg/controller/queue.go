@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+)
+
+func (t *Controller) GetQueue(userID string) ([]QueueEntry, error) {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return nil, errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	return t.pl.Queue(vs.GuildID), nil
+}
+
+func (t *Controller) MoveQueueEntry(userID, id string, position int) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	return wrapPlayerErr(t.pl.MoveQueueEntry(vs.GuildID, id, position))
+}
+
+func (t *Controller) RemoveQueueEntry(userID, id string) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	return wrapPlayerErr(t.pl.RemoveQueueEntry(vs.GuildID, id))
+}
+
+func (t *Controller) ClearQueue(userID string) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	return wrapPlayerErr(t.pl.ClearQueue(vs.GuildID))
+}
+
+func (t *Controller) SkipQueue(userID string) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	return wrapPlayerErr(t.pl.Skip(vs.GuildID))
+}
+
+func (t *Controller) GetQueueMode(userID string) (QueueMode, error) {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return "", errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	return t.getQueueMode(vs.GuildID)
+}
+
+func (t *Controller) SetQueueMode(userID string, mode QueueMode) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	if err := mode.Check(); err != nil {
+		return err
+	}
+
+	if err := t.db.SetGuildQueueMode(vs.GuildID, mode); err != nil {
+		return err
+	}
+
+	return t.publishToGuildUsers(vs.GuildID, Event[any]{
+		Type:    EventQueueModeUpdated,
+		Origin:  EventSenderController,
+		Payload: QueueModeRequest{Mode: mode},
+	})
+}
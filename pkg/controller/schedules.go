@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/xid"
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/database/dberrors"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+	"github.com/zekrotja/yuri69/pkg/scheduler"
+)
+
+// ListSchedules returns the schedules of the guild the user
+// is currently connected to.
+func (t *Controller) ListSchedules(userID string) ([]Schedule, error) {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return nil, errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	schedules, err := t.db.GetSchedules()
+	if err != nil && err != dberrors.ErrNotFound {
+		return nil, err
+	}
+
+	now := time.Now()
+	res := make([]Schedule, 0, len(schedules))
+	for _, s := range schedules {
+		if s.GuildID == vs.GuildID {
+			res = append(res, withNextRun(s, now))
+		}
+	}
+
+	return res, nil
+}
+
+// GetSchedule returns the schedule when it belongs to the
+// guild the user is currently connected to.
+func (t *Controller) GetSchedule(userID, id string) (Schedule, error) {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return Schedule{}, errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	s, err := t.db.GetSchedule(id)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	// Schedules of other guilds are handled as if they did
+	// not exist, so that their IDs are not disclosed.
+	if s.GuildID != vs.GuildID {
+		return Schedule{}, dberrors.ErrNotFound
+	}
+
+	return withNextRun(s, time.Now()), nil
+}
+
+// CreateSchedule creates a schedule in the guild the user is
+// currently connected to. When no channel is specified, the
+// sound is played in the current voice channel of the user.
+func (t *Controller) CreateSchedule(userID string, s Schedule) (Schedule, error) {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return Schedule{}, errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	s.Id = xid.New().String()
+	s.GuildID = vs.GuildID
+	s.Created = time.Now()
+	s.Creator = UserSlim{ID: userID}
+	s.LastRun = nil
+	s.NextRun = nil
+	if s.ChannelID == "" {
+		s.ChannelID = vs.ChannelID
+	}
+
+	if err := t.checkSchedule(s); err != nil {
+		return Schedule{}, err
+	}
+
+	if err := t.db.PutSchedule(s); err != nil {
+		return Schedule{}, err
+	}
+
+	return withNextRun(s, time.Now()), nil
+}
+
+func (t *Controller) UpdateSchedule(userID string, newSchedule Schedule) (Schedule, error) {
+	oldSchedule, err := t.db.GetSchedule(newSchedule.Id)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if err = t.checkScheduleOwner(userID, oldSchedule); err != nil {
+		return Schedule{}, err
+	}
+
+	newSchedule.GuildID = oldSchedule.GuildID
+	newSchedule.Created = oldSchedule.Created
+	newSchedule.Creator = oldSchedule.Creator
+	newSchedule.LastRun = oldSchedule.LastRun
+	newSchedule.NextRun = nil
+	if newSchedule.ChannelID == "" {
+		newSchedule.ChannelID = oldSchedule.ChannelID
+	}
+
+	// A re-scheduled one-time schedule must be executed
+	// again even if it has already run.
+	if newSchedule.At != nil &&
+		(oldSchedule.At == nil || !newSchedule.At.Equal(*oldSchedule.At)) {
+		newSchedule.LastRun = nil
+	}
+
+	if err = t.checkSchedule(newSchedule); err != nil {
+		return Schedule{}, err
+	}
+
+	if err = t.db.PutSchedule(newSchedule); err != nil {
+		return Schedule{}, err
+	}
+
+	return withNextRun(newSchedule, time.Now()), nil
+}
+
+func (t *Controller) RemoveSchedule(userID, id string) error {
+	s, err := t.db.GetSchedule(id)
+	if err != nil {
+		return err
+	}
+
+	if err = t.checkScheduleOwner(userID, s); err != nil {
+		return err
+	}
+
+	return t.db.RemoveSchedule(id)
+}
+
+// RunSchedule plays the sound of the schedule in its voice
+// channel. When the schedule must only be played if users
+// are in the channel and the channel is empty, nothing is
+// played.
+func (t *Controller) RunSchedule(s Schedule) error {
+	if s.OnlyIfOccupied {
+		users, err := t.dg.UsersInVoiceChannel(s.GuildID, s.ChannelID)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			logrus.WithField("id", s.Id).Debug("Skipping schedule because channel is empty")
+			return nil
+		}
+	}
+
+	return t.playFrom(discordgo.VoiceState{
+		GuildID:   s.GuildID,
+		ChannelID: s.ChannelID,
+		UserID:    s.Creator.ID,
+	}, s.Ident, nil, "schedule:"+s.Id)
+}
+
+// --- Internal stuff ---
+
+func (t *Controller) checkSchedule(s Schedule) error {
+	if err := s.Check(); err != nil {
+		return err
+	}
+
+	ch, err := t.dg.Session().State.Channel(s.ChannelID)
+	if err != nil || ch.GuildID != s.GuildID || ch.Type != discordgo.ChannelTypeGuildVoice {
+		return errs.WrapUserError("channel must be a voice channel of the guild")
+	}
+
+	_, err = t.db.GetMacro(s.Ident)
+	if err == nil {
+		return nil
+	}
+	if err != dberrors.ErrNotFound {
+		return err
+	}
+
+	sound, err := t.db.GetSound(s.Ident)
+	if err == dberrors.ErrNotFound || err == nil && sound.Uid != s.Ident {
+		return errs.WrapUserError("sound or macro does not exist")
+	}
+
+	return err
+}
+
+func (t *Controller) checkScheduleOwner(userID string, s Schedule) error {
+	if s.Creator.ID == userID {
+		return nil
+	}
+
+	ok, err := t.isAdmin(userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.WrapUserError(
+			"you need admin privileges to modify a schedule created by another user")
+	}
+
+	return nil
+}
+
+func withNextRun(s Schedule, now time.Time) Schedule {
+	s.NextRun = nil
+	if next, ok := scheduler.NextRun(s, now); ok {
+		s.NextRun = &next
+	}
+	return s
+}
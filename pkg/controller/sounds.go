@@ -2,9 +2,10 @@ package controller
 
 import (
 	"archive/tar"
-	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,9 +15,9 @@ import (
 	"time"
 
 	"github.com/gabriel-vasile/mimetype"
-	"github.com/kkdai/youtube/v2"
 	"github.com/rs/xid"
 	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/analysis"
 	"github.com/zekrotja/yuri69/pkg/database/dberrors"
 	"github.com/zekrotja/yuri69/pkg/errs"
 	. "github.com/zekrotja/yuri69/pkg/models"
@@ -29,91 +30,91 @@ func (t *Controller) UploadSound(
 	size int64,
 	mimeType string,
 ) (string, time.Time, error) {
-	var ext string
 	var d time.Time
 
-	buff, err := io.ReadAll(r)
+	err := t.checkUploadSize(size)
 	if err != nil {
 		return "", d, err
 	}
 
-	m, err := mimetype.DetectReader(bytes.NewReader(buff))
+	f, err := t.spoolUpload(r)
 	if err != nil {
 		return "", d, err
 	}
-	ext = mapExt(m.Extension())
-	mimeType = m.String()
+	defer f.Close()
 
 	id := xid.New().String()
-	err = t.st.PutObject(static.BucketTemp, id, bytes.NewReader(buff), size, mimeType)
+	d, err = t.storePendingUpload(id, f)
 	if err != nil {
 		return "", d, err
 	}
 
-	const lifetime = 5 * time.Minute
-	t.pendingCrations.Set(id, ext[1:], lifetime, func(v string) {
-		t.st.DeleteObject(static.BucketTemp, id)
-	})
-	d = time.Now().Add(lifetime)
 	return id, d, nil
 }
 
-func (t *Controller) CreateSound(req CreateSoundRequest) (Sound, error) {
-	req.Sanitize()
-
-	err := req.Check()
+// CreateSound checks the request and starts a job which
+// creates the sound from the uploaded file.
+func (t *Controller) CreateSound(req CreateSoundRequest) (Job, error) {
+	err := t.checkCreateSoundRequest(&req)
 	if err != nil {
-		return Sound{}, err
+		return Job{}, err
 	}
 
-	req.Uid = strings.ToLower(req.Uid)
-	if util.Contains(reservedUids, req.Uid) {
-		return Sound{}, errs.WrapUserError(
-			fmt.Sprintf("UID '%s' is reserved and can not be used", req.Uid))
+	upload := t.pendingCrations.GetValue(req.UploadId)
+	if upload.typ == "" {
+		return Job{}, errs.WrapUserError("no sound was uploaded or has been expired")
 	}
 
-	s, err := t.db.GetSound(req.Uid)
-	if s.Uid == req.Uid {
-		return Sound{}, errs.WrapUserError("sound with specified ID already exists")
-	}
-	if err != nil && err != dberrors.ErrNotFound {
-		return Sound{}, err
+	args, err := t.processingArgs(req.PresetNames(), req.Normalize)
+	if err != nil {
+		return Job{}, err
 	}
 
-	typ := t.pendingCrations.GetValue(req.UploadId)
-	if typ == "" {
-		return Sound{}, errs.WrapUserError("no sound was uploaded or has been expired")
-	}
+	// The upload is claimed by the job, so that it does not
+	// expire while the job is pending.
+	t.pendingCrations.Remove(req.UploadId)
 
-	r, _, err := t.st.GetObject(static.BucketTemp, req.UploadId)
-	if err != nil {
-		return Sound{}, err
-	}
-	defer func() {
-		r.Close()
-		t.st.DeleteObject(static.BucketTemp, req.UploadId)
-		t.pendingCrations.Remove(req.UploadId)
-	}()
+	return t.startJob(req.Creator.ID, JobTypeCreateSound, func(ctx context.Context, progress func(float64)) (any, error) {
+		defer t.st.DeleteObject(static.BucketTemp, req.UploadId)
 
-	var args []string
-	if req.Normalize {
-		args = append(args, "-af", "loudnorm=I=-16:TP=-0.3:LRA=11")
-	}
+		r, _, err := t.st.GetObject(static.BucketTemp, req.UploadId)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
 
-	var buf bytes.Buffer
-	err = t.ffmpeg(r, typ, &buf, "ogg", args...)
-	if err != nil {
-		return Sound{}, err
-	}
+		out, err := newTempFile()
+		if err != nil {
+			return nil, err
+		}
+		defer out.Close()
 
-	req.Created = time.Now()
-	err = t.createSound(req.Sound, &buf, int64(buf.Len()))
-	if err != nil {
-		return Sound{}, err
-	}
+		err = t.ffmpegContext(ctx, durationProgress(upload.duration, 0, 1, progress),
+			r, upload.typ, out, "ogg", args...)
+		if err != nil {
+			return nil, err
+		}
 
-	err = t.resizeHistoryBuffer()
-	return req.Sound, err
+		size, err := out.rewind()
+		if err != nil {
+			return nil, err
+		}
+
+		req.Created = time.Now()
+		err = t.createSound(&req.Sound, out, size)
+		if err != nil {
+			return nil, err
+		}
+
+		original, size, err := t.st.GetObject(static.BucketTemp, req.UploadId)
+		if err == nil {
+			t.storeOriginal(req.Uid, original, size)
+			original.Close()
+		}
+
+		err = t.resizeHistoryBuffer()
+		return req.Sound, err
+	}), nil
 }
 
 func (t *Controller) GetSound(uid string) (Sound, error) {
@@ -166,6 +167,10 @@ func (t *Controller) ListSounds(
 		less = func(i, j int) bool {
 			return sounds[i].Created.After(sounds[j].Created)
 		}
+	case SortOrderDuration:
+		less = func(i, j int) bool {
+			return sounds[i].Duration() > sounds[j].Duration()
+		}
 	default:
 		return nil, errs.WrapUserError("invalid sort order")
 	}
@@ -195,7 +200,10 @@ func (t *Controller) UpdateSound(newSound UpdateSoundRequest, userID string) (So
 	newSound.Created = oldSound.Created
 	newSound.Creator.ID = oldSound.Creator.ID
 	newSound.Uid = oldSound.Uid
+	newSound.Audio = oldSound.Audio
 
+	// Only the metadata is changed, so no version is kept,
+	// which would hold a copy of the unchanged audio.
 	err = t.db.PutSound(newSound.Sound)
 	if err != nil {
 		return Sound{}, err
@@ -213,144 +221,189 @@ func (t *Controller) UpdateSound(newSound UpdateSoundRequest, userID string) (So
 	return newSound.Sound, nil
 }
 
-func (t *Controller) RemoveSound(id, userID string) error {
-	sound, err := t.db.GetSound(id)
+// EditSound checks the request and starts a job which
+// re-renders the sound from its original source with the
+// given trim range, presets and normalization and replaces
+// the current audio of the sound with the result. The result
+// of the job is the edited sound.
+func (t *Controller) EditSound(uid string, req EditSoundRequest, userID string) (Job, error) {
+	sound, err := t.db.GetSound(uid)
 	if err != nil {
-		return err
+		return Job{}, err
 	}
 
-	if sound.Creator.ID != userID {
-		ok, err := t.isAdmin(userID)
-		if err != nil {
-			return err
-		}
-		if !ok {
-			return errs.WrapUserError(
-				"you need admin privileges to remove a sound created by another user")
-		}
+	err = t.checkSoundOwnership(sound, userID, "edit")
+	if err != nil {
+		return Job{}, err
 	}
 
-	err = t.db.RemoveSound(id)
+	err = req.Check()
 	if err != nil {
-		return err
+		return Job{}, err
 	}
 
-	err = t.st.DeleteObject(static.BucketSounds, id)
+	args, err := t.processingArgs(req.Presets, req.Normalize,
+		trimFilters(req.StartTimeSeconds, req.EndTimeSeconds)...)
 	if err != nil {
-		return err
+		return Job{}, err
 	}
 
-	t.Publish(ControllerEvent{
-		IsBroadcast: true,
-		Event: Event[any]{
-			Type:    EventSoundDeleted,
-			Origin:  EventSenderController,
-			Payload: sound,
-		},
-	})
-
-	err = t.resizeHistoryBuffer()
-	return err
-}
-
-func (t *Controller) GetSoundFromYoutube(req CreateSoundRequest) (Sound, error) {
-	if req.YouTube.URL == "" {
-		return Sound{}, errs.WrapUserError("YouTube URL is empty")
+	r, _, err := t.st.GetObject(static.BucketOriginals, uid)
+	if err != nil {
+		return Job{}, errs.WrapUserError("the original of the sound is not available")
 	}
-	if req.YouTube.EndTimeSeconds > 0 && req.YouTube.StartTimeSeconds > req.YouTube.EndTimeSeconds {
-		return Sound{}, errs.WrapUserError("'end_time_seconds' must be larger than 'start_time_seconds'")
+	original, err := newTempFile()
+	if err == nil {
+		_, err = io.Copy(original, r)
+	}
+	r.Close()
+	if err == nil {
+		_, err = original.rewind()
 	}
-
-	req.Sanitize()
-
-	err := req.Check()
 	if err != nil {
-		return Sound{}, err
+		if original != nil {
+			original.Close()
+		}
+		return Job{}, err
 	}
 
-	req.Uid = strings.ToLower(req.Uid)
-	if util.Contains(reservedUids, req.Uid) {
-		return Sound{}, errs.WrapUserError(
-			fmt.Sprintf("UID '%s' is reserved and can not be used", req.Uid))
+	typ, err := mimetype.DetectReader(original)
+	if err == nil && typ.Extension() == "" {
+		err = errs.WrapUserError("could not detect mime type of the original sound")
+	}
+	if err != nil {
+		original.Close()
+		return Job{}, err
 	}
 
-	s, err := t.db.GetSound(req.Uid)
-	if s.Uid == req.Uid {
-		return Sound{}, errs.WrapUserError("sound with specified ID already exists")
+	duration, err := t.fileDuration(context.Background(), original.Name())
+	if err != nil {
+		original.Close()
+		return Job{}, err
 	}
-	if err != nil && err != dberrors.ErrNotFound {
-		return Sound{}, err
+	duration = trimmedDuration(duration, req.StartTimeSeconds, req.EndTimeSeconds)
+	err = t.checkDuration(duration)
+	if err != nil {
+		original.Close()
+		return Job{}, err
 	}
 
-	client := youtube.Client{}
-	video, err := client.GetVideo(req.YouTube.URL)
+	return t.startJob(userID, JobTypeEditSound, func(ctx context.Context, progress func(float64)) (any, error) {
+		defer original.Close()
+
+		if _, err := original.rewind(); err != nil {
+			return nil, err
+		}
+
+		out, err := newTempFile()
+		if err != nil {
+			return nil, err
+		}
+		defer out.Close()
+
+		err = t.ffmpegContext(ctx, durationProgress(duration, 0, 1, progress),
+			original, mapExt(typ.Extension())[1:], out, "ogg", args...)
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := out.rewind()
+		if err != nil {
+			return nil, err
+		}
+
+		// The sound is fetched again so that changes made
+		// while it has been transcoded are not overwritten.
+		sound, err := t.db.GetSound(uid)
+		if err != nil {
+			return nil, err
+		}
+
+		return t.replaceEditedSound(sound, out, size, userID)
+	}), nil
+}
+
+// replaceEditedSound replaces the audio of the sound with the
+// edited audio read from r after a version of the current
+// state has been kept.
+func (t *Controller) replaceEditedSound(sound Sound, r io.Reader, size int64, userID string) (Sound, error) {
+	err := t.snapshotSound(sound, userID, SoundChangeEdit)
 	if err != nil {
 		return Sound{}, err
 	}
 
-	formats := video.Formats.WithAudioChannels()
-	if len(formats) == 0 {
-		return Sound{}, errs.WrapUserError("the provided video does not have any audio streams")
-	}
-	formats.Sort()
-	format := &formats[0]
-	stream, _, err := client.GetStream(video, format)
+	// Objects are replaced atomically, so that the sound can
+	// be played while it is being edited.
+	err = t.st.PutObject(static.BucketSounds, sound.Uid, r, size, static.SoundsMime)
 	if err != nil {
 		return Sound{}, err
 	}
 
-	var args []string
-	if req.Normalize {
-		args = append(args, "-af", "loudnorm=I=-16:TP=-0.3:LRA=11")
+	sound.Audio = t.analyzeSound(sound.Uid)
+	err = t.db.PutSound(sound)
+	if err != nil {
+		return Sound{}, err
 	}
 
-	if req.YouTube.StartTimeSeconds > 0 || req.YouTube.EndTimeSeconds > 0 {
-		args = append(args, "-ss", fmt.Sprintf("%.4f", req.YouTube.StartTimeSeconds))
-	}
-	if req.YouTube.EndTimeSeconds > 0 {
-		args = append(args, "-t", fmt.Sprintf("%.4f",
-			req.YouTube.EndTimeSeconds-req.YouTube.StartTimeSeconds))
+	t.Publish(ControllerEvent{
+		IsBroadcast: true,
+		Event: Event[any]{
+			Type:    EventSoundUpdated,
+			Origin:  EventSenderController,
+			Payload: sound,
+		},
+	})
+
+	return sound, nil
+}
+
+func (t *Controller) RemoveSound(id, userID string) error {
+	sound, err := t.db.GetSound(id)
+	if err != nil {
+		return err
 	}
 
-	mtyp := mimetype.Lookup(strings.SplitN(format.MimeType, ";", 2)[0])
-	if len(formats) == 0 {
-		return Sound{}, errs.WrapUserError(
-			fmt.Sprintf("could not match any mime type to the extracted stream (%s)", format.MimeType))
+	if sound.Creator.ID != userID {
+		ok, err := t.isAdmin(userID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errs.WrapUserError(
+				"you need admin privileges to remove a sound created by another user")
+		}
 	}
-	var buf bytes.Buffer
-	err = t.ffmpeg(stream, mtyp.Extension()[1:], &buf, "ogg", args...)
+
+	err = t.db.RemoveSound(id)
 	if err != nil {
-		return Sound{}, err
+		return err
 	}
 
-	err = t.st.PutObject(static.BucketSounds, req.Uid, &buf, int64(buf.Len()), static.SoundsMime)
+	err = t.st.DeleteObject(static.BucketSounds, id)
 	if err != nil {
-		return Sound{}, err
+		return err
 	}
 
-	req.Sound.Created = time.Now()
-	err = t.db.PutSound(req.Sound)
+	// Sounds created before originals have been kept do not
+	// have an original.
+	t.st.DeleteObject(static.BucketOriginals, id)
+
+	err = t.pruneSoundVersions(id, 0)
 	if err != nil {
-		stErr := t.st.DeleteObject(static.BucketSounds, req.Uid)
-		if stErr != nil {
-			logrus.
-				WithError(stErr).
-				WithField("id", req.Uid).Error("Failed removing temp uploaded sound")
-		}
-		return Sound{}, err
+		return err
 	}
 
 	t.Publish(ControllerEvent{
 		IsBroadcast: true,
 		Event: Event[any]{
-			Type:    EventSoundCreated,
+			Type:    EventSoundDeleted,
 			Origin:  EventSenderController,
-			Payload: req.Sound,
+			Payload: sound,
 		},
 	})
 
 	err = t.resizeHistoryBuffer()
-	return req.Sound, err
+	return err
 }
 
 func (t *Controller) DownloadAllSounds() (rc io.ReadCloser, err error) {
@@ -437,129 +490,146 @@ func (t *Controller) DownloadAllSounds() (rc io.ReadCloser, err error) {
 	return rc, nil
 }
 
-func (t *Controller) ImportSounds(userID string, f io.ReadCloser, mimeType string) (ImportResult, error) {
+// --- helpers ---
+
+// checkCreateSoundRequest sanitizes and checks the request
+// and returns an error when a sound with the UID can not be
+// created.
+func (t *Controller) checkCreateSoundRequest(req *CreateSoundRequest) error {
+	req.Sanitize()
+
+	err := req.Check()
+	if err != nil {
+		return err
+	}
+
+	req.Uid = strings.ToLower(req.Uid)
+	if util.Contains(reservedUids, req.Uid) {
+		return errs.WrapUserError(
+			fmt.Sprintf("UID '%s' is reserved and can not be used", req.Uid))
+	}
+
+	s, err := t.db.GetSound(req.Uid)
+	if s.Uid == req.Uid {
+		return errs.WrapUserError("sound with specified ID already exists")
+	}
+	if err != nil && err != dberrors.ErrNotFound {
+		return err
+	}
+
+	return nil
+}
+
+// checkSoundOwnership returns an error when the user neither
+// created the sound nor is an admin.
+func (t *Controller) checkSoundOwnership(sound Sound, userID, action string) error {
+	if sound.Creator.ID == userID {
+		return nil
+	}
+
 	ok, err := t.isAdmin(userID)
 	if err != nil {
-		return ImportResult{}, err
+		return err
 	}
 	if !ok {
-		return ImportResult{}, errs.WrapUserError(
-			"you need admin privileges import sounds")
+		return errs.WrapUserError(fmt.Sprintf(
+			"you need admin privileges to %s a sound created by another user", action))
 	}
 
-	if !strings.HasPrefix(mimeType, "application/tar+gzip") &&
-		!strings.HasPrefix(mimeType, "application/gzip") &&
-		!strings.HasPrefix(mimeType, "application/x-gzip") {
-		return ImportResult{}, errs.WrapUserError(
-			"currently, only archives of type 'application/tar+gzip' are supported")
+	return nil
+}
+
+// importSound transcodes the sound file read from r and
+// creates the sound with the given metadata.
+func (t *Controller) importSound(ctx context.Context, meta *Sound, r io.Reader) error {
+	in, err := t.spoolUpload(r)
+	if err != nil {
+		return err
 	}
+	defer in.Close()
 
-	gzr, err := gzip.NewReader(f)
+	typ, err := mimetype.DetectReader(in)
 	if err != nil {
-		return ImportResult{}, err
+		return err
+	}
+	if typ.Extension() == "" {
+		return errors.New("could not detect mime type from file stream")
 	}
 
-	tarr := tar.NewReader(gzr)
-	res := ImportResult{}
-	var metaMap map[string]Sound
-	var inBuff, outBuff bytes.Buffer
-	copyBuf := make([]byte, 16*1024)
+	err = t.checkFileDuration(ctx, in.Name())
+	if err != nil {
+		return err
+	}
 
-	for {
-		header, err := tarr.Next()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return ImportResult{}, err
-		}
+	if _, err = in.rewind(); err != nil {
+		return err
+	}
 
-		if header.Name == "meta.json" {
-			var meta []Sound
-			err = json.NewDecoder(tarr).Decode(&meta)
-			if err != nil {
-				return ImportResult{}, errs.WrapUserError(err)
-			}
-			metaMap = make(map[string]Sound)
-			for _, sound := range meta {
-				metaMap[sound.Uid] = sound
-			}
-			continue
-		}
+	out, err := newTempFile()
+	if err != nil {
+		return err
+	}
+	defer out.Close()
 
-		if path.Dir(header.Name) == "sounds" {
-			if metaMap == nil {
-				return ImportResult{}, errs.WrapUserError("no metadata found")
-			}
-
-			uid := util.CleanBase(header.Name)
-			meta, ok := metaMap[uid]
-			if !ok {
-				continue
-			}
-
-			_, err = t.db.GetSound(uid)
-			if err == nil {
-				res.Failed = append(res.Failed, SoundImportError{
-					Uid:   uid,
-					Error: "sound with uid already exists",
-				})
-				continue
-			}
-
-			inBuff.Reset()
-			_, err := io.CopyBuffer(&inBuff, tarr, copyBuf)
-			if err != nil {
-				res.Failed = append(res.Failed, SoundImportError{
-					Uid:   uid,
-					Error: err.Error(),
-				})
-				continue
-			}
-
-			typ := mimetype.Detect(inBuff.Bytes())
-			if typ == nil {
-				res.Failed = append(res.Failed, SoundImportError{
-					Uid:   uid,
-					Error: "could not detect mime type from file stream",
-				})
-				continue
-			}
-
-			outBuff.Reset()
-			err = t.ffmpeg(&inBuff, mapExt(typ.Extension())[1:], &outBuff, "ogg")
-			if err != nil {
-				res.Failed = append(res.Failed, SoundImportError{
-					Uid:   uid,
-					Error: err.Error(),
-				})
-				continue
-			}
-
-			err = t.createSound(meta, &outBuff, int64(outBuff.Len()))
-			if err != nil {
-				res.Failed = append(res.Failed, SoundImportError{
-					Uid:   uid,
-					Error: err.Error(),
-				})
-				continue
-			}
-
-			res.Successful = append(res.Successful, uid)
-		}
+	err = t.ffmpegContext(ctx, nil, in, mapExt(typ.Extension())[1:], out, "ogg")
+	if err != nil {
+		return err
+	}
+
+	size, err := out.rewind()
+	if err != nil {
+		return err
 	}
 
-	return res, nil
+	err = t.createSound(meta, out, size)
+	if err != nil {
+		return err
+	}
+
+	size, err = in.rewind()
+	if err == nil {
+		t.storeOriginal(meta.Uid, in, size)
+	}
+
+	return nil
 }
 
-// --- helpers ---
+// storeOriginal keeps the source of a sound, from which the
+// sound can be re-rendered when it is edited.
+func (t *Controller) storeOriginal(uid string, r io.Reader, size int64) {
+	err := t.st.PutObject(static.BucketOriginals, uid, r, size, static.OriginalsMime)
+	if err != nil {
+		logrus.WithError(err).WithField("uid", uid).Error("Storing original sound failed")
+	}
+}
+
+func (t *Controller) createSound(sound *Sound, r io.Reader, size int64) (err error) {
+	_, err = t.db.GetMacro(sound.Uid)
+	if err == nil {
+		return errs.WrapUserError("macro with specified ID already exists")
+	}
+	if err != dberrors.ErrNotFound {
+		return err
+	}
+
+	// Sounds are created in jobs, so a sound with the same ID
+	// might have been created since the request was checked.
+	s, err := t.db.GetSound(sound.Uid)
+	if s.Uid == sound.Uid {
+		return errs.WrapUserError("sound with specified ID already exists")
+	}
+	if err != nil && err != dberrors.ErrNotFound {
+		return err
+	}
 
-func (t *Controller) createSound(sound Sound, r io.Reader, size int64) (err error) {
 	err = t.st.PutObject(static.BucketSounds, sound.Uid, r, size, static.SoundsMime)
 	if err != nil {
 		return err
 	}
 
-	err = t.db.PutSound(sound)
+	sound.Audio = t.analyzeSound(sound.Uid)
+
+	err = t.db.PutSound(*sound)
 	if err != nil {
 		stErr := t.st.DeleteObject(static.BucketSounds, sound.Uid)
 		if stErr != nil {
@@ -575,9 +645,82 @@ func (t *Controller) createSound(sound Sound, r io.Reader, size int64) (err erro
 		Event: Event[any]{
 			Type:    EventSoundCreated,
 			Origin:  EventSenderController,
-			Payload: sound,
+			Payload: *sound,
 		},
 	})
 
 	return nil
 }
+
+// analyzeSound returns the audio info of the stored sound or
+// nil when the analysis failed.
+func (t *Controller) analyzeSound(uid string) *AudioInfo {
+	release, err := t.acquireFfmpeg(context.Background())
+	if err != nil {
+		return nil
+	}
+	defer release()
+
+	r, _, err := t.st.GetObject(static.BucketSounds, uid)
+	if err != nil {
+		logrus.WithError(err).WithField("uid", uid).Error("Failed reading sound for analysis")
+		return nil
+	}
+	defer r.Close()
+
+	info, err := analysis.Analyze(t.ffmpegExec, r, "ogg")
+	if err != nil {
+		logrus.WithError(err).WithField("uid", uid).Error("Failed analyzing sound")
+		return nil
+	}
+
+	return &info
+}
+
+// backfillAudioInfo analyzes all sounds which have been
+// created before sounds were analyzed on creation. It stops
+// when the controller is closed.
+func (t *Controller) backfillAudioInfo() {
+	sounds, err := t.db.GetSounds()
+	if err != nil {
+		logrus.WithError(err).Error("Failed listing sounds for audio analysis backfill")
+		return
+	}
+
+	var n int
+	for _, sound := range sounds {
+		if sound.Audio != nil {
+			continue
+		}
+
+		select {
+		case <-t.stop:
+			return
+		default:
+		}
+
+		info := t.analyzeSound(sound.Uid)
+		if info == nil {
+			continue
+		}
+
+		// The sound is fetched again so that changes made
+		// during the analysis are not overwritten.
+		sound, err = t.db.GetSound(sound.Uid)
+		if err != nil || sound.Uid == "" || sound.Audio != nil {
+			continue
+		}
+
+		sound.Audio = info
+		err = t.db.PutSound(sound)
+		if err != nil {
+			logrus.WithError(err).WithField("uid", sound.Uid).Error("Failed storing sound audio info")
+			continue
+		}
+		n++
+	}
+
+	if n > 0 {
+		logrus.WithField("n", n).Info("Backfilled sound audio info")
+	}
+}
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+	"github.com/zekrotja/yuri69/pkg/sources"
+)
+
+// GetSources returns the names of the available source
+// providers.
+func (t *Controller) GetSources() []string {
+	return t.sources.Names()
+}
+
+// GetSoundFromSource checks the request and starts a job
+// which fetches the audio from the source and creates the
+// sound from it.
+func (t *Controller) GetSoundFromSource(req CreateSoundRequest) (Job, error) {
+	if req.Source == nil && req.YouTube != nil {
+		req.Source = &SoundSource{
+			Provider:         sources.ProviderYouTube,
+			URL:              req.YouTube.URL,
+			StartTimeSeconds: req.YouTube.StartTimeSeconds,
+			EndTimeSeconds:   req.YouTube.EndTimeSeconds,
+		}
+	}
+	if req.Source == nil {
+		return Job{}, errs.WrapUserError("no source was specified")
+	}
+	src := *req.Source
+
+	err := src.Check()
+	if err != nil {
+		return Job{}, err
+	}
+
+	err = t.checkCreateSoundRequest(&req)
+	if err != nil {
+		return Job{}, err
+	}
+
+	provider, u, err := t.sources.Resolve(src.Provider, src.URL)
+	if err != nil {
+		return Job{}, err
+	}
+
+	// The source is trimmed before the presets are applied,
+	// so that e.g. reversing the sound does not affect the
+	// range.
+	args, err := t.processingArgs(req.PresetNames(), req.Normalize,
+		trimFilters(src.StartTimeSeconds, src.EndTimeSeconds)...)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return t.startJob(req.Creator.ID, JobTypeCreateSound, func(ctx context.Context, progress func(float64)) (any, error) {
+		audio, err := provider.Fetch(ctx, u, t.sounds.MaxUploadSize)
+		if err != nil {
+			return nil, err
+		}
+		defer audio.Close()
+
+		// Sources of which the duration is known are checked
+		// before they are downloaded.
+		if audio.Duration > 0 {
+			err = t.checkDuration(trimmedDuration(audio.Duration, src.StartTimeSeconds, src.EndTimeSeconds))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = t.checkUploadSize(audio.Size)
+		if err != nil {
+			return nil, err
+		}
+
+		// The whole source is kept as original, so that the
+		// sound can be re-cut afterwards.
+		original, err := t.spoolUpload(newProgressReader(ctx, audio, audio.Size, 0, 0.5, progress))
+		if err != nil {
+			return nil, err
+		}
+		defer original.Close()
+
+		typ := audio.Type
+		if typ == "" {
+			m, err := mimetype.DetectReader(original)
+			if err != nil {
+				return nil, err
+			}
+			if mapExt(m.Extension()) == "" {
+				return nil, errs.WrapUserError("could not detect the type of the source audio")
+			}
+			typ = mapExt(m.Extension())[1:]
+		}
+
+		duration := audio.Duration
+		if duration == 0 {
+			duration, err = t.fileDuration(ctx, original.Name())
+			if err != nil {
+				return nil, err
+			}
+			err = t.checkDuration(trimmedDuration(duration, src.StartTimeSeconds, src.EndTimeSeconds))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err = original.rewind(); err != nil {
+			return nil, err
+		}
+
+		out, err := newTempFile()
+		if err != nil {
+			return nil, err
+		}
+		defer out.Close()
+
+		err = t.ffmpegContext(ctx, durationProgress(trimmedDuration(duration, src.StartTimeSeconds, src.EndTimeSeconds), 0.5, 1, progress),
+			original, typ, out, "ogg", args...)
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := out.rewind()
+		if err != nil {
+			return nil, err
+		}
+
+		req.Sound.Created = time.Now()
+		err = t.createSound(&req.Sound, out, size)
+		if err != nil {
+			return nil, err
+		}
+
+		size, err = original.rewind()
+		if err == nil {
+			t.storeOriginal(req.Uid, original, size)
+		}
+
+		err = t.resizeHistoryBuffer()
+		return req.Sound, err
+	}), nil
+}
+
+// --- Internal stuff ---
+
+// trimmedDuration returns the duration of the range between
+// start and end which is kept from audio of the total
+// duration. It returns 0 when the total duration is not known
+// and the range has no end.
+func trimmedDuration(total time.Duration, start, end float64) time.Duration {
+	if end > 0 {
+		endDuration := secondsToDuration(end)
+		if total > 0 && endDuration > total {
+			endDuration = total
+		}
+		return endDuration - secondsToDuration(start)
+	}
+	if total == 0 {
+		return 0
+	}
+	return total - secondsToDuration(start)
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+	"github.com/zekrotja/yuri69/pkg/static"
+)
+
+// tempSoundLifetime is the time after which a spoken sound
+// is removed from the storage. It must be long enough for
+// the sound to be played even when it has been enqueued.
+const tempSoundLifetime = 1 * time.Hour
+
+// GetSoundFromTTS checks the request and starts a job which
+// creates the sound from the spoken text.
+func (t *Controller) GetSoundFromTTS(req CreateSoundRequest) (Job, error) {
+	err := t.checkCreateSoundRequest(&req)
+	if err != nil {
+		return Job{}, err
+	}
+
+	args, err := t.processingArgs(req.PresetNames(), req.Normalize)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return t.startJob(req.Creator.ID, JobTypeCreateSound, func(ctx context.Context, progress func(float64)) (any, error) {
+		out, wav, err := t.synthesize(ctx, *req.TTS, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer out.Close()
+		defer wav.Close()
+
+		size, err := out.rewind()
+		if err != nil {
+			return nil, err
+		}
+
+		req.Created = time.Now()
+		err = t.createSound(&req.Sound, out, size)
+		if err != nil {
+			return nil, err
+		}
+
+		size, err = wav.rewind()
+		if err == nil {
+			t.storeOriginal(req.Uid, wav, size)
+		}
+
+		err = t.resizeHistoryBuffer()
+		return req.Sound, err
+	}), nil
+}
+
+// Say speaks the given text in the voice channel of the user
+// without saving it as sound.
+func (t *Controller) Say(userID string, req SayRequest) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	if err := t.pl.CheckEffects(req.Effects); err != nil {
+		return wrapPlayerErr(err)
+	}
+
+	var args []string
+	if req.Normalize {
+		args = append(args, "-af", loudnormFilter)
+	}
+
+	out, wav, err := t.synthesize(context.Background(), req.TTS, args...)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	wav.Close()
+
+	size, err := out.rewind()
+	if err != nil {
+		return err
+	}
+
+	// Spoken sounds are kept in the temp bucket, so that they
+	// do not end up between the saved sounds when they are
+	// not removed, for example because of a crash.
+	ident := static.TempSoundPrefix + xid.New().String()
+	err = t.st.PutObject(static.BucketTemp, ident, out, size, static.SoundsMime)
+	if err != nil {
+		return err
+	}
+
+	t.tempSounds.Set(ident, struct{}{}, tempSoundLifetime, func(struct{}) {
+		if err := t.st.DeleteObject(static.BucketTemp, ident); err != nil {
+			logrus.WithError(err).WithField("id", ident).Error("Failed removing temp sound")
+		}
+	})
+
+	return t.playEntry(vs, QueueEntry{
+		Id:      xid.New().String(),
+		Ident:   ident,
+		UserID:  vs.UserID,
+		Added:   time.Now(),
+		Effects: req.Effects,
+	}, "")
+}
+
+// --- Internal stuff ---
+
+// synthesize speaks the text and returns the speech encoded
+// as ogg and the unencoded speech as WAV. args are passed to
+// ffmpeg on encoding. Both files must be closed by the caller.
+func (t *Controller) synthesize(ctx context.Context, req TTS, args ...string) (*tempFile, *tempFile, error) {
+	if t.tts == nil {
+		return nil, nil, errs.WrapUserError("text to speech is not available")
+	}
+
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		return nil, nil, errs.WrapUserError("text must not be empty")
+	}
+	if maxLength := t.tts.MaxLength(); maxLength > 0 && len([]rune(req.Text)) > maxLength {
+		return nil, nil, errs.WrapUserError(
+			fmt.Sprintf("text must not be longer than %d characters", maxLength))
+	}
+
+	wav, err := newTempFile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = t.tts.Synthesize(ctx, req.Text, req.Voice, wav)
+	if err == nil {
+		_, err = wav.rewind()
+	}
+	if err != nil {
+		wav.Close()
+		return nil, nil, err
+	}
+
+	out, err := newTempFile()
+	if err != nil {
+		wav.Close()
+		return nil, nil, err
+	}
+
+	err = t.ffmpegContext(ctx, nil, wav, "wav", out, "ogg", args...)
+	if err != nil {
+		wav.Close()
+		out.Close()
+		return nil, nil, err
+	}
+
+	return out, wav, nil
+}
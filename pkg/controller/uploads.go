@@ -0,0 +1,314 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	"github.com/zekrotja/yuri69/pkg/static"
+)
+
+var durationRx = regexp.MustCompile(`Duration: (\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+
+// MaxUploadSize returns the maximum size in bytes of uploaded
+// sound files or 0 when the size is not limited.
+func (t *Controller) MaxUploadSize() int64 {
+	return t.sounds.MaxUploadSize
+}
+
+// --- Internal stuff ---
+
+// pendingUpload is an uploaded file from which a sound can
+// be created.
+type pendingUpload struct {
+	typ      string
+	duration time.Duration
+}
+
+// tempFile is a local temporary file which is removed when
+// it is closed.
+type tempFile struct {
+	*os.File
+}
+
+func newTempFile() (*tempFile, error) {
+	f, err := os.CreateTemp("", "yuri69-*")
+	if err != nil {
+		return nil, err
+	}
+	return &tempFile{File: f}, nil
+}
+
+// rewind seeks to the start of the file and returns its size.
+func (t *tempFile) rewind() (int64, error) {
+	_, err := t.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	stat, err := t.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return stat.Size(), nil
+}
+
+func (t *tempFile) Close() error {
+	t.File.Close()
+	err := os.Remove(t.Name())
+	if err != nil {
+		logrus.WithError(err).WithField("name", t.Name()).Error("Failed removing temp file")
+	}
+	return err
+}
+
+// storePendingUpload checks the uploaded file and stores it
+// with the given id, so that a sound can be created from it
+// until the returned deadline.
+func (t *Controller) storePendingUpload(id string, f *tempFile) (time.Time, error) {
+	var d time.Time
+
+	if _, err := f.rewind(); err != nil {
+		return d, err
+	}
+
+	m, err := mimetype.DetectReader(f)
+	if err != nil {
+		return d, err
+	}
+	ext := mapExt(m.Extension())
+	if ext == "" {
+		return d, errs.WrapUserError("could not detect the type of the uploaded file")
+	}
+
+	duration, err := t.fileDuration(context.Background(), f.Name())
+	if err != nil {
+		return d, err
+	}
+	err = t.checkDuration(duration)
+	if err != nil {
+		return d, err
+	}
+
+	size, err := f.rewind()
+	if err != nil {
+		return d, err
+	}
+
+	err = t.st.PutObject(static.BucketTemp, id, f, size, m.String())
+	if err != nil {
+		return d, err
+	}
+
+	const lifetime = 5 * time.Minute
+	upload := pendingUpload{typ: ext[1:], duration: duration}
+	t.pendingCrations.Set(id, upload, lifetime, func(v pendingUpload) {
+		t.st.DeleteObject(static.BucketTemp, id)
+	})
+	d = time.Now().Add(lifetime)
+	return d, nil
+}
+
+// spoolFile copies r into a temporary file and seeks to its
+// start.
+func spoolFile(r io.Reader) (*tempFile, error) {
+	f, err := newTempFile()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.Copy(f, r)
+	if err == nil {
+		_, err = f.rewind()
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// spoolUpload copies r into a temporary file, so that large
+// uploads are not held in memory. It fails with a user error
+// when r exceeds the maximum upload size.
+func (t *Controller) spoolUpload(r io.Reader) (*tempFile, error) {
+	f, err := newTempFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if t.sounds.MaxUploadSize > 0 {
+		r = io.LimitReader(r, t.sounds.MaxUploadSize+1)
+	}
+
+	n, err := io.Copy(f, r)
+	if err == nil {
+		err = t.checkUploadSize(n)
+	}
+	if err == nil {
+		_, err = f.rewind()
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// checkUploadSize returns a user error when size exceeds the
+// maximum upload size.
+func (t *Controller) checkUploadSize(size int64) error {
+	if t.sounds.MaxUploadSize > 0 && size > t.sounds.MaxUploadSize {
+		return errs.WrapUserError(fmt.Sprintf(
+			"the file exceeds the maximum upload size of %s",
+			formatBytes(t.sounds.MaxUploadSize)))
+	}
+	return nil
+}
+
+// checkDuration returns a user error when d exceeds the
+// maximum sound duration.
+func (t *Controller) checkDuration(d time.Duration) error {
+	if t.sounds.MaxDuration > 0 && d > t.sounds.MaxDuration {
+		return errs.WrapUserError(fmt.Sprintf(
+			"the sound exceeds the maximum duration of %s", t.sounds.MaxDuration))
+	}
+	return nil
+}
+
+// checkFileDuration probes the duration of the audio file
+// before it is transcoded and returns a user error when it
+// exceeds the maximum sound duration.
+func (t *Controller) checkFileDuration(ctx context.Context, name string) error {
+	if t.sounds.MaxDuration <= 0 {
+		return nil
+	}
+
+	d, err := t.fileDuration(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return t.checkDuration(d)
+}
+
+// fileDuration probes the duration of the audio file. When a
+// maximum sound duration is configured, files of which the
+// duration can not be determined are rejected. Otherwise, 0
+// is returned for them.
+func (t *Controller) fileDuration(ctx context.Context, name string) (time.Duration, error) {
+	d, ok := t.probeDuration(ctx, name)
+	if ok || t.sounds.MaxDuration <= 0 {
+		return d, nil
+	}
+
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	return 0, errs.WrapUserError("the duration of the sound could not be determined")
+}
+
+// probeDuration reads the duration of the audio file from the
+// input information printed by ffmpeg.
+func (t *Controller) probeDuration(ctx context.Context, name string) (time.Duration, bool) {
+	release, err := t.acquireFfmpeg(ctx)
+	if err != nil {
+		return 0, false
+	}
+	defer release()
+
+	var stderr bytes.Buffer
+	// Without an output, ffmpeg only prints the information
+	// about the input and exits with an error.
+	cmd := exec.CommandContext(ctx, t.ffmpegExec, "-hide_banner", "-i", name)
+	cmd.Stderr = &stderr
+	cmd.Run()
+
+	m := durationRx.FindStringSubmatch(stderr.String())
+	if m == nil {
+		return 0, false
+	}
+
+	hours, _ := strconv.Atoi(m[1])
+	minutes, _ := strconv.Atoi(m[2])
+	seconds, _ := strconv.ParseFloat(m[3], 64)
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)), true
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// progressReader reports the progress of reading size bytes
+// from r mapped into the range of [from, to]. Reading fails
+// when ctx is done.
+type progressReader struct {
+	ctx      context.Context
+	r        io.Reader
+	n        int64
+	size     int64
+	from, to float64
+	progress func(float64)
+}
+
+func newProgressReader(
+	ctx context.Context,
+	r io.Reader,
+	size int64,
+	from, to float64,
+	progress func(float64),
+) *progressReader {
+	return &progressReader{
+		ctx:      ctx,
+		r:        r,
+		size:     size,
+		from:     from,
+		to:       to,
+		progress: progress,
+	}
+}
+
+func (t *progressReader) Read(p []byte) (int, error) {
+	if err := t.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := t.r.Read(p)
+	t.n += int64(n)
+	if t.size > 0 {
+		t.progress(t.from + (t.to-t.from)*float64(t.n)/float64(t.size))
+	}
+
+	return n, err
+}
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zekrotja/yuri69/pkg/errs"
+)
+
+func TestFileDurationUnknown(t *testing.T) {
+	// Without an ffmpeg executable, no duration can be probed.
+	ct := &Controller{}
+
+	d, err := ct.fileDuration(context.Background(), "sound.mp3")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), d)
+	assert.Nil(t, ct.checkFileDuration(context.Background(), "sound.mp3"))
+
+	ct.sounds.MaxDuration = time.Minute
+
+	_, err = ct.fileDuration(context.Background(), "sound.mp3")
+	_, ok := errs.As[errs.UserError](err)
+	assert.True(t, ok, err)
+	err = ct.checkFileDuration(context.Background(), "sound.mp3")
+	_, ok = errs.As[errs.UserError](err)
+	assert.True(t, ok, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ct.fileDuration(ctx, "sound.mp3")
+	assert.ErrorIs(t, err, context.Canceled)
+}
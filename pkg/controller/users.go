@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"strings"
+
 	"github.com/zekrotja/yuri69/pkg/cryptoutil"
 	"github.com/zekrotja/yuri69/pkg/database/dberrors"
 	"github.com/zekrotja/yuri69/pkg/errs"
@@ -19,6 +21,49 @@ func (t *Controller) SetFastTrigger(userID, ident string) error {
 	return t.db.SetUserFastTrigger(userID, ident)
 }
 
+// GetFastTriggers returns the sounds or macros bound to the
+// fast trigger gestures of the user. When guildID is empty,
+// the global bindings are returned.
+func (t *Controller) GetFastTriggers(userID, guildID string) (FastTriggers, error) {
+	s, err := t.db.GetUserFastTriggers(userID, guildID)
+	if err != nil && err != dberrors.ErrNotFound {
+		return FastTriggers{}, err
+	}
+
+	if guildID == "" {
+		// The global mute toggle binding is the fast trigger
+		// which has been set before gestures were introduced.
+		s.Mute, err = t.GetFastTrigger(userID)
+		if err != nil {
+			return FastTriggers{}, err
+		}
+	}
+
+	return s, nil
+}
+
+// SetFastTriggers binds sounds or macros to the fast trigger
+// gestures of the user. When guildID is empty, the bindings
+// are set globally, otherwise they override the global
+// bindings in the guild.
+func (t *Controller) SetFastTriggers(userID, guildID string, s FastTriggers) error {
+	for _, ident := range []string{s.Mute, s.Deafen, s.Double} {
+		if err := t.checkFastTriggerIdent(ident); err != nil {
+			return err
+		}
+	}
+
+	if guildID == "" {
+		err := t.db.SetUserFastTrigger(userID, s.Mute)
+		if err != nil {
+			return err
+		}
+		s.Mute = ""
+	}
+
+	return t.db.SetUserFastTriggers(userID, guildID, s)
+}
+
 func (t *Controller) GetFavorites(userID string) ([]string, error) {
 	favs, err := t.db.GetFavorites(userID)
 	if err == dberrors.ErrNotFound {
@@ -134,3 +179,19 @@ func (t *Controller) LeaveTwitch(userid string) error {
 
 	return t.tw.Leave(setting.TwitchUserName)
 }
+
+// --- Internal stuff ---
+
+func (t *Controller) checkFastTriggerIdent(ident string) error {
+	if ident == "" || strings.ToLower(ident) == "random" {
+		return nil
+	}
+
+	_, err := t.db.GetSound(ident)
+	if err != dberrors.ErrNotFound {
+		return err
+	}
+
+	_, err = t.db.GetMacro(ident)
+	return err
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/database/dberrors"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+)
+
+var defaultGuildUserSounds = GuildUserSounds{
+	Enabled:         true,
+	CooldownSeconds: 60,
+}
+
+// GetUserSounds returns the entrance and exit sounds of the
+// user. When guildID is empty, the global sounds are returned.
+func (t *Controller) GetUserSounds(userID, guildID string) (UserSounds, error) {
+	s, err := t.db.GetUserSounds(userID, guildID)
+	if err == dberrors.ErrNotFound {
+		err = nil
+	}
+	return s, err
+}
+
+// SetUserSounds sets the entrance and exit sounds of the
+// user. When guildID is empty, the sounds are set globally,
+// otherwise they override the global sounds in the guild.
+func (t *Controller) SetUserSounds(userID, guildID string, s UserSounds) error {
+	for _, ident := range []string{s.Entrance, s.Exit} {
+		if ident == "" {
+			continue
+		}
+		if _, err := t.db.GetSound(ident); err != nil {
+			return err
+		}
+	}
+
+	return t.db.SetUserSounds(userID, guildID, s)
+}
+
+func (t *Controller) GetGuildUserSounds(userID string) (GuildUserSounds, error) {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return GuildUserSounds{},
+			errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	return t.getGuildUserSounds(vs.GuildID)
+}
+
+func (t *Controller) SetGuildUserSounds(userID string, s GuildUserSounds) error {
+	vs, ok := t.dg.FindUserVS(userID)
+	if !ok {
+		return errs.WrapUserError("you need to be in a voice channel to perform this action")
+	}
+
+	err := s.Check()
+	if err != nil {
+		return err
+	}
+
+	err = t.db.SetGuildUserSounds(vs.GuildID, s)
+	if err != nil {
+		return err
+	}
+
+	return t.publishToGuildUsers(vs.GuildID, Event[any]{
+		Type:    EventGuildUserSoundsUpdated,
+		Origin:  EventSenderController,
+		Payload: s,
+	})
+}
+
+// --- Internal stuff ---
+
+func (t *Controller) getGuildUserSounds(guildID string) (GuildUserSounds, error) {
+	s, err := t.db.GetGuildUserSounds(guildID)
+	if err == dberrors.ErrNotFound {
+		return defaultGuildUserSounds, nil
+	}
+	return s, err
+}
+
+// getEffectiveUserSounds returns the sounds of the user in
+// the given guild, falling back to the global sounds.
+func (t *Controller) getEffectiveUserSounds(userID, guildID string) (UserSounds, error) {
+	guildSounds, err := t.GetUserSounds(userID, guildID)
+	if err != nil {
+		return UserSounds{}, err
+	}
+
+	globalSounds, err := t.GetUserSounds(userID, "")
+	if err != nil {
+		return UserSounds{}, err
+	}
+
+	return guildSounds.Merge(globalSounds), nil
+}
+
+// playUserSound plays the entrance or exit sound of the user
+// in the given voice channel when user sounds are enabled in
+// the guild and the cooldown of the user has passed.
+func (t *Controller) playUserSound(guildID, channelID, userID string, exit bool) {
+	log := logrus.WithFields(logrus.Fields{
+		"guildid": guildID,
+		"userid":  userID,
+		"exit":    exit,
+	})
+
+	settings, err := t.getGuildUserSounds(guildID)
+	if err != nil {
+		log.WithError(err).Error("Getting guild user sounds settings failed")
+		return
+	}
+	if !settings.Enabled {
+		return
+	}
+
+	cooldownKey := guildID + ":" + userID
+	if t.userSoundCooldowns.Contains(cooldownKey) {
+		return
+	}
+
+	sounds, err := t.getEffectiveUserSounds(userID, guildID)
+	if err != nil {
+		log.WithError(err).Error("Getting user sounds failed")
+		return
+	}
+
+	ident := sounds.Entrance
+	if exit {
+		ident = sounds.Exit
+	}
+	if ident == "" {
+		return
+	}
+
+	if settings.CooldownSeconds > 0 {
+		t.userSoundCooldowns.Set(cooldownKey, struct{}{},
+			time.Duration(settings.CooldownSeconds)*time.Second)
+	}
+
+	err = t.play(discordgo.VoiceState{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		UserID:    userID,
+	}, ident, nil)
+	if err != nil {
+		log.WithError(err).WithField("ident", ident).Error("Playing user sound failed")
+	}
+}
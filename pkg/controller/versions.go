@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"io"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/database/dberrors"
+	. "github.com/zekrotja/yuri69/pkg/models"
+	"github.com/zekrotja/yuri69/pkg/static"
+)
+
+// ListSoundVersions returns the previous versions of the
+// sound, newest first.
+func (t *Controller) ListSoundVersions(uid string) ([]SoundVersion, error) {
+	_, err := t.db.GetSound(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := t.db.GetSoundVersions(uid)
+	if err != nil && err != dberrors.ErrNotFound {
+		return nil, err
+	}
+	if versions == nil {
+		versions = []SoundVersion{}
+	}
+
+	return versions, nil
+}
+
+// GetSoundVersionReader returns the audio of the given
+// version of the sound.
+func (t *Controller) GetSoundVersionReader(uid, id string) (io.ReadCloser, int64, error) {
+	_, err := t.db.GetSoundVersion(uid, id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return t.st.GetObject(static.BucketVersions, id)
+}
+
+// RollbackSound restores the audio and metadata of the sound
+// from the given version. The current state of the sound is
+// kept as version as well.
+func (t *Controller) RollbackSound(uid, id, userID string) (Sound, error) {
+	sound, err := t.db.GetSound(uid)
+	if err != nil {
+		return Sound{}, err
+	}
+
+	err = t.checkSoundOwnership(sound, userID, "roll back")
+	if err != nil {
+		return Sound{}, err
+	}
+
+	version, err := t.db.GetSoundVersion(uid, id)
+	if err != nil {
+		return Sound{}, err
+	}
+
+	// The audio of the version is read before the current
+	// state is kept, because keeping it might prune the
+	// version which is restored.
+	r, _, err := t.st.GetObject(static.BucketVersions, id)
+	if err != nil {
+		return Sound{}, err
+	}
+	audio, err := spoolFile(r)
+	r.Close()
+	if err != nil {
+		return Sound{}, err
+	}
+	defer audio.Close()
+
+	err = t.snapshotSound(sound, userID, SoundChangeRollback)
+	if err != nil {
+		return Sound{}, err
+	}
+
+	size, err := audio.rewind()
+	if err != nil {
+		return Sound{}, err
+	}
+
+	err = t.st.PutObject(static.BucketSounds, uid, audio, size, static.SoundsMime)
+	if err != nil {
+		return Sound{}, err
+	}
+
+	restored := version.Sound
+	restored.Uid = sound.Uid
+	restored.Created = sound.Created
+	restored.Creator.ID = sound.Creator.ID
+	restored.Audio = t.analyzeSound(uid)
+
+	err = t.db.PutSound(restored)
+	if err != nil {
+		return Sound{}, err
+	}
+
+	t.Publish(ControllerEvent{
+		IsBroadcast: true,
+		Event: Event[any]{
+			Type:    EventSoundUpdated,
+			Origin:  EventSenderController,
+			Payload: restored,
+		},
+	})
+
+	return restored, nil
+}
+
+// --- Internal stuff ---
+
+// snapshotSound keeps the current audio and metadata of the
+// sound as version before its audio is replaced by the user.
+// Versions exceeding the configured retention are removed.
+func (t *Controller) snapshotSound(sound Sound, userID string, change SoundChange) error {
+	if t.sounds.VersionRetention <= 0 {
+		return nil
+	}
+
+	r, size, err := t.st.GetObject(static.BucketSounds, sound.Uid)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	version := SoundVersion{
+		Id:      xid.New().String(),
+		Sound:   sound,
+		Created: time.Now(),
+		Change:  change,
+	}
+	version.Creator.ID = userID
+
+	err = t.st.PutObject(static.BucketVersions, version.Id, r, size, static.SoundsMime)
+	if err != nil {
+		return err
+	}
+
+	err = t.db.PutSoundVersion(version)
+	if err != nil {
+		t.st.DeleteObject(static.BucketVersions, version.Id)
+		return err
+	}
+
+	return t.pruneSoundVersions(sound.Uid, t.sounds.VersionRetention)
+}
+
+// pruneSoundVersions removes all but the latest keep
+// versions of the sound.
+func (t *Controller) pruneSoundVersions(uid string, keep int) error {
+	versions, err := t.db.GetSoundVersions(uid)
+	if err == dberrors.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(versions) <= keep {
+		return nil
+	}
+
+	for _, version := range versions[keep:] {
+		err = t.db.RemoveSoundVersion(uid, version.Id)
+		if err != nil {
+			return err
+		}
+
+		err = t.st.DeleteObject(static.BucketVersions, version.Id)
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("uid", uid).
+				WithField("version", version.Id).
+				Error("Failed removing sound version audio")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zekrotja/eventbus"
+	"github.com/zekrotja/yuri69/pkg/database"
+	"github.com/zekrotja/yuri69/pkg/database/dberrors"
+	"github.com/zekrotja/yuri69/pkg/database/nuts"
+	. "github.com/zekrotja/yuri69/pkg/models"
+	"github.com/zekrotja/yuri69/pkg/static"
+	"github.com/zekrotja/yuri69/pkg/storage"
+)
+
+func newVersionsController(t *testing.T, retention int) *Controller {
+	db, err := nuts.NewNuts(nuts.NutsConfig{Location: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	st, err := storage.NewFile(storage.FileConfig{BasePath: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Controller{
+		EventBus: eventbus.New[ControllerEvent](),
+		db:       db,
+		st:       lazyStorage{st},
+		sounds:   SoundsConfig{VersionRetention: retention},
+	}
+}
+
+// lazyStorage opens objects only when they are read, like
+// object stores which stream the object from the server.
+type lazyStorage struct {
+	storage.IStorage
+}
+
+func (t lazyStorage) GetObject(bucketName, objectName string) (io.ReadCloser, int64, error) {
+	r, size, err := t.IStorage.GetObject(bucketName, objectName)
+	if err != nil {
+		return nil, 0, err
+	}
+	r.Close()
+
+	return &lazyReader{open: func() (io.ReadCloser, error) {
+		r, _, err := t.IStorage.GetObject(bucketName, objectName)
+		return r, err
+	}}, size, nil
+}
+
+type lazyReader struct {
+	open func() (io.ReadCloser, error)
+	r    io.ReadCloser
+}
+
+func (t *lazyReader) Read(p []byte) (int, error) {
+	if t.r == nil {
+		r, err := t.open()
+		if err != nil {
+			return 0, err
+		}
+		t.r = r
+	}
+	return t.r.Read(p)
+}
+
+func (t *lazyReader) Close() error {
+	if t.r == nil {
+		return nil
+	}
+	return t.r.Close()
+}
+
+// putSoundAudio stores the given audio as the current audio
+// of the sound.
+func putSoundAudio(t *testing.T, ct *Controller, uid, audio string) {
+	err := ct.st.PutObject(static.BucketSounds, uid,
+		strings.NewReader(audio), int64(len(audio)), static.SoundsMime)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// updateSound keeps the current state of the sound as version
+// and replaces its audio.
+func updateSound(t *testing.T, ct *Controller, sound Sound, audio string) {
+	err := ct.snapshotSound(sound, sound.Creator.ID, SoundChangeEdit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	putSoundAudio(t, ct, sound.Uid, audio)
+}
+
+func readAll(t *testing.T, r io.ReadCloser) string {
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func newVersionedSound(t *testing.T, ct *Controller) Sound {
+	sound := Sound{Uid: "sound", DisplayName: "v1"}
+	sound.Creator.ID = "user"
+	if err := ct.db.PutSound(sound); err != nil {
+		t.Fatal(err)
+	}
+	putSoundAudio(t, ct, sound.Uid, "audio v1")
+	return sound
+}
+
+func TestListSoundVersions(t *testing.T) {
+	ct := newVersionsController(t, 2)
+
+	sound := newVersionedSound(t, ct)
+
+	_, err := ct.ListSoundVersions("unknown")
+	assert.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	versions, err := ct.ListSoundVersions(sound.Uid)
+	assert.Nil(t, err)
+	assert.Empty(t, versions)
+
+	updateSound(t, ct, sound, "audio v2")
+	sound.DisplayName = "v2"
+	updateSound(t, ct, sound, "audio v3")
+	sound.DisplayName = "v3"
+	updateSound(t, ct, sound, "audio v4")
+
+	versions, err = ct.ListSoundVersions(sound.Uid)
+	assert.Nil(t, err)
+	if assert.Len(t, versions, 2) {
+		assert.Equal(t, "v3", versions[0].Sound.DisplayName)
+		assert.Equal(t, "v2", versions[1].Sound.DisplayName)
+		assert.Equal(t, SoundChangeEdit, versions[0].Change)
+		assert.Equal(t, "user", versions[0].Creator.ID)
+	}
+}
+
+func TestUpdateSoundKeepsNoVersion(t *testing.T) {
+	ct := newVersionsController(t, 2)
+	sound := newVersionedSound(t, ct)
+
+	var req UpdateSoundRequest
+	req.Sound = sound
+	req.DisplayName = "renamed"
+	req.Tags = []string{"meme"}
+
+	updated, err := ct.UpdateSound(req, "user")
+	assert.Nil(t, err)
+	assert.Equal(t, "renamed", updated.DisplayName)
+
+	versions, err := ct.ListSoundVersions(sound.Uid)
+	assert.Nil(t, err)
+	assert.Empty(t, versions)
+}
+
+// failingVersionsDatabase fails listing the sound versions.
+type failingVersionsDatabase struct {
+	database.IDatabase
+}
+
+func (t failingVersionsDatabase) GetSoundVersions(uid string) ([]SoundVersion, error) {
+	return nil, errors.New("connection lost")
+}
+
+func TestListSoundVersionsDatabaseError(t *testing.T) {
+	ct := newVersionsController(t, 2)
+	sound := newVersionedSound(t, ct)
+	ct.db = failingVersionsDatabase{ct.db}
+
+	_, err := ct.ListSoundVersions(sound.Uid)
+	assert.EqualError(t, err, "connection lost")
+}
+
+func TestGetSoundVersionReader(t *testing.T) {
+	ct := newVersionsController(t, 2)
+	sound := newVersionedSound(t, ct)
+	updateSound(t, ct, sound, "audio v2")
+
+	versions, err := ct.ListSoundVersions(sound.Uid)
+	if err != nil || len(versions) != 1 {
+		t.Fatal(versions, err)
+	}
+
+	r, size, err := ct.GetSoundVersionReader(sound.Uid, versions[0].Id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len("audio v1")), size)
+	assert.Equal(t, "audio v1", readAll(t, r))
+
+	_, _, err = ct.GetSoundVersionReader(sound.Uid, "unknown")
+	assert.ErrorIs(t, err, dberrors.ErrNotFound)
+
+	_, _, err = ct.GetSoundVersionReader("unknown", versions[0].Id)
+	assert.ErrorIs(t, err, dberrors.ErrNotFound)
+}
+
+func TestRollbackSoundToOldestVersion(t *testing.T) {
+	ct := newVersionsController(t, 2)
+	sound := newVersionedSound(t, ct)
+	updateSound(t, ct, sound, "audio v2")
+	sound.DisplayName = "v2"
+	updateSound(t, ct, sound, "audio v3")
+	sound.DisplayName = "v3"
+	if err := ct.db.PutSound(sound); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := ct.ListSoundVersions(sound.Uid)
+	if err != nil || len(versions) != 2 {
+		t.Fatal(versions, err)
+	}
+
+	// Keeping the current state prunes the oldest version,
+	// which is the one restored.
+	restored, err := ct.RollbackSound(sound.Uid, versions[1].Id, "user")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", restored.DisplayName)
+
+	r, _, err := ct.st.GetObject(static.BucketSounds, sound.Uid)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "audio v1", readAll(t, r))
+	}
+
+	versions, err = ct.ListSoundVersions(sound.Uid)
+	assert.Nil(t, err)
+	if assert.Len(t, versions, 2) {
+		assert.Equal(t, "v3", versions[0].Sound.DisplayName)
+		assert.Equal(t, SoundChangeRollback, versions[0].Change)
+		assert.Equal(t, "v2", versions[1].Sound.DisplayName)
+	}
+}
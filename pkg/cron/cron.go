@@ -0,0 +1,216 @@
+// Package cron implements parsing and evaluation of cron
+// expressions with the five standard fields minute, hour,
+// day of month, month and day of week.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidExpression = errors.New("invalid cron expression")
+)
+
+var aliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	min, max int
+}
+
+var (
+	fieldMinute = field{0, 59}
+	fieldHour   = field{0, 23}
+	fieldDom    = field{1, 31}
+	fieldMonth  = field{1, 12}
+	fieldDow    = field{0, 7}
+)
+
+// maxLookahead limits the search for the next matching
+// time of expressions which never match, like "0 0 31 2 *".
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+// Expression is a parsed cron expression.
+type Expression struct {
+	minute, hour, dom, month, dow uint64
+
+	domAny, dowAny bool
+}
+
+// Parse parses the given cron expression. Besides the five
+// standard fields, the aliases @yearly, @annually, @monthly,
+// @weekly, @daily, @midnight and @hourly are supported.
+func Parse(expr string) (*Expression, error) {
+	expr = strings.TrimSpace(expr)
+	if alias, ok := aliases[strings.ToLower(expr)]; ok {
+		expr = alias
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields but got %d", ErrInvalidExpression, len(fields))
+	}
+
+	var (
+		t   Expression
+		err error
+	)
+
+	if t.minute, err = parseField(fields[0], fieldMinute); err != nil {
+		return nil, err
+	}
+	if t.hour, err = parseField(fields[1], fieldHour); err != nil {
+		return nil, err
+	}
+	if t.dom, err = parseField(fields[2], fieldDom); err != nil {
+		return nil, err
+	}
+	if t.month, err = parseField(fields[3], fieldMonth); err != nil {
+		return nil, err
+	}
+	if t.dow, err = parseField(fields[4], fieldDow); err != nil {
+		return nil, err
+	}
+
+	// Sunday can be specified as 0 or 7.
+	if t.dow&(1<<7) != 0 {
+		t.dow |= 1
+	}
+
+	t.domAny = fields[2] == "*"
+	t.dowAny = fields[4] == "*"
+
+	return &t, nil
+}
+
+// Matches returns true when the minute of the given time
+// matches the expression.
+func (t *Expression) Matches(tm time.Time) bool {
+	return has(t.month, int(tm.Month())) &&
+		t.matchesDay(tm) &&
+		has(t.hour, tm.Hour()) &&
+		has(t.minute, tm.Minute())
+}
+
+// Next returns the first minute after the given time which
+// matches the expression. When no such time exists, false
+// is returned.
+func (t *Expression) Next(after time.Time) (time.Time, bool) {
+	tm := after.Truncate(time.Minute).Add(time.Minute)
+	limit := tm.Add(maxLookahead)
+	loc := tm.Location()
+
+	for tm.Before(limit) {
+		switch {
+		case !has(t.month, int(tm.Month())):
+			tm = time.Date(tm.Year(), tm.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !t.matchesDay(tm):
+			tm = time.Date(tm.Year(), tm.Month(), tm.Day()+1, 0, 0, 0, 0, loc)
+		case !has(t.hour, tm.Hour()):
+			tm = tm.Truncate(time.Hour).Add(time.Hour)
+		case !has(t.minute, tm.Minute()):
+			tm = tm.Add(time.Minute)
+		default:
+			return tm, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// --- Internal stuff ---
+
+// matchesDay follows the cron convention that a day matches
+// either field when both day of month and day of week are
+// restricted.
+func (t *Expression) matchesDay(tm time.Time) bool {
+	dom := has(t.dom, tm.Day())
+	dow := has(t.dow, int(tm.Weekday()))
+
+	if t.domAny || t.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+func parseField(v string, f field) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(v, ",") {
+		s, err := parsePart(part, f)
+		if err != nil {
+			return 0, err
+		}
+		set |= s
+	}
+	return set, nil
+}
+
+func parsePart(v string, f field) (uint64, error) {
+	rng, stepStr, hasStep := strings.Cut(v, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepStr)
+		if err != nil || step < 1 {
+			return 0, fmt.Errorf("%w: invalid step '%s'", ErrInvalidExpression, stepStr)
+		}
+	}
+
+	var from, to int
+	switch {
+	case rng == "*":
+		from, to = f.min, f.max
+	case strings.Contains(rng, "-"):
+		fromStr, toStr, _ := strings.Cut(rng, "-")
+		var err error
+		if from, err = parseValue(fromStr, f); err != nil {
+			return 0, err
+		}
+		if to, err = parseValue(toStr, f); err != nil {
+			return 0, err
+		}
+		if from > to {
+			return 0, fmt.Errorf("%w: invalid range '%s'", ErrInvalidExpression, rng)
+		}
+	default:
+		var err error
+		if from, err = parseValue(rng, f); err != nil {
+			return 0, err
+		}
+		to = from
+		if hasStep {
+			to = f.max
+		}
+	}
+
+	var set uint64
+	for i := from; i <= to; i += step {
+		set |= 1 << uint(i)
+	}
+	return set, nil
+}
+
+func parseValue(v string, f field) (int, error) {
+	i, err := strconv.Atoi(v)
+	if err != nil || i < f.min || i > f.max {
+		return 0, fmt.Errorf("%w: value '%s' must be in range %d-%d",
+			ErrInvalidExpression, v, f.min, f.max)
+	}
+	return i, nil
+}
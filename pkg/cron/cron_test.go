@@ -0,0 +1,98 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2023, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"0 12 * * *",
+		"*/15 8-18 * * 1-5",
+		"0,30 * 1,15 * *",
+		"5/10 * * * 7",
+		"@daily",
+		"@HOURLY",
+	}
+	for _, expr := range valid {
+		_, err := Parse(expr)
+		assert.Nil(t, err, expr)
+	}
+
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"@never",
+	}
+	for _, expr := range invalid {
+		_, err := Parse(expr)
+		assert.ErrorIs(t, err, ErrInvalidExpression, expr)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	e, _ := Parse("0 12 * * *")
+	assert.True(t, e.Matches(date(5, 17, 12, 0)))
+	assert.False(t, e.Matches(date(5, 17, 12, 1)))
+	assert.False(t, e.Matches(date(5, 17, 13, 0)))
+
+	e, _ = Parse("*/15 8-18 * * 1-5")
+	// 2023-05-17 is a Wednesday.
+	assert.True(t, e.Matches(date(5, 17, 8, 45)))
+	assert.False(t, e.Matches(date(5, 17, 8, 50)))
+	assert.False(t, e.Matches(date(5, 17, 19, 0)))
+	// 2023-05-20 is a Saturday.
+	assert.False(t, e.Matches(date(5, 20, 8, 45)))
+
+	e, _ = Parse("0 0 * * 7")
+	assert.True(t, e.Matches(date(5, 21, 0, 0)))
+
+	// Day of month and day of week are combined with OR
+	// when both are restricted.
+	e, _ = Parse("0 0 1 * 1")
+	assert.True(t, e.Matches(date(6, 1, 0, 0)))
+	assert.True(t, e.Matches(date(5, 22, 0, 0)))
+	assert.False(t, e.Matches(date(5, 23, 0, 0)))
+}
+
+func TestNext(t *testing.T) {
+	e, _ := Parse("0 12 * * *")
+
+	next, ok := e.Next(date(5, 17, 11, 30))
+	assert.True(t, ok)
+	assert.Equal(t, date(5, 17, 12, 0), next)
+
+	next, ok = e.Next(date(5, 17, 12, 0))
+	assert.True(t, ok)
+	assert.Equal(t, date(5, 18, 12, 0), next)
+
+	e, _ = Parse("30 6 29 2 *")
+	next, ok = e.Next(date(5, 17, 0, 0))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 2, 29, 6, 30, 0, 0, time.UTC), next)
+
+	e, _ = Parse("@hourly")
+	next, ok = e.Next(date(12, 31, 23, 59))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), next)
+
+	e, _ = Parse("0 0 31 2 *")
+	_, ok = e.Next(date(5, 17, 0, 0))
+	assert.False(t, ok)
+}
@@ -76,6 +76,51 @@ func (t *DatabaseCache) RemoveSound(uid string) error {
 	return t.IDatabase.RemoveSound(uid)
 }
 
+func (t *DatabaseCache) GetMacros() ([]Macro, error) {
+	var err error
+	key := ckey("macros")
+
+	vi, _ := t.cache.Load(key)
+	v, ok := vi.([]Macro)
+	if !ok {
+		v, err = t.IDatabase.GetMacros()
+		if err != nil {
+			return nil, err
+		}
+		t.cache.Store(key, v)
+	}
+
+	r := make([]Macro, len(v))
+	copy(r, v)
+
+	return r, nil
+}
+
+func (t *DatabaseCache) GetMacro(uid string) (Macro, error) {
+	macros, err := t.GetMacros()
+	if err != nil {
+		return Macro{}, err
+	}
+
+	for _, m := range macros {
+		if m.Uid == uid {
+			return m, nil
+		}
+	}
+
+	return Macro{}, dberrors.ErrNotFound
+}
+
+func (t *DatabaseCache) PutMacro(macro Macro) error {
+	t.cache.Delete(ckey("macros"))
+	return t.IDatabase.PutMacro(macro)
+}
+
+func (t *DatabaseCache) RemoveMacro(uid string) error {
+	t.cache.Delete(ckey("macros"))
+	return t.IDatabase.RemoveMacro(uid)
+}
+
 func (t *DatabaseCache) GetGuildVolume(guildID string) (int, error) {
 	var err error
 	key := ckey("guilds", guildID, "volume")
@@ -142,6 +187,138 @@ func (t *DatabaseCache) SetGuildFilters(guildID string, f GuildFilters) error {
 	return t.IDatabase.SetGuildFilters(guildID, f)
 }
 
+func (t *DatabaseCache) GetGuildQueueMode(guildID string) (QueueMode, error) {
+	var err error
+	key := ckey("guilds", guildID, "queuemode")
+
+	vi, _ := t.cache.Load(key)
+	v, ok := vi.(QueueMode)
+	if !ok {
+		v, err = t.IDatabase.GetGuildQueueMode(guildID)
+		if err != nil {
+			return "", err
+		}
+		t.cache.Store(key, v)
+	}
+
+	return v, nil
+}
+
+func (t *DatabaseCache) SetGuildQueueMode(guildID string, mode QueueMode) error {
+	t.cache.Store(ckey("guilds", guildID, "queuemode"), mode)
+	return t.IDatabase.SetGuildQueueMode(guildID, mode)
+}
+
+func (t *DatabaseCache) GetGuildAutoLeave(guildID string) (GuildAutoLeave, error) {
+	var err error
+	key := ckey("guilds", guildID, "autoleave")
+
+	vi, _ := t.cache.Load(key)
+	v, ok := vi.(GuildAutoLeave)
+	if !ok {
+		v, err = t.IDatabase.GetGuildAutoLeave(guildID)
+		if err != nil {
+			return GuildAutoLeave{}, err
+		}
+		t.cache.Store(key, v)
+	}
+
+	return v, nil
+}
+
+func (t *DatabaseCache) SetGuildAutoLeave(guildID string, s GuildAutoLeave) error {
+	t.cache.Store(ckey("guilds", guildID, "autoleave"), s)
+	return t.IDatabase.SetGuildAutoLeave(guildID, s)
+}
+
+func (t *DatabaseCache) GetUserFastTriggers(userID, guildID string) (FastTriggers, error) {
+	var err error
+	key := ckey("users", userID, "fasttriggers", guildID)
+
+	vi, _ := t.cache.Load(key)
+	v, ok := vi.(FastTriggers)
+	if !ok {
+		v, err = t.IDatabase.GetUserFastTriggers(userID, guildID)
+		if err != nil {
+			return FastTriggers{}, err
+		}
+		t.cache.Store(key, v)
+	}
+
+	return v, nil
+}
+
+func (t *DatabaseCache) SetUserFastTriggers(userID, guildID string, s FastTriggers) error {
+	t.cache.Store(ckey("users", userID, "fasttriggers", guildID), s)
+	return t.IDatabase.SetUserFastTriggers(userID, guildID, s)
+}
+
+func (t *DatabaseCache) GetGuildRandomWeights(guildID string) (RandomWeights, error) {
+	var err error
+	key := ckey("guilds", guildID, "randomweights")
+
+	vi, _ := t.cache.Load(key)
+	v, ok := vi.(RandomWeights)
+	if !ok {
+		v, err = t.IDatabase.GetGuildRandomWeights(guildID)
+		if err != nil {
+			return RandomWeights{}, err
+		}
+		t.cache.Store(key, v)
+	}
+
+	return v, nil
+}
+
+func (t *DatabaseCache) SetGuildRandomWeights(guildID string, w RandomWeights) error {
+	t.cache.Store(ckey("guilds", guildID, "randomweights"), w)
+	return t.IDatabase.SetGuildRandomWeights(guildID, w)
+}
+
+func (t *DatabaseCache) GetUserSounds(userID, guildID string) (UserSounds, error) {
+	var err error
+	key := ckey("users", userID, "sounds", guildID)
+
+	vi, _ := t.cache.Load(key)
+	v, ok := vi.(UserSounds)
+	if !ok {
+		v, err = t.IDatabase.GetUserSounds(userID, guildID)
+		if err != nil {
+			return UserSounds{}, err
+		}
+		t.cache.Store(key, v)
+	}
+
+	return v, nil
+}
+
+func (t *DatabaseCache) SetUserSounds(userID, guildID string, s UserSounds) error {
+	t.cache.Store(ckey("users", userID, "sounds", guildID), s)
+	return t.IDatabase.SetUserSounds(userID, guildID, s)
+}
+
+func (t *DatabaseCache) GetGuildUserSounds(guildID string) (GuildUserSounds, error) {
+	var err error
+	key := ckey("guilds", guildID, "usersounds")
+
+	vi, _ := t.cache.Load(key)
+	v, ok := vi.(GuildUserSounds)
+	if !ok {
+		v, err = t.IDatabase.GetGuildUserSounds(guildID)
+		if err != nil {
+			return GuildUserSounds{}, err
+		}
+		t.cache.Store(key, v)
+	}
+
+	return v, nil
+}
+
+func (t *DatabaseCache) SetGuildUserSounds(guildID string, s GuildUserSounds) error {
+	t.cache.Store(ckey("guilds", guildID, "usersounds"), s)
+	return t.IDatabase.SetGuildUserSounds(guildID, s)
+}
+
 // --- Felpers ---
 
 func ckey(elements ...string) string {
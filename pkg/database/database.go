@@ -2,6 +2,7 @@ package database
 
 import (
 	"strings"
+	"time"
 
 	"github.com/zekrotja/yuri69/pkg/database/dberrors"
 	"github.com/zekrotja/yuri69/pkg/database/nuts"
@@ -17,15 +18,51 @@ type IDatabase interface {
 	GetSounds() ([]Sound, error)
 	GetSound(uid string) (Sound, error)
 
+	PutSoundVersion(v SoundVersion) error
+	RemoveSoundVersion(uid, id string) error
+	GetSoundVersions(uid string) ([]SoundVersion, error)
+	GetSoundVersion(uid, id string) (SoundVersion, error)
+
+	PutMacro(macro Macro) error
+	RemoveMacro(uid string) error
+	GetMacros() ([]Macro, error)
+	GetMacro(uid string) (Macro, error)
+
 	GetGuildVolume(guildID string) (int, error)
 	SetGuildVolume(guildID string, volume int) error
 
 	GetUserFastTrigger(userID string) (string, error)
 	SetUserFastTrigger(userID, ident string) error
 
+	GetUserFastTriggers(userID, guildID string) (FastTriggers, error)
+	SetUserFastTriggers(userID, guildID string, s FastTriggers) error
+
 	GetGuildFilters(guildID string) (GuildFilters, error)
 	SetGuildFilters(guildID string, f GuildFilters) error
 
+	GetGuildRandomWeights(guildID string) (RandomWeights, error)
+	SetGuildRandomWeights(guildID string, w RandomWeights) error
+
+	GetGuildQueueMode(guildID string) (QueueMode, error)
+	SetGuildQueueMode(guildID string, mode QueueMode) error
+
+	GetGuildAutoLeave(guildID string) (GuildAutoLeave, error)
+	SetGuildAutoLeave(guildID string, s GuildAutoLeave) error
+
+	GetUserSounds(userID, guildID string) (UserSounds, error)
+	SetUserSounds(userID, guildID string, s UserSounds) error
+
+	GetGuildUserSounds(guildID string) (GuildUserSounds, error)
+	SetGuildUserSounds(guildID string, s GuildUserSounds) error
+
+	GetSchedules() ([]Schedule, error)
+	GetSchedule(id string) (Schedule, error)
+	PutSchedule(s Schedule) error
+	RemoveSchedule(id string) error
+	// SetScheduleRun only updates the last run of an existing
+	// schedule and disables it when disable is true.
+	SetScheduleRun(id string, lastRun time.Time, disable bool) error
+
 	PutPlaybackLog(e PlaybackLogEntry) error
 	GetPlaybackLog(guildID, ident, userID string, limit, offset int) ([]PlaybackLogEntry, error)
 	GetPlaybackLogSize() (int, error)
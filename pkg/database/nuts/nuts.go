@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/xujiajun/nutsdb"
 	"github.com/zekrotja/yuri69/pkg/database/dberrors"
@@ -13,9 +14,12 @@ import (
 
 const (
 	bucketSounds         = "sounds"
+	bucketSoundVersions  = "soundversions"
+	bucketMacros         = "macros"
 	bucketGuilds         = "guilds"
 	bucketUsers          = "users"
 	bucketStats          = "stats"
+	bucketSchedules      = "schedules"
 	bucketAdmins         = "admins"
 	bucketTokens         = "tokens"
 	bucketTwitchSettings = "twitchsettings"
@@ -66,6 +70,49 @@ func (t *Nuts) GetSound(uid string) (Sound, error) {
 	return nuts_getValue[Sound](t, bucketSounds, nuts_key(uid))
 }
 
+func (t *Nuts) PutSoundVersion(v SoundVersion) error {
+	return nuts_setValue(t, bucketSoundVersions, nuts_key(v.Sound.Uid, v.Id), v)
+}
+
+func (t *Nuts) RemoveSoundVersion(uid, id string) error {
+	return t.remove(bucketSoundVersions, nuts_key(uid, id))
+}
+
+func (t *Nuts) GetSoundVersions(uid string) ([]SoundVersion, error) {
+	versions, err := nuts_listValues(t, bucketSoundVersions, nil, func(v SoundVersion) bool {
+		return v.Sound.Uid == uid
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Created.After(versions[j].Created)
+	})
+
+	return versions, nil
+}
+
+func (t *Nuts) GetSoundVersion(uid, id string) (SoundVersion, error) {
+	return nuts_getValue[SoundVersion](t, bucketSoundVersions, nuts_key(uid, id))
+}
+
+func (t *Nuts) PutMacro(macro Macro) error {
+	return nuts_setValue(t, bucketMacros, nuts_key(macro.Uid), macro)
+}
+
+func (t *Nuts) RemoveMacro(uid string) error {
+	return t.remove(bucketMacros, []byte(uid))
+}
+
+func (t *Nuts) GetMacros() ([]Macro, error) {
+	return nuts_listValues[Macro](t, bucketMacros, nil, nil)
+}
+
+func (t *Nuts) GetMacro(uid string) (Macro, error) {
+	return nuts_getValue[Macro](t, bucketMacros, nuts_key(uid))
+}
+
 func (t *Nuts) GetGuildVolume(guildID string) (int, error) {
 	return nuts_getValue[int](t, bucketGuilds, nuts_key(guildID, "volume"))
 }
@@ -90,6 +137,97 @@ func (t *Nuts) SetGuildFilters(guildID string, f GuildFilters) error {
 	return nuts_setValue(t, bucketGuilds, nuts_key(guildID, "filters"), f)
 }
 
+func (t *Nuts) GetGuildQueueMode(guildID string) (QueueMode, error) {
+	return nuts_getValue[QueueMode](t, bucketGuilds, nuts_key(guildID, "queuemode"))
+}
+
+func (t *Nuts) SetGuildQueueMode(guildID string, mode QueueMode) error {
+	return nuts_setValue(t, bucketGuilds, nuts_key(guildID, "queuemode"), mode)
+}
+
+func (t *Nuts) GetGuildRandomWeights(guildID string) (RandomWeights, error) {
+	return nuts_getValue[RandomWeights](t, bucketGuilds, nuts_key(guildID, "randomweights"))
+}
+
+func (t *Nuts) SetGuildRandomWeights(guildID string, w RandomWeights) error {
+	return nuts_setValue(t, bucketGuilds, nuts_key(guildID, "randomweights"), w)
+}
+
+func (t *Nuts) GetGuildAutoLeave(guildID string) (GuildAutoLeave, error) {
+	return nuts_getValue[GuildAutoLeave](t, bucketGuilds, nuts_key(guildID, "autoleave"))
+}
+
+func (t *Nuts) SetGuildAutoLeave(guildID string, s GuildAutoLeave) error {
+	return nuts_setValue(t, bucketGuilds, nuts_key(guildID, "autoleave"), s)
+}
+
+func (t *Nuts) GetUserFastTriggers(userID, guildID string) (FastTriggers, error) {
+	return nuts_getValue[FastTriggers](t, bucketUsers, nuts_key(userID, "fasttriggers", guildID))
+}
+
+func (t *Nuts) SetUserFastTriggers(userID, guildID string, s FastTriggers) error {
+	return nuts_setValue(t, bucketUsers, nuts_key(userID, "fasttriggers", guildID), s)
+}
+
+func (t *Nuts) GetUserSounds(userID, guildID string) (UserSounds, error) {
+	return nuts_getValue[UserSounds](t, bucketUsers, nuts_key(userID, "sounds", guildID))
+}
+
+func (t *Nuts) SetUserSounds(userID, guildID string, s UserSounds) error {
+	return nuts_setValue(t, bucketUsers, nuts_key(userID, "sounds", guildID), s)
+}
+
+func (t *Nuts) GetGuildUserSounds(guildID string) (GuildUserSounds, error) {
+	return nuts_getValue[GuildUserSounds](t, bucketGuilds, nuts_key(guildID, "usersounds"))
+}
+
+func (t *Nuts) SetGuildUserSounds(guildID string, s GuildUserSounds) error {
+	return nuts_setValue(t, bucketGuilds, nuts_key(guildID, "usersounds"), s)
+}
+
+func (t *Nuts) GetSchedules() ([]Schedule, error) {
+	return nuts_listValues[Schedule](t, bucketSchedules, nil, nil)
+}
+
+func (t *Nuts) GetSchedule(id string) (Schedule, error) {
+	return nuts_getValue[Schedule](t, bucketSchedules, nuts_key(id))
+}
+
+func (t *Nuts) PutSchedule(s Schedule) error {
+	return nuts_setValue(t, bucketSchedules, nuts_key(s.Id), s)
+}
+
+func (t *Nuts) RemoveSchedule(id string) error {
+	return t.remove(bucketSchedules, nuts_key(id))
+}
+
+func (t *Nuts) SetScheduleRun(id string, lastRun time.Time, disable bool) error {
+	key := nuts_key(id)
+	return t.db.Update(func(tx *nutsdb.Tx) error {
+		e, err := tx.Get(bucketSchedules, key)
+		if err != nil {
+			return t.wrapErr(err)
+		}
+
+		s, err := nuts_unmarshal[Schedule](e.Value)
+		if err != nil {
+			return err
+		}
+
+		s.LastRun = &lastRun
+		if disable {
+			s.Enabled = false
+		}
+
+		data, err := nuts_marshal(s)
+		if err != nil {
+			return err
+		}
+
+		return tx.Put(bucketSchedules, key, data, 0)
+	})
+}
+
 func (t *Nuts) PutPlaybackLog(e PlaybackLogEntry) error {
 	return nuts_setValue(t, bucketStats, nuts_key(e.Id), e)
 }
@@ -3,7 +3,9 @@ package postgres
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/pressly/goose/v3"
@@ -68,11 +70,16 @@ func (t *Postgres) PutSound(sound Sound) error {
 
 	if exists {
 		err = t.tx(func(tx *sql.Tx) error {
+			duration, sampleRate, loudness, peaks := pg_audioInfo(sound.Audio)
 			_, err := tx.Exec(`
 				UPDATE sounds
-				SET "displayname" = $2
+				SET "displayname" = $2,
+				    "duration" = $3,
+				    "samplerate" = $4,
+				    "loudness" = $5,
+				    "peaks" = $6
 				WHERE "uid" = $1
-			`, sound.Uid, sound.DisplayName)
+			`, sound.Uid, sound.DisplayName, duration, sampleRate, loudness, peaks)
 			if err != nil {
 				return err
 			}
@@ -98,10 +105,13 @@ func (t *Postgres) PutSound(sound Sound) error {
 	}
 
 	err = t.tx(func(tx *sql.Tx) error {
+		duration, sampleRate, loudness, peaks := pg_audioInfo(sound.Audio)
 		_, err := tx.Exec(`
-			INSERT INTO sounds ("uid", "displayname", "created", "creatorid")
-			VALUES ($1, $2, $3, $4)
-		`, sound.Uid, sound.DisplayName, sound.Created, sound.Creator.ID)
+			INSERT INTO sounds ("uid", "displayname", "created", "creatorid",
+				"duration", "samplerate", "loudness", "peaks")
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, sound.Uid, sound.DisplayName, sound.Created, sound.Creator.ID,
+			duration, sampleRate, loudness, peaks)
 		if err != nil {
 			return err
 		}
@@ -126,9 +136,40 @@ func (t *Postgres) RemoveSound(uid string) error {
 	return pg_delete(t, "sounds", "uid", uid)
 }
 
+func (t *Postgres) PutSoundVersion(v SoundVersion) error {
+	_, err := t.db.Exec(`
+		INSERT INTO sound_versions ("id", "sound", "displayname", "tags", "soundcreated",
+			"soundcreatorid", "created", "creatorid", "change")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, v.Id, v.Sound.Uid, v.Sound.DisplayName, strings.Join(v.Sound.Tags, ","), v.Sound.Created,
+		v.Sound.Creator.ID, v.Created, v.Creator.ID, v.Change)
+	return err
+}
+
+func (t *Postgres) RemoveSoundVersion(uid, id string) error {
+	_, err := t.db.Exec(`DELETE FROM sound_versions WHERE "sound" = $1 AND "id" = $2`, uid, id)
+	return err
+}
+
+func (t *Postgres) GetSoundVersions(uid string) ([]SoundVersion, error) {
+	return t.querySoundVersions(`WHERE "sound" = $1`, uid)
+}
+
+func (t *Postgres) GetSoundVersion(uid, id string) (SoundVersion, error) {
+	versions, err := t.querySoundVersions(`WHERE "sound" = $1 AND "id" = $2`, uid, id)
+	if err != nil {
+		return SoundVersion{}, err
+	}
+	if len(versions) == 0 {
+		return SoundVersion{}, dberrors.ErrNotFound
+	}
+	return versions[0], nil
+}
+
 func (t *Postgres) GetSounds() ([]Sound, error) {
 	rows, err := t.db.Query(`
-		SELECT "uid", "displayname", "created", "creatorid", "tag"
+		SELECT "uid", "displayname", "created", "creatorid",
+			"duration", "samplerate", "loudness", "peaks", "tag"
 		FROM sounds
 		LEFT JOIN sounds_tags
 		ON sounds."uid" = sounds_tags."sound"
@@ -141,10 +182,13 @@ func (t *Postgres) GetSounds() ([]Sound, error) {
 	for rows.Next() {
 		var s Sound
 		var tag sql.NullString
-		err = rows.Scan(&s.Uid, &s.DisplayName, &s.Created, &s.Creator.ID, &tag)
+		var audio pg_audioColumns
+		err = rows.Scan(&s.Uid, &s.DisplayName, &s.Created, &s.Creator.ID,
+			&audio.duration, &audio.sampleRate, &audio.loudness, &audio.peaks, &tag)
 		if err != nil {
 			return nil, err
 		}
+		s.Audio = audio.info()
 		s.Uid = strings.TrimSpace(s.Uid)
 		ms, ok := soundsMap[s.Uid]
 		if !ok {
@@ -166,7 +210,8 @@ func (t *Postgres) GetSounds() ([]Sound, error) {
 
 func (t *Postgres) GetSound(uid string) (Sound, error) {
 	rows, err := t.db.Query(`
-	    SELECT "uid", "displayname", "created", "creatorid", "tag"
+	    SELECT "uid", "displayname", "created", "creatorid",
+	        "duration", "samplerate", "loudness", "peaks", "tag"
 	    FROM sounds
 	    LEFT JOIN sounds_tags
 	    ON sounds."uid" = sounds_tags."sound"
@@ -179,10 +224,13 @@ func (t *Postgres) GetSound(uid string) (Sound, error) {
 	var s Sound
 	for rows.Next() {
 		var tag sql.NullString
-		err = rows.Scan(&s.Uid, &s.DisplayName, &s.Created, &s.Creator.ID, &tag)
+		var audio pg_audioColumns
+		err = rows.Scan(&s.Uid, &s.DisplayName, &s.Created, &s.Creator.ID,
+			&audio.duration, &audio.sampleRate, &audio.loudness, &audio.peaks, &tag)
 		if err != nil {
 			return Sound{}, err
 		}
+		s.Audio = audio.info()
 		s.Uid = strings.TrimSpace(s.Uid)
 		if tag.Valid {
 			s.Tags = append(s.Tags, tag.String)
@@ -192,6 +240,56 @@ func (t *Postgres) GetSound(uid string) (Sound, error) {
 	return s, nil
 }
 
+func (t *Postgres) PutMacro(macro Macro) error {
+	return t.tx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO macros ("uid", "displayname", "created", "creatorid")
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT ("uid") DO UPDATE
+			SET "displayname" = $2
+		`, macro.Uid, macro.DisplayName, macro.Created, macro.Creator.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`DELETE FROM macros_steps WHERE "macro" = $1`, macro.Uid)
+		if err != nil {
+			return err
+		}
+
+		for i, step := range macro.Steps {
+			_, err = tx.Exec(`
+				INSERT INTO macros_steps ("macro", "position", "sound", "delay", "volume")
+				VALUES ($1, $2, $3, $4, $5)
+			`, macro.Uid, i, step.Ident, step.Delay, step.Volume)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (t *Postgres) RemoveMacro(uid string) error {
+	return pg_delete(t, "macros", "uid", uid)
+}
+
+func (t *Postgres) GetMacros() ([]Macro, error) {
+	return t.queryMacros(``)
+}
+
+func (t *Postgres) GetMacro(uid string) (Macro, error) {
+	macros, err := t.queryMacros(`WHERE macros."uid" = $1`, uid)
+	if err != nil {
+		return Macro{}, err
+	}
+	if len(macros) == 0 {
+		return Macro{}, dberrors.ErrNotFound
+	}
+	return macros[0], nil
+}
+
 func (t *Postgres) GetGuildVolume(guildID string) (int, error) {
 	return pg_getValue[int](t, "guilds", "volume", "id", guildID)
 }
@@ -286,11 +384,276 @@ func (t *Postgres) SetGuildFilters(guildID string, f GuildFilters) error {
 	return nil
 }
 
-func (t *Postgres) PutPlaybackLog(e PlaybackLogEntry) error {
+func (t *Postgres) GetGuildRandomWeights(guildID string) (RandomWeights, error) {
+	var w RandomWeights
+	err := t.db.QueryRow(`
+		SELECT "randomfavorites", "randomrarelyplayed"
+		FROM guilds
+		WHERE "id" = $1;
+	`, guildID).Scan(&w.Favorites, &w.RarelyPlayed)
+	if err != nil {
+		return RandomWeights{}, t.wrapErr(err)
+	}
+
+	rows, err := t.db.Query(`
+		SELECT "tag", "weight"
+		FROM guild_random_tags
+		WHERE "guildid" = $1;
+	`, guildID)
+	if err != nil {
+		return RandomWeights{}, t.wrapErr(err)
+	}
+	defer rows.Close()
+
+	w.Tags = make(map[string]float64)
+	for rows.Next() {
+		var (
+			tag    string
+			weight float64
+		)
+		if err = rows.Scan(&tag, &weight); err != nil {
+			return RandomWeights{}, err
+		}
+		w.Tags[tag] = weight
+	}
+
+	return w, nil
+}
+
+func (t *Postgres) SetGuildRandomWeights(guildID string, w RandomWeights) error {
+	return t.tx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO guilds ("id", "randomfavorites", "randomrarelyplayed")
+			VALUES ($1, $2, $3)
+			ON CONFLICT ("id") DO UPDATE
+			SET "randomfavorites" = $2,
+			    "randomrarelyplayed" = $3;
+		`, guildID, w.Favorites, w.RarelyPlayed)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`DELETE FROM guild_random_tags WHERE "guildid" = $1`, guildID)
+		if err != nil {
+			return err
+		}
+
+		for tag, weight := range w.Tags {
+			_, err = tx.Exec(
+				`INSERT INTO guild_random_tags ("guildid", "tag", "weight") VALUES ($1, $2, $3)`,
+				guildID, tag, weight)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (t *Postgres) GetGuildQueueMode(guildID string) (QueueMode, error) {
+	return pg_getValue[QueueMode](t, "guilds", "queuemode", "id", guildID)
+}
+
+func (t *Postgres) SetGuildQueueMode(guildID string, mode QueueMode) error {
+	return pg_setValue(t, "guilds", "queuemode", mode, "id", guildID)
+}
+
+func (t *Postgres) GetGuildAutoLeave(guildID string) (GuildAutoLeave, error) {
+	var s GuildAutoLeave
+	err := t.db.QueryRow(`
+		SELECT "autoleavetimeout", "idletimeout"
+		FROM guilds
+		WHERE "id" = $1;
+	`, guildID).Scan(&s.Timeout, &s.IdleTimeout)
+	return s, t.wrapErr(err)
+}
+
+func (t *Postgres) SetGuildAutoLeave(guildID string, s GuildAutoLeave) error {
+	res, err := t.db.Exec(`
+		UPDATE guilds
+		SET "autoleavetimeout" = $1,
+		    "idletimeout" = $2
+		WHERE "id" = $3;
+	`, s.Timeout, s.IdleTimeout, guildID)
+	if err != nil {
+		return err
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if ar == 0 {
+		_, err = t.db.Exec(`
+			INSERT INTO guilds ("id", "autoleavetimeout", "idletimeout")
+			VALUES ($1, $2, $3);
+		`, guildID, s.Timeout, s.IdleTimeout)
+	}
+
+	return err
+}
+
+func (t *Postgres) GetUserFastTriggers(userID, guildID string) (FastTriggers, error) {
+	var s FastTriggers
+	err := t.db.QueryRow(`
+		SELECT "mute", "deafen", "doubletoggle"
+		FROM user_fasttriggers
+		WHERE "userid" = $1 AND "guildid" = $2;
+	`, userID, guildID).Scan(&s.Mute, &s.Deafen, &s.Double)
+	return s, t.wrapErr(err)
+}
+
+func (t *Postgres) SetUserFastTriggers(userID, guildID string, s FastTriggers) error {
 	_, err := t.db.Exec(`
-		INSERT INTO playbacklog ("id", "sound", "guildid", "userid", "timestamp")
+		INSERT INTO user_fasttriggers ("userid", "guildid", "mute", "deafen", "doubletoggle")
 		VALUES ($1, $2, $3, $4, $5)
-	`, e.Id, e.Ident, e.GuildID, e.UserID, e.Timestamp)
+		ON CONFLICT ("userid", "guildid") DO UPDATE
+		SET "mute" = $3,
+		    "deafen" = $4,
+		    "doubletoggle" = $5;
+	`, userID, guildID, s.Mute, s.Deafen, s.Double)
+	return err
+}
+
+func (t *Postgres) GetUserSounds(userID, guildID string) (UserSounds, error) {
+	var s UserSounds
+	err := t.db.QueryRow(`
+		SELECT "entrance", "exitsound"
+		FROM user_sounds
+		WHERE "userid" = $1 AND "guildid" = $2;
+	`, userID, guildID).Scan(&s.Entrance, &s.Exit)
+	return s, t.wrapErr(err)
+}
+
+func (t *Postgres) SetUserSounds(userID, guildID string, s UserSounds) error {
+	res, err := t.db.Exec(`
+		UPDATE user_sounds
+		SET "entrance" = $1,
+		    "exitsound" = $2
+		WHERE "userid" = $3 AND "guildid" = $4;
+	`, s.Entrance, s.Exit, userID, guildID)
+	if err != nil {
+		return err
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if ar == 0 {
+		_, err = t.db.Exec(`
+			INSERT INTO user_sounds ("userid", "guildid", "entrance", "exitsound")
+			VALUES ($1, $2, $3, $4);
+		`, userID, guildID, s.Entrance, s.Exit)
+	}
+
+	return err
+}
+
+func (t *Postgres) GetGuildUserSounds(guildID string) (GuildUserSounds, error) {
+	var s GuildUserSounds
+	err := t.db.QueryRow(`
+		SELECT "usersoundsenabled", "usersoundscooldown"
+		FROM guilds
+		WHERE "id" = $1;
+	`, guildID).Scan(&s.Enabled, &s.CooldownSeconds)
+	return s, t.wrapErr(err)
+}
+
+func (t *Postgres) SetGuildUserSounds(guildID string, s GuildUserSounds) error {
+	res, err := t.db.Exec(`
+		UPDATE guilds
+		SET "usersoundsenabled" = $1,
+		    "usersoundscooldown" = $2
+		WHERE "id" = $3;
+	`, s.Enabled, s.CooldownSeconds, guildID)
+	if err != nil {
+		return err
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if ar == 0 {
+		_, err = t.db.Exec(`
+			INSERT INTO guilds ("id", "usersoundsenabled", "usersoundscooldown")
+			VALUES ($1, $2, $3);
+		`, guildID, s.Enabled, s.CooldownSeconds)
+	}
+
+	return err
+}
+
+func (t *Postgres) GetSchedules() ([]Schedule, error) {
+	return t.querySchedules(``)
+}
+
+func (t *Postgres) GetSchedule(id string) (Schedule, error) {
+	schedules, err := t.querySchedules(`WHERE "id" = $1`, id)
+	if err != nil {
+		return Schedule{}, err
+	}
+	if len(schedules) == 0 {
+		return Schedule{}, dberrors.ErrNotFound
+	}
+	return schedules[0], nil
+}
+
+func (t *Postgres) PutSchedule(s Schedule) error {
+	_, err := t.db.Exec(`
+		INSERT INTO schedules ("id", "guildid", "channelid", "ident", "cron", "at", "timezone",
+			"onlyifoccupied", "enabled", "created", "creatorid", "lastrun")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT ("id") DO UPDATE
+		SET "channelid" = $3,
+		    "ident" = $4,
+		    "cron" = $5,
+		    "at" = $6,
+		    "timezone" = $7,
+		    "onlyifoccupied" = $8,
+		    "enabled" = $9,
+		    "lastrun" = $12
+	`, s.Id, s.GuildID, s.ChannelID, s.Ident, s.Cron, pg_nullTimeUTC(s.At), s.Timezone,
+		s.OnlyIfOccupied, s.Enabled, s.Created, s.Creator.ID, pg_nullTimeUTC(s.LastRun))
+	return err
+}
+
+func (t *Postgres) RemoveSchedule(id string) error {
+	return pg_delete(t, "schedules", "id", id)
+}
+
+func (t *Postgres) SetScheduleRun(id string, lastRun time.Time, disable bool) error {
+	res, err := t.db.Exec(`
+		UPDATE schedules
+		SET "lastrun" = $1,
+		    "enabled" = "enabled" AND NOT $2
+		WHERE "id" = $3
+	`, lastRun.UTC(), disable, id)
+	if err != nil {
+		return err
+	}
+
+	ar, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if ar == 0 {
+		return dberrors.ErrNotFound
+	}
+
+	return nil
+}
+
+func (t *Postgres) PutPlaybackLog(e PlaybackLogEntry) error {
+	_, err := t.db.Exec(`
+		INSERT INTO playbacklog ("id", "sound", "guildid", "userid", "timestamp", "source")
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, e.Id, e.Ident, e.GuildID, e.UserID, e.Timestamp, e.Source)
 	return err
 }
 
@@ -315,7 +678,7 @@ func (t *Postgres) GetPlaybackLog(guildID, ident, userID string, limit, offset i
 	}
 
 	rows, err := t.db.Query(fmt.Sprintf(`
-		SELECT "id", "sound", "guildid", "userid", "timestamp"
+		SELECT "id", "sound", "guildid", "userid", "timestamp", "source"
 		FROM playbacklog
 		%s
 		ORDER BY "timestamp" DESC
@@ -328,7 +691,7 @@ func (t *Postgres) GetPlaybackLog(guildID, ident, userID string, limit, offset i
 	var logs []PlaybackLogEntry
 	for rows.Next() {
 		var log PlaybackLogEntry
-		err = rows.Scan(&log.Id, &log.Ident, &log.GuildID, &log.UserID, &log.Timestamp)
+		err = rows.Scan(&log.Id, &log.Ident, &log.GuildID, &log.UserID, &log.Timestamp, &log.Source)
 		if err != nil {
 			return nil, err
 		}
@@ -556,6 +919,119 @@ func (t *Postgres) tx(f func(*sql.Tx) error) error {
 	return tx.Commit()
 }
 
+func (t *Postgres) querySoundVersions(where string, args ...any) ([]SoundVersion, error) {
+	rows, err := t.db.Query(`
+		SELECT "id", "sound", "displayname", "tags", "soundcreated", "soundcreatorid",
+			"created", "creatorid", "change"
+		FROM sound_versions
+		`+where+`
+		ORDER BY "created" DESC
+	`, args...)
+	if err != nil {
+		return nil, t.wrapErr(err)
+	}
+	defer rows.Close()
+
+	var versions []SoundVersion
+	for rows.Next() {
+		var (
+			v    SoundVersion
+			tags string
+		)
+		err = rows.Scan(&v.Id, &v.Sound.Uid, &v.Sound.DisplayName, &tags, &v.Sound.Created,
+			&v.Sound.Creator.ID, &v.Created, &v.Creator.ID, &v.Change)
+		if err != nil {
+			return nil, err
+		}
+		if tags != "" {
+			v.Sound.Tags = strings.Split(tags, ",")
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
+
+func (t *Postgres) queryMacros(where string, args ...any) ([]Macro, error) {
+	rows, err := t.db.Query(`
+		SELECT "uid", "displayname", "created", "creatorid", "sound", "delay", "volume"
+		FROM macros
+		LEFT JOIN macros_steps
+		ON macros."uid" = macros_steps."macro"
+		`+where+`
+		ORDER BY macros."uid", macros_steps."position"
+	`, args...)
+	if err != nil {
+		return nil, t.wrapErr(err)
+	}
+	defer rows.Close()
+
+	var macros []Macro
+	for rows.Next() {
+		var (
+			m      Macro
+			sound  sql.NullString
+			delay  sql.NullInt64
+			volume sql.NullInt64
+		)
+		err = rows.Scan(&m.Uid, &m.DisplayName, &m.Created, &m.Creator.ID, &sound, &delay, &volume)
+		if err != nil {
+			return nil, err
+		}
+		m.Uid = strings.TrimSpace(m.Uid)
+		if len(macros) == 0 || macros[len(macros)-1].Uid != m.Uid {
+			macros = append(macros, m)
+		}
+		if sound.Valid {
+			last := &macros[len(macros)-1]
+			last.Steps = append(last.Steps, MacroStep{
+				Ident:  sound.String,
+				Delay:  int(delay.Int64),
+				Volume: int(volume.Int64),
+			})
+		}
+	}
+
+	return macros, rows.Err()
+}
+
+func (t *Postgres) querySchedules(where string, args ...any) ([]Schedule, error) {
+	rows, err := t.db.Query(`
+		SELECT "id", "guildid", "channelid", "ident", "cron", "at", "timezone",
+			"onlyifoccupied", "enabled", "created", "creatorid", "lastrun"
+		FROM schedules
+		`+where, args...)
+	if err != nil {
+		return nil, t.wrapErr(err)
+	}
+	defer rows.Close()
+
+	var schedules []Schedule
+	for rows.Next() {
+		var (
+			s       Schedule
+			at      sql.NullTime
+			lastRun sql.NullTime
+		)
+		err = rows.Scan(&s.Id, &s.GuildID, &s.ChannelID, &s.Ident, &s.Cron, &at, &s.Timezone,
+			&s.OnlyIfOccupied, &s.Enabled, &s.Created, &s.Creator.ID, &lastRun)
+		if err != nil {
+			return nil, err
+		}
+		if at.Valid {
+			at.Time = at.Time.UTC()
+			s.At = &at.Time
+		}
+		if lastRun.Valid {
+			lastRun.Time = lastRun.Time.UTC()
+			s.LastRun = &lastRun.Time
+		}
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
 func (t *Postgres) wrapErr(err error) error {
 	if err != nil && err == sql.ErrNoRows {
 		return dberrors.ErrNotFound
@@ -593,6 +1069,67 @@ func pg_setValue[TVal, TWv any](t *Postgres, table, vk string, val TVal, wk stri
 	return err
 }
 
+func pg_nullTime(v *time.Time) sql.NullTime {
+	if v == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *v, Valid: true}
+}
+
+// pg_nullTimeUTC is like pg_nullTime but converts the time
+// to UTC, so that it is stored as the same instant whatever
+// time zone the time and the database session are in.
+func pg_nullTimeUTC(v *time.Time) sql.NullTime {
+	if v == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: v.UTC(), Valid: true}
+}
+
+// pg_audioColumns holds the nullable audio info columns of
+// a sound, which are NULL when the sound has not been
+// analyzed.
+type pg_audioColumns struct {
+	duration   sql.NullFloat64
+	sampleRate sql.NullInt64
+	loudness   sql.NullFloat64
+	peaks      sql.NullString
+}
+
+func (t pg_audioColumns) info() *AudioInfo {
+	if !t.duration.Valid {
+		return nil
+	}
+
+	info := AudioInfo{
+		Duration:   t.duration.Float64,
+		SampleRate: int(t.sampleRate.Int64),
+		Loudness:   t.loudness.Float64,
+		Peaks:      []int{},
+	}
+	if t.peaks.String != "" {
+		for _, p := range strings.Split(t.peaks.String, ",") {
+			v, _ := strconv.Atoi(p)
+			info.Peaks = append(info.Peaks, v)
+		}
+	}
+
+	return &info
+}
+
+func pg_audioInfo(info *AudioInfo) (duration, sampleRate, loudness, peaks any) {
+	if info == nil {
+		return nil, nil, nil, nil
+	}
+
+	peaksStr := make([]string, len(info.Peaks))
+	for i, p := range info.Peaks {
+		peaksStr[i] = strconv.Itoa(p)
+	}
+
+	return info.Duration, info.SampleRate, info.Loudness, strings.Join(peaksStr, ",")
+}
+
 func pg_delete[TWv any](t *Postgres, table, wk string, wv TWv) error {
 	_, err := t.db.Exec(fmt.Sprintf(`DELETE FROM %s WHERE "%s" = $1`, table, wk), wv)
 	return t.wrapErr(err)
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	. "github.com/zekrotja/yuri69/pkg/models"
+)
+
+// newTestPostgres connects to the database given via the
+// YURI_TEST_POSTGRES_HOST environment variable, for example
+// the one of docker-compose.dev.yml. The test is skipped when
+// it is not set.
+func newTestPostgres(t *testing.T) *Postgres {
+	host := os.Getenv("YURI_TEST_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("YURI_TEST_POSTGRES_HOST is not set")
+	}
+
+	db, err := NewPostgres(PostgresConfig{
+		Host:     host,
+		Port:     5432,
+		Database: "yuri69",
+		Username: "yuri69",
+		Password: "yuri69",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestNullTimeUTC(t *testing.T) {
+	assert.False(t, pg_nullTimeUTC(nil).Valid)
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, tokyo)
+
+	v := pg_nullTimeUTC(&at)
+	assert.True(t, v.Valid)
+	assert.Equal(t, time.UTC, v.Time.Location())
+	assert.True(t, at.Equal(v.Time))
+}
+
+func TestScheduleTimesRoundTrip(t *testing.T) {
+	db := newTestPostgres(t)
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	at := time.Date(2026, 10, 18, 9, 0, 0, 0, tokyo)
+	lastRun := time.Date(2026, 10, 17, 23, 30, 0, 0, tokyo)
+
+	s := Schedule{
+		Id:        xid.New().String(),
+		GuildID:   "guild",
+		ChannelID: "channel",
+		Ident:     "sound",
+		At:        &at,
+		LastRun:   &lastRun,
+		Enabled:   true,
+		Created:   time.Now(),
+	}
+	s.Creator.ID = "user"
+
+	err := db.PutSchedule(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.RemoveSchedule(s.Id) })
+
+	got, err := db.GetSchedule(s.Id)
+	assert.Nil(t, err)
+	if assert.NotNil(t, got.At) && assert.NotNil(t, got.LastRun) {
+		assert.True(t, at.Equal(*got.At), got.At)
+		assert.True(t, lastRun.Equal(*got.LastRun), got.LastRun)
+	}
+
+	lastRun = time.Date(2026, 10, 18, 9, 0, 5, 0, tokyo)
+	err = db.SetScheduleRun(s.Id, lastRun, true)
+	assert.Nil(t, err)
+
+	got, err = db.GetSchedule(s.Id)
+	assert.Nil(t, err)
+	assert.False(t, got.Enabled)
+	if assert.NotNil(t, got.LastRun) {
+		assert.True(t, lastRun.Equal(*got.LastRun), got.LastRun)
+	}
+}
@@ -78,6 +78,22 @@ func (t *Discord) UsersInGuildVoice(guildID string) ([]string, error) {
 	return userIDs, nil
 }
 
+func (t *Discord) UsersInVoiceChannel(guildID, channelID string) ([]string, error) {
+	g, err := t.session.State.Guild(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	var userIDs []string
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID == channelID && vs.UserID != t.session.State.User.ID {
+			userIDs = append(userIDs, vs.UserID)
+		}
+	}
+
+	return userIDs, nil
+}
+
 func (t *Discord) GetGuild(id string) (discordgo.Guild, error) {
 	guild, err := t.session.State.Guild(id)
 	if err == nil {
@@ -1,6 +1,47 @@
 package lavalink
 
 type LavalinkConfig struct {
+	// Address and Password configure a single node. They are
+	// only used when no Nodes are configured.
 	Address  string
 	Password string
+
+	// Version is the Lavalink protocol version used for
+	// nodes which do not specify a version. Supported
+	// versions are 3 and 4. Defaults to 4.
+	Version int
+
+	Nodes []NodeConfig
+}
+
+type NodeConfig struct {
+	Name     string
+	Address  string
+	Password string
+	Version  int
+}
+
+func (t LavalinkConfig) nodes() []NodeConfig {
+	nodes := t.Nodes
+	if len(nodes) == 0 && t.Address != "" {
+		nodes = []NodeConfig{{
+			Address:  t.Address,
+			Password: t.Password,
+		}}
+	}
+
+	version := t.Version
+	if version == 0 {
+		version = 4
+	}
+
+	res := make([]NodeConfig, 0, len(nodes))
+	for _, n := range nodes {
+		if n.Version == 0 {
+			n.Version = version
+		}
+		res = append(res, n)
+	}
+
+	return res
 }
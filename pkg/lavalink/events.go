@@ -0,0 +1,52 @@
+package lavalink
+
+import "time"
+
+// The following events are passed to the event handler
+// given to New, independent of the protocol version of
+// the node they originate from.
+
+// ReconnectedEvent is emitted when the connection to a node
+// has been re-established. When the session has not been
+// resumed, the players of the node have been lost and need
+// to be restored using RestorePlayer.
+type ReconnectedEvent struct {
+	Node    string
+	Resumed bool
+}
+
+type PlayerUpdateEvent struct {
+	GuildID   string
+	Position  time.Duration
+	Connected bool
+}
+
+type TrackStartEvent struct {
+	GuildID string
+	TrackID string
+}
+
+type TrackEndEvent struct {
+	GuildID string
+	TrackID string
+	Reason  string
+}
+
+type TrackExceptionEvent struct {
+	GuildID string
+	TrackID string
+	Error   string
+}
+
+type TrackStuckEvent struct {
+	GuildID   string
+	TrackID   string
+	Threshold time.Duration
+}
+
+type WebSocketClosedEvent struct {
+	GuildID  string
+	Code     int
+	Reason   string
+	ByRemote bool
+}
@@ -0,0 +1,130 @@
+package lavalink
+
+import (
+	"github.com/lukasl-dev/waterlink/v2/filter"
+)
+
+// Filters describes a set of Lavalink audio filters which
+// are applied to the playback of a guild player. All filters
+// are optional and leaving them out disables them.
+type Filters struct {
+	Volume    float32         `json:"volume,omitempty"`
+	Equalizer []EqualizerBand `json:"equalizer,omitempty"`
+	Karaoke   *Karaoke        `json:"karaoke,omitempty"`
+	Timescale *Timescale      `json:"timescale,omitempty"`
+	Tremolo   *Tremolo        `json:"tremolo,omitempty"`
+	Rotation  *Rotation       `json:"rotation,omitempty"`
+}
+
+type EqualizerBand struct {
+	Band uint8   `json:"band"`
+	Gain float32 `json:"gain"`
+}
+
+type Karaoke struct {
+	Level       float32 `json:"level,omitempty"`
+	MonoLevel   float32 `json:"monoLevel,omitempty"`
+	FilterBand  float32 `json:"filterBand,omitempty"`
+	FilterWidth float32 `json:"filterWidth,omitempty"`
+}
+
+type Timescale struct {
+	Speed float32 `json:"speed,omitempty"`
+	Pitch float32 `json:"pitch,omitempty"`
+	Rate  float32 `json:"rate,omitempty"`
+}
+
+type Tremolo struct {
+	Frequency float32 `json:"frequency,omitempty"`
+	Depth     float32 `json:"depth,omitempty"`
+}
+
+type Rotation struct {
+	RotationHz float32 `json:"rotationHz,omitempty"`
+}
+
+// IsEmpty returns true when no filter is set.
+func (t Filters) IsEmpty() bool {
+	return t.Volume == 0 &&
+		len(t.Equalizer) == 0 &&
+		t.Karaoke == nil &&
+		t.Timescale == nil &&
+		t.Tremolo == nil &&
+		t.Rotation == nil
+}
+
+// Merge returns a copy of the filters with all filters
+// set in other applied on top of it. Equalizer bands set
+// in other replace the bands with the same index.
+func (t Filters) Merge(other Filters) Filters {
+	if other.Volume != 0 {
+		t.Volume = other.Volume
+	}
+	if other.Karaoke != nil {
+		t.Karaoke = other.Karaoke
+	}
+	if other.Timescale != nil {
+		t.Timescale = other.Timescale
+	}
+	if other.Tremolo != nil {
+		t.Tremolo = other.Tremolo
+	}
+	if other.Rotation != nil {
+		t.Rotation = other.Rotation
+	}
+
+	if len(other.Equalizer) != 0 {
+		bands := make([]EqualizerBand, 0, len(t.Equalizer)+len(other.Equalizer))
+		for _, band := range t.Equalizer {
+			if !containsBand(other.Equalizer, band.Band) {
+				bands = append(bands, band)
+			}
+		}
+		t.Equalizer = append(bands, other.Equalizer...)
+	}
+
+	return t
+}
+
+func (t Filters) toWaterlink() filter.Filters {
+	var f filter.Filters
+
+	f.Volume = t.Volume
+	if t.Karaoke != nil {
+		f.Karaoke = &filter.Karaoke{
+			Level:       t.Karaoke.Level,
+			MonoLevel:   t.Karaoke.MonoLevel,
+			FilterBand:  t.Karaoke.FilterBand,
+			FilterWidth: t.Karaoke.FilterWidth,
+		}
+	}
+	if t.Timescale != nil {
+		f.Timescale = &filter.Timescale{
+			Speed: t.Timescale.Speed,
+			Pitch: t.Timescale.Pitch,
+			Rate:  t.Timescale.Rate,
+		}
+	}
+	if t.Tremolo != nil {
+		f.Tremolo = &filter.Tremolo{
+			Frequency: t.Tremolo.Frequency,
+			Depth:     t.Tremolo.Depth,
+		}
+	}
+	if t.Rotation != nil {
+		f.Rotation = &filter.Rotation{
+			RotationHz: t.Rotation.RotationHz,
+		}
+	}
+
+	return f
+}
+
+func containsBand(bands []EqualizerBand, band uint8) bool {
+	for _, b := range bands {
+		if b.Band == band {
+			return true
+		}
+	}
+	return false
+}
@@ -2,57 +2,62 @@ package lavalink
 
 import (
 	"errors"
-	"fmt"
 	"math/rand"
-	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/gompus/snowflake"
-	"github.com/lukasl-dev/waterlink/v2"
-	"github.com/lukasl-dev/waterlink/v2/track"
-	"github.com/lukasl-dev/waterlink/v2/track/query"
 	"github.com/sirupsen/logrus"
 	"github.com/zekrotja/yuri69/pkg/discord"
+	"github.com/zekrotja/yuri69/pkg/generic"
 )
 
-type Lavalink struct {
-	dc     *discord.Discord
-	client *waterlink.Client
-	conn   *waterlink.Connection
+var (
+	ErrNoNodeAvailable = errors.New("no lavalink node available")
+	ErrNoVoiceServer   = errors.New("no voice server known for guild")
+)
 
-	address         string
-	creds           waterlink.Credentials
-	opts            waterlink.ConnectionOptions
-	reconnectionTry int
+type Lavalink struct {
+	dc           *discord.Discord
+	nodes        []INode
+	players      generic.SyncMap[string, *guildPlayer]
+	eventHandler func(any)
 }
 
+var _ nodeHandler = (*Lavalink)(nil)
+
 func New(c LavalinkConfig, dc *discord.Discord, eventHandler func(any)) (*Lavalink, error) {
 	var t Lavalink
-	var err error
 
-	t.address = c.Address
 	t.dc = dc
+	t.eventHandler = eventHandler
 
-	t.creds = waterlink.Credentials{
-		Authorization: c.Password,
-		UserID:        snowflake.MustParse(t.dc.Session().State.User.ID),
-		ResumeKey:     "yuri69session",
-	}
-	t.opts = waterlink.ConnectionOptions{
-		HandleEventError: t.handleErrors,
-	}
-	if eventHandler != nil {
-		t.opts.EventHandler = waterlink.EventHandlerFunc(eventHandler)
+	nodeConfigs := c.nodes()
+	if len(nodeConfigs) == 0 {
+		return nil, errors.New("no lavalink nodes have been configured")
 	}
 
-	t.client, err = waterlink.NewClient(fmt.Sprintf("http://%s", c.Address), t.creds)
-	if err != nil {
-		return nil, err
+	userID := t.dc.Session().State.User.ID
+
+	var lastErr error
+	connected := 0
+	for _, nc := range nodeConfigs {
+		n, err := newNode(nc, userID, &t)
+		if err != nil {
+			return nil, err
+		}
+		t.nodes = append(t.nodes, n)
+
+		if err = n.Connect(); err != nil {
+			lastErr = err
+			logrus.WithError(err).WithField("node", n.Name()).Error("Connecting to lavalink node failed")
+			go t.reconnect(n)
+			continue
+		}
+		connected++
 	}
 
-	if err = t.Connect(); err != nil {
-		return nil, err
+	if connected == 0 {
+		return nil, lastErr
 	}
 
 	t.dc.Session().AddHandler(t.handleVoiceServerUpdate)
@@ -60,122 +65,377 @@ func New(c LavalinkConfig, dc *discord.Discord, eventHandler func(any)) (*Lavali
 	return &t, nil
 }
 
-func (t *Lavalink) Connect() error {
-	if t.conn != nil && !t.conn.Closed() {
-		return errors.New("connection already established")
+func (t *Lavalink) Close() error {
+	var lastErr error
+	for _, n := range t.nodes {
+		if err := n.Close(); err != nil {
+			lastErr = err
+		}
 	}
-	var err error
-	t.conn, err = waterlink.Open(fmt.Sprintf("ws://%s", t.address), t.creds, t.opts)
-	return err
+	return lastErr
 }
 
-func (t *Lavalink) Close() error {
-	return t.conn.Close()
+func (t *Lavalink) Play(guildID, ident string) (Track, error) {
+	var tr Track
+	err := t.withPlayer(guildID, func(n INode, p *guildPlayer) error {
+		var err error
+		tr, err = n.LoadTrack(ident)
+		if err != nil {
+			return err
+		}
+		if err = n.Play(guildID, tr, 0, p.paused); err != nil {
+			return err
+		}
+		p.setTrack(&tr)
+		return nil
+	})
+	return tr, err
 }
 
-func (t *Lavalink) Play(guildID, ident string) (track.Track, error) {
-	tracks, err := t.client.LoadTracks(query.Of(ident))
-	if err != nil {
-		return track.Track{}, err
+func (t *Lavalink) Destroy(guildID string) error {
+	p, ok := t.players.LoadAndDelete(guildID)
+	if !ok {
+		return nil
 	}
 
-	logrus.
-		WithField("type", tracks.LoadType).
-		WithField("n", len(tracks.Tracks)).
-		Debug("Tracks loaded")
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
 
-	if len(tracks.Tracks) == 0 {
-		return track.Track{}, errors.New("no tracks have been loaded")
+	if p.node == nil || !p.node.Available() {
+		return nil
 	}
 
-	sf, err := snowflake.Parse(guildID)
-	if err != nil {
-		return track.Track{}, err
+	return p.node.Destroy(guildID)
+}
+
+func (t *Lavalink) Stop(guildID string) error {
+	return t.withPlayer(guildID, func(n INode, p *guildPlayer) error {
+		p.setTrack(nil)
+		return n.Stop(guildID)
+	})
+}
+
+func (t *Lavalink) SetPaused(guildID string, paused bool) error {
+	return t.withPlayer(guildID, func(n INode, p *guildPlayer) error {
+		if err := n.SetPaused(guildID, paused); err != nil {
+			return err
+		}
+		p.setPosition(p.currentPosition())
+		p.paused = paused
+		return nil
+	})
+}
+
+func (t *Lavalink) Seek(guildID string, position time.Duration) error {
+	return t.withPlayer(guildID, func(n INode, p *guildPlayer) error {
+		if err := n.Seek(guildID, position); err != nil {
+			return err
+		}
+		p.setPosition(position)
+		return nil
+	})
+}
+
+// SetFilters applies the given filters to the player of the
+// guild. Passing empty filters resets all filters.
+func (t *Lavalink) SetFilters(guildID string, filters Filters) error {
+	return t.withPlayer(guildID, func(n INode, p *guildPlayer) error {
+		if err := n.SetFilters(guildID, filters); err != nil {
+			return err
+		}
+		p.filters = filters
+		return nil
+	})
+}
+
+func (t *Lavalink) SetVolume(guildID string, volume uint16) error {
+	return t.withPlayer(guildID, func(n INode, p *guildPlayer) error {
+		if err := n.SetVolume(guildID, volume); err != nil {
+			return err
+		}
+		p.volume = volume
+		return nil
+	})
+}
+
+// RestorePlayer re-sends the last received voice server
+// update of the guild and the known player state like
+// volume, filters and the currently played track to the
+// node of the guild player.
+func (t *Lavalink) RestorePlayer(guildID string) error {
+	p, ok := t.players.Load(guildID)
+	if !ok {
+		return ErrNoVoiceServer
 	}
 
-	track := tracks.Tracks[0]
-	return track, t.conn.Guild(sf).PlayTrack(track)
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.voice == nil {
+		return ErrNoVoiceServer
+	}
+
+	n := p.node
+	if n == nil || !n.Available() {
+		n = t.bestNode()
+	}
+	if n == nil {
+		return ErrNoNodeAvailable
+	}
+
+	return t.restorePlayer(guildID, p, n)
 }
 
-func (t *Lavalink) Destroy(guildID string) error {
-	sf, err := snowflake.Parse(guildID)
-	if err != nil {
-		return err
+func (t *Lavalink) DecodeTrackId(uid string) (*TrackInfo, error) {
+	n := t.bestNode()
+	if n == nil {
+		return nil, ErrNoNodeAvailable
 	}
+	return n.DecodeTrack(uid)
+}
+
+// --- Internal stuff ---
 
-	return t.conn.Guild(sf).Destroy()
+func (t *Lavalink) getPlayer(guildID string) *guildPlayer {
+	p, _ := t.players.LoadOrStore(guildID, &guildPlayer{})
+	return p
 }
 
-func (t *Lavalink) Stop(guildID string) error {
-	sf, err := snowflake.Parse(guildID)
+func (t *Lavalink) withPlayer(guildID string, f func(n INode, p *guildPlayer) error) error {
+	p := t.getPlayer(guildID)
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	n, err := t.nodeOf(guildID, p)
 	if err != nil {
 		return err
 	}
 
-	return t.conn.Guild(sf).Stop()
+	return f(n, p)
 }
 
-func (t *Lavalink) SetVolume(guildID string, volume uint16) error {
-	sf, err := snowflake.Parse(guildID)
+// nodeOf returns the node the given player is assigned to.
+// If the player is not assigned to an available node yet, it
+// is assigned to the least loaded available node.
+//
+// The player must be locked by the caller.
+func (t *Lavalink) nodeOf(guildID string, p *guildPlayer) (INode, error) {
+	if p.node != nil && p.node.Available() {
+		return p.node, nil
+	}
+
+	n := t.bestNode()
+	if n == nil {
+		return nil, ErrNoNodeAvailable
+	}
+
+	if err := t.restorePlayer(guildID, p, n); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// bestNode returns the available node with the lowest
+// load penalty or nil if no node is available.
+func (t *Lavalink) bestNode() INode {
+	var (
+		best        INode
+		bestPenalty float64
+	)
+
+	for _, n := range t.nodes {
+		if !n.Available() {
+			continue
+		}
+		penalty := n.Stats().penalty()
+		if best == nil || penalty < bestPenalty {
+			best = n
+			bestPenalty = penalty
+		}
+	}
+
+	return best
+}
+
+// restorePlayer assigns the player to the given node and
+// transfers the known voice connection, volume, filters and
+// the currently played track to it.
+//
+// The player must be locked by the caller.
+func (t *Lavalink) restorePlayer(guildID string, p *guildPlayer, n INode) error {
+	p.node = n
+
+	if p.voice == nil {
+		return nil
+	}
+
+	err := n.UpdateVoice(guildID, *p.voice)
 	if err != nil {
 		return err
 	}
 
-	return t.conn.Guild(sf).UpdateVolume(volume)
+	if !p.filters.IsEmpty() {
+		if err = n.SetFilters(guildID, p.filters); err != nil {
+			return err
+		}
+	}
+
+	if p.track != nil {
+		position := p.currentPosition()
+		if err = n.Play(guildID, *p.track, position, p.paused); err != nil {
+			return err
+		}
+		p.setPosition(position)
+	}
+
+	// The volume is restored after the track has been
+	// started, so that it is not reset by the new track.
+	if p.volume != 0 {
+		if err = n.SetVolume(guildID, p.volume); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (t *Lavalink) DecodeTrackId(uid string) (*track.Info, error) {
-	return t.client.DecodeTrack(uid)
+// migratePlayers moves all players assigned to the given
+// node to the least loaded available node. When no other
+// node is available, the players stay assigned so that they
+// can be resumed when the node comes back.
+func (t *Lavalink) migratePlayers(from INode) {
+	t.players.Range(func(guildID string, p *guildPlayer) bool {
+		p.mtx.Lock()
+		defer p.mtx.Unlock()
+
+		if p.node != from {
+			return true
+		}
+
+		n := t.bestNode()
+		if n == nil {
+			logrus.
+				WithField("guild", guildID).
+				WithField("node", from.Name()).
+				Warn("No lavalink node available to migrate player to")
+			return true
+		}
+
+		err := t.restorePlayer(guildID, p, n)
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("guild", guildID).
+				WithField("node", n.Name()).
+				Error("Migrating player failed")
+			return true
+		}
+
+		logrus.
+			WithField("guild", guildID).
+			WithField("from", from.Name()).
+			WithField("to", n.Name()).
+			Info("Player migrated to another lavalink node")
+		return true
+	})
 }
 
-func (t *Lavalink) handleVoiceServerUpdate(s *discordgo.Session, e *discordgo.VoiceServerUpdate) {
-	logrus.
-		WithField("guild", e.GuildID).
-		WithField("sessionID", s.State.SessionID).
-		Debugf("Update voice server: %+v", e)
+func (t *Lavalink) reconnect(n INode) {
+	for try := 0; ; try++ {
+		timeout := time.Duration(try)*500*time.Millisecond + time.Duration(rand.Intn(900)+100)*time.Millisecond
+
+		if timeout > 30*time.Second {
+			timeout = 30 * time.Second
+		}
 
-	g := t.conn.Guild(snowflake.MustParse(e.GuildID))
-	err := g.UpdateVoice(s.State.SessionID, e.Token, e.Endpoint)
-	if err != nil {
 		logrus.
-			WithError(err).
-			WithField("guild", e.GuildID).
-			WithField("sessionID", s.State.SessionID).
-			Error("Voice server update failed")
+			WithField("node", n.Name()).
+			WithField("try", try).
+			WithField("timeout", timeout).
+			Warn("Lavalink: Trying to reconnect ...")
+		time.Sleep(timeout)
+
+		err := n.Connect()
+		if err == nil {
+			break
+		}
+
+		logrus.WithError(err).WithField("node", n.Name()).Error("Lavalink reconnect failed")
 	}
-}
 
-func (t *Lavalink) handleErrors(err error) {
-	if strings.Contains(err.Error(), "waterlink: connection: websocket: close") {
-		logrus.WithError(err).Error("Lavalink connection closed")
-		t.tryReconnecting()
-		return
+	logrus.
+		WithField("node", n.Name()).
+		WithField("resumed", n.SessionResumed()).
+		Info("Lavalink connection re-established")
+
+	if t.eventHandler != nil {
+		t.eventHandler(ReconnectedEvent{
+			Node:    n.Name(),
+			Resumed: n.SessionResumed(),
+		})
 	}
+}
 
-	logrus.WithError(err).Error("Lavalink error")
+func (t *Lavalink) handleNodeDisconnect(n INode, err error) {
+	logrus.WithError(err).WithField("node", n.Name()).Error("Lavalink connection closed")
+
+	t.migratePlayers(n)
+	t.reconnect(n)
 }
 
-func (t *Lavalink) tryReconnecting() {
-	timeout := time.Duration(t.reconnectionTry)*500*time.Millisecond + time.Duration(rand.Intn(900)+100)*time.Millisecond
+func (t *Lavalink) handleNodeEvent(n INode, e any) {
+	switch et := e.(type) {
+	case PlayerUpdateEvent:
+		if p, ok := t.players.Load(et.GuildID); ok {
+			p.mtx.Lock()
+			p.setPosition(et.Position)
+			p.mtx.Unlock()
+		}
+	case TrackEndEvent:
+		if p, ok := t.players.Load(et.GuildID); ok {
+			p.mtx.Lock()
+			if p.track != nil && p.track.ID == et.TrackID {
+				p.setTrack(nil)
+			}
+			p.mtx.Unlock()
+		}
+	}
 
-	if timeout > 30*time.Second {
-		timeout = 30 * time.Second
+	if t.eventHandler != nil {
+		t.eventHandler(e)
 	}
+}
 
+func (t *Lavalink) handleVoiceServerUpdate(s *discordgo.Session, e *discordgo.VoiceServerUpdate) {
 	logrus.
-		WithField("try", t.reconnectionTry).
-		WithField("timeout", timeout).
-		Warn("Lavalink: Trying to reconnect ...")
-	time.Sleep(timeout)
+		WithField("guild", e.GuildID).
+		WithField("sessionID", s.State.SessionID).
+		Debugf("Update voice server: %+v", e)
 
-	t.reconnectionTry++
-	err := t.Connect()
-	if err != nil {
-		logrus.WithError(err)
-		t.tryReconnecting()
-		return
+	p := t.getPlayer(e.GuildID)
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.voice = &VoiceServer{
+		SessionID: s.State.SessionID,
+		Token:     e.Token,
+		Endpoint:  e.Endpoint,
 	}
 
-	t.reconnectionTry = 0
-	logrus.Info("Lavalink connection re-established")
+	// When the player is not assigned to an available node yet,
+	// the voice server is transferred on assignment.
+	var err error
+	if p.node != nil && p.node.Available() {
+		err = p.node.UpdateVoice(e.GuildID, *p.voice)
+	} else {
+		_, err = t.nodeOf(e.GuildID, p)
+	}
+	if err != nil {
+		logrus.
+			WithError(err).
+			WithField("guild", e.GuildID).
+			WithField("sessionID", s.State.SessionID).
+			Error("Voice server update failed")
+	}
 }
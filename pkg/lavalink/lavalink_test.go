@@ -0,0 +1,303 @@
+package lavalink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testGuildID  = "123456789012345678"
+	testPassword = "password"
+	testTrackID  = "encoded-track"
+)
+
+type fakeUpdate struct {
+	SessionID string
+	GuildID   string
+	Update    v4PlayerUpdate
+}
+
+// fakeLavalink is a minimal Lavalink v4 server which records
+// all player updates and can drop the websocket connection
+// to simulate a restart of the node.
+type fakeLavalink struct {
+	srv      *httptest.Server
+	upgrader websocket.Upgrader
+
+	mtx         sync.Mutex
+	conn        *websocket.Conn
+	sessions    int
+	sessionID   string
+	allowResume bool
+
+	updates chan fakeUpdate
+}
+
+func newFakeLavalink() *fakeLavalink {
+	t := &fakeLavalink{
+		updates: make(chan fakeUpdate, 100),
+	}
+	t.srv = httptest.NewServer(http.HandlerFunc(t.handle))
+	return t
+}
+
+func (t *fakeLavalink) Address() string {
+	return strings.TrimPrefix(t.srv.URL, "http://")
+}
+
+func (t *fakeLavalink) Close() {
+	t.Disconnect()
+	t.srv.Close()
+}
+
+func (t *fakeLavalink) Disconnect() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn = nil
+	}
+}
+
+func (t *fakeLavalink) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != testPassword {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.URL.Path == "/v4/websocket":
+		t.handleWebsocket(w, r)
+	case r.URL.Path == "/v4/loadtracks":
+		json.NewEncoder(w).Encode(map[string]any{
+			"loadType": "track",
+			"data": v4Track{
+				Encoded: testTrackID,
+				Info: v4TrackInfo{
+					Identifier: r.URL.Query().Get("identifier"),
+					URI:        r.URL.Query().Get("identifier"),
+					IsSeekable: true,
+					Length:     60000,
+				},
+			},
+		})
+	case len(path) == 5 && path[3] == "players" && r.Method == http.MethodPatch:
+		var update v4PlayerUpdate
+		json.NewDecoder(r.Body).Decode(&update)
+		t.updates <- fakeUpdate{
+			SessionID: path[2],
+			GuildID:   path[4],
+			Update:    update,
+		}
+		w.Write([]byte("{}"))
+	default:
+		w.Write([]byte("{}"))
+	}
+}
+
+func (t *fakeLavalink) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := t.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	t.mtx.Lock()
+	resumed := t.allowResume && t.sessionID != "" &&
+		r.Header.Get("Session-Id") == t.sessionID
+	if !resumed {
+		t.sessions++
+		t.sessionID = fmt.Sprintf("session-%d", t.sessions)
+	}
+	t.conn = conn
+	sessionID := t.sessionID
+	t.mtx.Unlock()
+
+	conn.WriteJSON(map[string]any{
+		"op":        "ready",
+		"resumed":   resumed,
+		"sessionId": sessionID,
+	})
+
+	for {
+		if _, _, err = conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func nextUpdate(t *testing.T, fake *fakeLavalink) fakeUpdate {
+	t.Helper()
+
+	select {
+	case u := <-fake.updates:
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for player update")
+		return fakeUpdate{}
+	}
+}
+
+func awaitReconnect(t *testing.T, events <-chan any) ReconnectedEvent {
+	t.Helper()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if re, ok := e.(ReconnectedEvent); ok {
+				return re
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for reconnect")
+			return ReconnectedEvent{}
+		}
+	}
+}
+
+func setupLavalink(t *testing.T, fake *fakeLavalink) (*Lavalink, <-chan any) {
+	t.Helper()
+
+	events := make(chan any, 100)
+	ll := &Lavalink{
+		eventHandler: func(e any) { events <- e },
+	}
+
+	n, err := newNode(NodeConfig{
+		Address:  fake.Address(),
+		Password: testPassword,
+		Version:  4,
+	}, "1234", ll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ll.nodes = []INode{n}
+
+	if err = n.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &discordgo.Session{State: discordgo.NewState()}
+	s.State.SessionID = "discord-session"
+	ll.handleVoiceServerUpdate(s, &discordgo.VoiceServerUpdate{
+		GuildID:  testGuildID,
+		Token:    "voice-token",
+		Endpoint: "voice.discord.media",
+	})
+
+	u := nextUpdate(t, fake)
+	assert.Equal(t, "session-1", u.SessionID)
+	assert.Equal(t, testGuildID, u.GuildID)
+	if assert.NotNil(t, u.Update.Voice) {
+		assert.Equal(t, "voice-token", u.Update.Voice.Token)
+		assert.Equal(t, "discord-session", u.Update.Voice.SessionID)
+	}
+
+	return ll, events
+}
+
+func TestRestorePlayerAfterReconnect(t *testing.T) {
+	fake := newFakeLavalink()
+	defer fake.Close()
+
+	ll, events := setupLavalink(t, fake)
+	defer ll.Close()
+
+	tr, err := ll.Play(testGuildID, "http://yuri69:6969/file/sound")
+	assert.Nil(t, err)
+	assert.Equal(t, testTrackID, tr.ID)
+	u := nextUpdate(t, fake)
+	if assert.NotNil(t, u.Update.Track) && assert.NotNil(t, u.Update.Track.Encoded) {
+		assert.Equal(t, testTrackID, *u.Update.Track.Encoded)
+	}
+
+	assert.Nil(t, ll.SetVolume(testGuildID, 42))
+	nextUpdate(t, fake)
+
+	fake.Disconnect()
+
+	re := awaitReconnect(t, events)
+	assert.False(t, re.Resumed)
+
+	assert.Nil(t, ll.RestorePlayer(testGuildID))
+
+	u = nextUpdate(t, fake)
+	assert.Equal(t, "session-2", u.SessionID)
+	if assert.NotNil(t, u.Update.Voice) {
+		assert.Equal(t, "voice-token", u.Update.Voice.Token)
+		assert.Equal(t, "voice.discord.media", u.Update.Voice.Endpoint)
+		assert.Equal(t, "discord-session", u.Update.Voice.SessionID)
+	}
+
+	u = nextUpdate(t, fake)
+	assert.Equal(t, "session-2", u.SessionID)
+	if assert.NotNil(t, u.Update.Track) && assert.NotNil(t, u.Update.Track.Encoded) {
+		assert.Equal(t, testTrackID, *u.Update.Track.Encoded)
+	}
+	if assert.NotNil(t, u.Update.Position) {
+		assert.Greater(t, *u.Update.Position, int64(0))
+	}
+
+	u = nextUpdate(t, fake)
+	if assert.NotNil(t, u.Update.Volume) {
+		assert.Equal(t, 42, *u.Update.Volume)
+	}
+}
+
+func TestResumeSessionAfterReconnect(t *testing.T) {
+	fake := newFakeLavalink()
+	fake.allowResume = true
+	defer fake.Close()
+
+	ll, events := setupLavalink(t, fake)
+	defer ll.Close()
+
+	fake.Disconnect()
+
+	re := awaitReconnect(t, events)
+	assert.True(t, re.Resumed)
+
+	assert.Nil(t, ll.SetVolume(testGuildID, 42))
+	u := nextUpdate(t, fake)
+	assert.Equal(t, "session-1", u.SessionID)
+	assert.Nil(t, u.Update.Voice)
+}
+
+func TestRestorePlayerWithoutVoiceServer(t *testing.T) {
+	var ll Lavalink
+	assert.ErrorIs(t, ll.RestorePlayer(testGuildID), ErrNoVoiceServer)
+}
+
+func TestV3ReplacedTrackEnd(t *testing.T) {
+	n := &nodeV3{
+		active:   map[string]bool{},
+		replaced: map[string]int{},
+	}
+
+	n.trackStarted(testGuildID)
+	n.trackStarted(testGuildID)
+	assert.Equal(t, "replaced", n.trackEnded(testGuildID))
+	assert.Equal(t, "", n.trackEnded(testGuildID))
+
+	n.trackStarted(testGuildID)
+	assert.Equal(t, "", n.trackEnded(testGuildID))
+}
+
+func TestDefaultVersion(t *testing.T) {
+	nodes := LavalinkConfig{Address: "localhost:2333"}.nodes()
+	if assert.Len(t, nodes, 1) {
+		assert.Equal(t, 4, nodes[0].Version)
+	}
+}
@@ -0,0 +1,124 @@
+package lavalink
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// v4Message is the union of all messages sent by a Lavalink
+// v4 node via the websocket connection.
+type v4Message struct {
+	Op string `json:"op"`
+
+	// ready
+	Resumed   bool   `json:"resumed"`
+	SessionID string `json:"sessionId"`
+
+	// playerUpdate
+	GuildID string `json:"guildId"`
+	State   struct {
+		Time      int64 `json:"time"`
+		Position  int64 `json:"position"`
+		Connected bool  `json:"connected"`
+	} `json:"state"`
+
+	// stats
+	Players        int `json:"players"`
+	PlayingPlayers int `json:"playingPlayers"`
+	CPU            struct {
+		SystemLoad   float64 `json:"systemLoad"`
+		LavalinkLoad float64 `json:"lavalinkLoad"`
+	} `json:"cpu"`
+	FrameStats *struct {
+		Deficit int `json:"deficit"`
+	} `json:"frameStats"`
+
+	// event
+	Type        string       `json:"type"`
+	Track       *v4Track     `json:"track"`
+	Reason      string       `json:"reason"`
+	Exception   *v4Exception `json:"exception"`
+	ThresholdMs int64        `json:"thresholdMs"`
+	Code        int          `json:"code"`
+	ByRemote    bool         `json:"byRemote"`
+}
+
+type v4Track struct {
+	Encoded string      `json:"encoded"`
+	Info    v4TrackInfo `json:"info"`
+}
+
+func (t v4Track) toTrack() Track {
+	return Track{
+		ID:   t.Encoded,
+		Info: t.Info.toTrackInfo(),
+	}
+}
+
+type v4TrackInfo struct {
+	Identifier string `json:"identifier"`
+	IsSeekable bool   `json:"isSeekable"`
+	Author     string `json:"author"`
+	Length     int64  `json:"length"`
+	IsStream   bool   `json:"isStream"`
+	Title      string `json:"title"`
+	URI        string `json:"uri"`
+	SourceName string `json:"sourceName"`
+}
+
+func (t v4TrackInfo) toTrackInfo() TrackInfo {
+	return TrackInfo{
+		Identifier: t.Identifier,
+		Title:      t.Title,
+		Author:     t.Author,
+		URI:        t.URI,
+		SourceName: t.SourceName,
+		Length:     time.Duration(t.Length) * time.Millisecond,
+		Seekable:   t.IsSeekable,
+		Stream:     t.IsStream,
+	}
+}
+
+type v4Exception struct {
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+	Cause    string `json:"cause"`
+}
+
+type v4LoadResult struct {
+	LoadType string          `json:"loadType"`
+	Data     json.RawMessage `json:"data"`
+}
+
+type v4Error struct {
+	Status  int    `json:"status"`
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Path    string `json:"path"`
+}
+
+type v4SessionUpdate struct {
+	Resuming bool `json:"resuming"`
+	Timeout  int  `json:"timeout"`
+}
+
+type v4PlayerUpdate struct {
+	Track    *v4UpdateTrack `json:"track,omitempty"`
+	Position *int64         `json:"position,omitempty"`
+	Volume   *int           `json:"volume,omitempty"`
+	Paused   *bool          `json:"paused,omitempty"`
+	Filters  *Filters       `json:"filters,omitempty"`
+	Voice    *v4VoiceState  `json:"voice,omitempty"`
+}
+
+// v4UpdateTrack sets the track of a player. An Encoded
+// value of nil stops the currently played track.
+type v4UpdateTrack struct {
+	Encoded *string `json:"encoded"`
+}
+
+type v4VoiceState struct {
+	Token     string `json:"token"`
+	Endpoint  string `json:"endpoint"`
+	SessionID string `json:"sessionId"`
+}
@@ -0,0 +1,88 @@
+package lavalink
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// INode is a connection to a single Lavalink server
+// which abstracts the used protocol version.
+type INode interface {
+	Name() string
+
+	Connect() error
+	Close() error
+	Available() bool
+	SessionResumed() bool
+	Stats() *Stats
+
+	LoadTrack(ident string) (Track, error)
+	DecodeTrack(trackID string) (*TrackInfo, error)
+
+	UpdateVoice(guildID string, voice VoiceServer) error
+	Play(guildID string, tr Track, position time.Duration, paused bool) error
+	Stop(guildID string) error
+	Destroy(guildID string) error
+	SetPaused(guildID string, paused bool) error
+	Seek(guildID string, position time.Duration) error
+	SetVolume(guildID string, volume uint16) error
+	SetFilters(guildID string, filters Filters) error
+}
+
+// nodeHandler receives the events and connection state
+// changes of a node.
+type nodeHandler interface {
+	handleNodeEvent(n INode, e any)
+	handleNodeDisconnect(n INode, err error)
+}
+
+type VoiceServer struct {
+	SessionID string
+	Token     string
+	Endpoint  string
+}
+
+// Stats contains the load statistics last reported by a node.
+type Stats struct {
+	Players        int
+	PlayingPlayers int
+	SystemLoad     float64
+	LavalinkLoad   float64
+	FrameDeficit   int
+}
+
+// penalty calculates a score of the load of the node.
+// Nodes with a lower penalty should be preferred when
+// assigning players.
+func (t *Stats) penalty() float64 {
+	if t == nil {
+		return 0
+	}
+
+	penalty := float64(t.PlayingPlayers)
+	penalty += math.Pow(1.05, 100*t.SystemLoad)*10 - 10
+	if t.FrameDeficit > 0 {
+		penalty += float64(t.FrameDeficit)
+	}
+
+	return penalty
+}
+
+func newNode(c NodeConfig, userID string, h nodeHandler) (INode, error) {
+	switch c.Version {
+	case 3:
+		return newNodeV3(c, userID, h)
+	case 4:
+		return newNodeV4(c, userID, h)
+	default:
+		return nil, fmt.Errorf("unsupported lavalink version: %d", c.Version)
+	}
+}
+
+func nodeName(c NodeConfig) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Address
+}
@@ -0,0 +1,378 @@
+package lavalink
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gompus/snowflake"
+	"github.com/lukasl-dev/waterlink/v2"
+	"github.com/lukasl-dev/waterlink/v2/event"
+	"github.com/lukasl-dev/waterlink/v2/track"
+	"github.com/lukasl-dev/waterlink/v2/track/query"
+	"github.com/sirupsen/logrus"
+)
+
+// nodeV3 implements INode for the Lavalink v3
+// websocket protocol using waterlink.
+type nodeV3 struct {
+	h nodeHandler
+
+	name    string
+	address string
+
+	client *waterlink.Client
+	conn   *waterlink.Connection
+	creds  waterlink.Credentials
+	opts   waterlink.ConnectionOptions
+
+	mtx       sync.RWMutex
+	available bool
+	stats     *Stats
+
+	// waterlink does not pass the end reason of tracks, so
+	// replaced tracks are tracked per guild. replaced counts
+	// the tracks whose end event has not been received yet
+	// after another track has been started.
+	tracksMtx sync.Mutex
+	active    map[string]bool
+	replaced  map[string]int
+}
+
+var _ INode = (*nodeV3)(nil)
+
+func newNodeV3(c NodeConfig, userID string, h nodeHandler) (*nodeV3, error) {
+	var (
+		t   nodeV3
+		err error
+	)
+
+	t.h = h
+	t.name = nodeName(c)
+	t.active = make(map[string]bool)
+	t.replaced = make(map[string]int)
+	t.address = c.Address
+
+	uid, err := snowflake.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	t.creds = waterlink.Credentials{
+		Authorization: c.Password,
+		UserID:        uid,
+		ResumeKey:     "yuri69session",
+	}
+	t.opts = waterlink.ConnectionOptions{
+		EventHandler:     waterlink.EventHandlerFunc(t.handleEvent),
+		HandleEventError: t.handleErrors,
+	}
+
+	t.client, err = waterlink.NewClient(fmt.Sprintf("http://%s", c.Address), t.creds)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (t *nodeV3) Name() string {
+	return t.name
+}
+
+func (t *nodeV3) Connect() error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.conn != nil && !t.conn.Closed() {
+		return errors.New("connection already established")
+	}
+
+	var err error
+	t.conn, err = waterlink.Open(fmt.Sprintf("ws://%s", t.address), t.creds, t.opts)
+	if err != nil {
+		return err
+	}
+
+	t.available = true
+	t.stats = nil
+
+	// Players and their tracks are lost when the session has
+	// not been resumed.
+	if !t.conn.SessionResumed() {
+		t.tracksMtx.Lock()
+		t.active = make(map[string]bool)
+		t.replaced = make(map[string]int)
+		t.tracksMtx.Unlock()
+	}
+
+	return nil
+}
+
+func (t *nodeV3) Close() error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.available = false
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
+
+func (t *nodeV3) Available() bool {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	return t.available
+}
+
+func (t *nodeV3) SessionResumed() bool {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	return t.conn != nil && t.conn.SessionResumed()
+}
+
+func (t *nodeV3) Stats() *Stats {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	return t.stats
+}
+
+func (t *nodeV3) LoadTrack(ident string) (Track, error) {
+	tracks, err := t.client.LoadTracks(query.Of(ident))
+	if err != nil {
+		return Track{}, err
+	}
+
+	logrus.
+		WithField("node", t.name).
+		WithField("type", tracks.LoadType).
+		WithField("n", len(tracks.Tracks)).
+		Debug("Tracks loaded")
+
+	if len(tracks.Tracks) == 0 {
+		return Track{}, errors.New("no tracks have been loaded")
+	}
+
+	tr := tracks.Tracks[0]
+	return Track{
+		ID:   tr.ID,
+		Info: trackInfoFromV3(tr.Info),
+	}, nil
+}
+
+func (t *nodeV3) DecodeTrack(trackID string) (*TrackInfo, error) {
+	info, err := t.client.DecodeTrack(trackID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := trackInfoFromV3(*info)
+	return &res, nil
+}
+
+func (t *nodeV3) UpdateVoice(guildID string, voice VoiceServer) error {
+	return t.withGuild(guildID, func(g waterlink.Guild) error {
+		return g.UpdateVoice(voice.SessionID, voice.Token, voice.Endpoint)
+	})
+}
+
+func (t *nodeV3) Play(guildID string, tr Track, position time.Duration, paused bool) error {
+	return t.withGuild(guildID, func(g waterlink.Guild) error {
+		err := g.Play(tr.ID, waterlink.PlayParams{
+			StartTime: position,
+			Pause:     paused,
+		})
+		if err == nil {
+			t.trackStarted(guildID)
+		}
+		return err
+	})
+}
+
+func (t *nodeV3) Stop(guildID string) error {
+	return t.withGuild(guildID, func(g waterlink.Guild) error {
+		return g.Stop()
+	})
+}
+
+func (t *nodeV3) Destroy(guildID string) error {
+	t.tracksMtx.Lock()
+	delete(t.active, guildID)
+	delete(t.replaced, guildID)
+	t.tracksMtx.Unlock()
+
+	return t.withGuild(guildID, func(g waterlink.Guild) error {
+		return g.Destroy()
+	})
+}
+
+func (t *nodeV3) SetPaused(guildID string, paused bool) error {
+	return t.withGuild(guildID, func(g waterlink.Guild) error {
+		return g.SetPaused(paused)
+	})
+}
+
+func (t *nodeV3) Seek(guildID string, position time.Duration) error {
+	return t.withGuild(guildID, func(g waterlink.Guild) error {
+		return g.Seek(position)
+	})
+}
+
+func (t *nodeV3) SetVolume(guildID string, volume uint16) error {
+	return t.withGuild(guildID, func(g waterlink.Guild) error {
+		return g.UpdateVolume(volume)
+	})
+}
+
+// SetFilters applies the given filters to the player of the
+// guild. Equalizer bands can not be transmitted via waterlink,
+// so they are omitted.
+func (t *nodeV3) SetFilters(guildID string, filters Filters) error {
+	if len(filters.Equalizer) != 0 {
+		logrus.
+			WithField("node", t.name).
+			WithField("guild", guildID).
+			Warn("Equalizer filters are not supported by Lavalink v3 nodes and will be ignored")
+	}
+
+	return t.withGuild(guildID, func(g waterlink.Guild) error {
+		return g.Filters(filters.toWaterlink())
+	})
+}
+
+// --- Internal stuff ---
+
+func (t *nodeV3) withGuild(guildID string, f func(g waterlink.Guild) error) error {
+	sf, err := snowflake.Parse(guildID)
+	if err != nil {
+		return err
+	}
+
+	t.mtx.RLock()
+	conn := t.conn
+	t.mtx.RUnlock()
+
+	if conn == nil {
+		return ErrNoNodeAvailable
+	}
+
+	return f(conn.Guild(sf))
+}
+
+func (t *nodeV3) handleEvent(e any) {
+	switch et := e.(type) {
+	case event.Stats:
+		t.mtx.Lock()
+		t.stats = &Stats{
+			Players:        int(et.Players),
+			PlayingPlayers: int(et.PlayingPlayers),
+			SystemLoad:     et.CPU.SystemLoad,
+			LavalinkLoad:   et.CPU.LavalinkLoad,
+			FrameDeficit:   et.Frame.Deficit,
+		}
+		t.mtx.Unlock()
+
+	case event.PlayerUpdate:
+		t.h.handleNodeEvent(t, PlayerUpdateEvent{
+			GuildID:   et.GuildID.String(),
+			Position:  time.Duration(et.State.Position) * time.Millisecond,
+			Connected: et.State.Connected,
+		})
+	case event.TrackStart:
+		t.h.handleNodeEvent(t, TrackStartEvent{
+			GuildID: et.GuildID.String(),
+			TrackID: et.TrackID,
+		})
+	case event.TrackEnd:
+		t.h.handleNodeEvent(t, TrackEndEvent{
+			GuildID: et.GuildID.String(),
+			TrackID: et.TrackID,
+			Reason:  t.trackEnded(et.GuildID.String()),
+		})
+	case event.TrackException:
+		t.h.handleNodeEvent(t, TrackExceptionEvent{
+			GuildID: et.GuildID.String(),
+			TrackID: et.TrackID,
+			Error:   et.Error,
+		})
+	case event.TrackStuck:
+		t.h.handleNodeEvent(t, TrackStuckEvent{
+			GuildID:   et.GuildID.String(),
+			TrackID:   et.TrackID,
+			Threshold: time.Duration(et.ThresholdMS) * time.Millisecond,
+		})
+	case event.WebSocketClosed:
+		t.h.handleNodeEvent(t, WebSocketClosedEvent{
+			GuildID:  et.GuildID.String(),
+			Code:     int(et.Code),
+			Reason:   et.Reason,
+			ByRemote: et.Remote,
+		})
+	}
+}
+
+// trackStarted records that a track has been started in the
+// guild. When another track is still active, it is replaced.
+func (t *nodeV3) trackStarted(guildID string) {
+	t.tracksMtx.Lock()
+	defer t.tracksMtx.Unlock()
+
+	if t.active[guildID] {
+		t.replaced[guildID]++
+	}
+	t.active[guildID] = true
+}
+
+// trackEnded returns the end reason of the track which has
+// ended in the guild. It is "replaced" when another track
+// has been started before the end was reported.
+func (t *nodeV3) trackEnded(guildID string) string {
+	t.tracksMtx.Lock()
+	defer t.tracksMtx.Unlock()
+
+	if t.replaced[guildID] > 0 {
+		t.replaced[guildID]--
+		return "replaced"
+	}
+	t.active[guildID] = false
+	return ""
+}
+
+// handleErrors is called by waterlink on errors in the event
+// loop. waterlink closes the connection on every error, so
+// the node is considered disconnected afterwards.
+func (t *nodeV3) handleErrors(err error) {
+	t.mtx.Lock()
+	wasAvailable := t.available
+	t.available = false
+	t.mtx.Unlock()
+
+	if !wasAvailable {
+		return
+	}
+
+	if !strings.Contains(err.Error(), "waterlink: connection: websocket: close") {
+		logrus.WithError(err).WithField("node", t.name).Error("Lavalink error")
+	}
+
+	t.h.handleNodeDisconnect(t, err)
+}
+
+func trackInfoFromV3(info track.Info) TrackInfo {
+	return TrackInfo{
+		Identifier: string(info.Query),
+		Title:      info.Title,
+		Author:     info.Author,
+		URI:        info.URI,
+		SourceName: info.SourceName,
+		Length:     time.Duration(info.Length) * time.Millisecond,
+		Seekable:   info.Seekable,
+		Stream:     info.Stream,
+	}
+}
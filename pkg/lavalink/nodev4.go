@@ -0,0 +1,429 @@
+package lavalink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	v4ClientName    = "yuri69"
+	v4ResumeTimeout = 60 * time.Second
+	v4ReadyTimeout  = 10 * time.Second
+)
+
+// nodeV4 implements INode for the Lavalink v4 protocol,
+// where the websocket connection is only used to receive
+// events and players are controlled via the REST API of
+// the session.
+type nodeV4 struct {
+	h nodeHandler
+
+	name     string
+	address  string
+	password string
+	userID   string
+
+	client *http.Client
+
+	mtx       sync.RWMutex
+	conn      *websocket.Conn
+	sessionID string
+	resumed   bool
+	available bool
+	closing   bool
+	stats     *Stats
+}
+
+var _ INode = (*nodeV4)(nil)
+
+func newNodeV4(c NodeConfig, userID string, h nodeHandler) (*nodeV4, error) {
+	var t nodeV4
+
+	t.h = h
+	t.name = nodeName(c)
+	t.address = c.Address
+	t.password = c.Password
+	t.userID = userID
+	t.client = &http.Client{Timeout: 10 * time.Second}
+
+	return &t, nil
+}
+
+func (t *nodeV4) Name() string {
+	return t.name
+}
+
+// Connect opens the websocket connection to the node. When a
+// session has been established before, it is tried to resume
+// it so that the players of the session keep playing.
+func (t *nodeV4) Connect() error {
+	t.mtx.Lock()
+
+	if t.conn != nil && t.available {
+		t.mtx.Unlock()
+		return errors.New("connection already established")
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", t.password)
+	header.Set("User-Id", t.userID)
+	header.Set("Client-Name", v4ClientName)
+	if t.sessionID != "" {
+		header.Set("Session-Id", t.sessionID)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s/v4/websocket", t.address), header)
+	if err != nil {
+		t.mtx.Unlock()
+		return err
+	}
+
+	ready, err := t.awaitReady(conn)
+	if err != nil {
+		t.mtx.Unlock()
+		conn.Close()
+		return err
+	}
+
+	t.conn = conn
+	t.sessionID = ready.SessionID
+	t.resumed = ready.Resumed
+	t.available = true
+	t.closing = false
+	t.stats = nil
+	t.mtx.Unlock()
+
+	go t.listen(conn)
+
+	logrus.
+		WithField("node", t.name).
+		WithField("session", ready.SessionID).
+		WithField("resumed", ready.Resumed).
+		Debug("Lavalink session established")
+
+	return t.request(http.MethodPatch, t.sessionPath(), v4SessionUpdate{
+		Resuming: true,
+		Timeout:  int(v4ResumeTimeout.Seconds()),
+	}, nil)
+}
+
+func (t *nodeV4) Close() error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.available = false
+	t.closing = true
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
+
+func (t *nodeV4) Available() bool {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	return t.available
+}
+
+func (t *nodeV4) SessionResumed() bool {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	return t.resumed
+}
+
+func (t *nodeV4) Stats() *Stats {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	return t.stats
+}
+
+func (t *nodeV4) LoadTrack(ident string) (Track, error) {
+	var res v4LoadResult
+	err := t.request(http.MethodGet, "/v4/loadtracks?identifier="+url.QueryEscape(ident), nil, &res)
+	if err != nil {
+		return Track{}, err
+	}
+
+	logrus.
+		WithField("node", t.name).
+		WithField("type", res.LoadType).
+		Debug("Tracks loaded")
+
+	var tracks []v4Track
+	switch res.LoadType {
+	case "track":
+		var tr v4Track
+		err = json.Unmarshal(res.Data, &tr)
+		tracks = []v4Track{tr}
+	case "playlist":
+		var pl struct {
+			Tracks []v4Track `json:"tracks"`
+		}
+		err = json.Unmarshal(res.Data, &pl)
+		tracks = pl.Tracks
+	case "search":
+		err = json.Unmarshal(res.Data, &tracks)
+	case "error":
+		var ex v4Exception
+		if err = json.Unmarshal(res.Data, &ex); err == nil {
+			err = fmt.Errorf("loading track failed: %s", ex.Message)
+		}
+	}
+	if err != nil {
+		return Track{}, err
+	}
+
+	if len(tracks) == 0 {
+		return Track{}, errors.New("no tracks have been loaded")
+	}
+
+	return tracks[0].toTrack(), nil
+}
+
+func (t *nodeV4) DecodeTrack(trackID string) (*TrackInfo, error) {
+	var tr v4Track
+	err := t.request(http.MethodGet, "/v4/decodetrack?encodedTrack="+url.QueryEscape(trackID), nil, &tr)
+	if err != nil {
+		return nil, err
+	}
+
+	info := tr.Info.toTrackInfo()
+	return &info, nil
+}
+
+func (t *nodeV4) UpdateVoice(guildID string, voice VoiceServer) error {
+	return t.updatePlayer(guildID, v4PlayerUpdate{
+		Voice: &v4VoiceState{
+			Token:     voice.Token,
+			Endpoint:  voice.Endpoint,
+			SessionID: voice.SessionID,
+		},
+	})
+}
+
+func (t *nodeV4) Play(guildID string, tr Track, position time.Duration, paused bool) error {
+	pos := position.Milliseconds()
+	return t.updatePlayer(guildID, v4PlayerUpdate{
+		Track:    &v4UpdateTrack{Encoded: &tr.ID},
+		Position: &pos,
+		Paused:   &paused,
+	})
+}
+
+func (t *nodeV4) Stop(guildID string) error {
+	return t.updatePlayer(guildID, v4PlayerUpdate{
+		Track: &v4UpdateTrack{Encoded: nil},
+	})
+}
+
+func (t *nodeV4) Destroy(guildID string) error {
+	return t.request(http.MethodDelete, t.playerPath(guildID), nil, nil)
+}
+
+func (t *nodeV4) SetPaused(guildID string, paused bool) error {
+	return t.updatePlayer(guildID, v4PlayerUpdate{
+		Paused: &paused,
+	})
+}
+
+func (t *nodeV4) Seek(guildID string, position time.Duration) error {
+	pos := position.Milliseconds()
+	return t.updatePlayer(guildID, v4PlayerUpdate{
+		Position: &pos,
+	})
+}
+
+func (t *nodeV4) SetVolume(guildID string, volume uint16) error {
+	vol := int(volume)
+	return t.updatePlayer(guildID, v4PlayerUpdate{
+		Volume: &vol,
+	})
+}
+
+func (t *nodeV4) SetFilters(guildID string, filters Filters) error {
+	return t.updatePlayer(guildID, v4PlayerUpdate{
+		Filters: &filters,
+	})
+}
+
+// --- Internal stuff ---
+
+func (t *nodeV4) sessionPath() string {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	return fmt.Sprintf("/v4/sessions/%s", t.sessionID)
+}
+
+func (t *nodeV4) playerPath(guildID string) string {
+	return fmt.Sprintf("%s/players/%s", t.sessionPath(), guildID)
+}
+
+func (t *nodeV4) updatePlayer(guildID string, update v4PlayerUpdate) error {
+	if !t.Available() {
+		return ErrNoNodeAvailable
+	}
+	return t.request(http.MethodPatch, t.playerPath(guildID)+"?noReplace=false", update, nil)
+}
+
+func (t *nodeV4) request(method, path string, body, res any) error {
+	var bodyReader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", t.address, path), bodyReader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", t.password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var errRes v4Error
+		if err = json.NewDecoder(resp.Body).Decode(&errRes); err != nil || errRes.Message == "" {
+			return fmt.Errorf("lavalink: %s %s: %s", method, path, resp.Status)
+		}
+		return fmt.Errorf("lavalink: %s %s: %s", method, path, errRes.Message)
+	}
+
+	if res == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+func (t *nodeV4) awaitReady(conn *websocket.Conn) (v4Message, error) {
+	conn.SetReadDeadline(time.Now().Add(v4ReadyTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	var msg v4Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		return v4Message{}, err
+	}
+
+	if msg.Op != "ready" {
+		return v4Message{}, fmt.Errorf("expected ready message but got %s", msg.Op)
+	}
+
+	return msg, nil
+}
+
+func (t *nodeV4) listen(conn *websocket.Conn) {
+	for {
+		var msg v4Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.handleReadError(conn, err)
+			return
+		}
+		t.handleMessage(msg)
+	}
+}
+
+func (t *nodeV4) handleReadError(conn *websocket.Conn, err error) {
+	t.mtx.Lock()
+	if t.conn != conn || t.closing {
+		t.mtx.Unlock()
+		return
+	}
+	t.available = false
+	t.mtx.Unlock()
+
+	conn.Close()
+	t.h.handleNodeDisconnect(t, err)
+}
+
+func (t *nodeV4) handleMessage(msg v4Message) {
+	switch msg.Op {
+	case "stats":
+		stats := Stats{
+			Players:        msg.Players,
+			PlayingPlayers: msg.PlayingPlayers,
+			SystemLoad:     msg.CPU.SystemLoad,
+			LavalinkLoad:   msg.CPU.LavalinkLoad,
+		}
+		if msg.FrameStats != nil {
+			stats.FrameDeficit = msg.FrameStats.Deficit
+		}
+		t.mtx.Lock()
+		t.stats = &stats
+		t.mtx.Unlock()
+
+	case "playerUpdate":
+		t.h.handleNodeEvent(t, PlayerUpdateEvent{
+			GuildID:   msg.GuildID,
+			Position:  time.Duration(msg.State.Position) * time.Millisecond,
+			Connected: msg.State.Connected,
+		})
+
+	case "event":
+		var trackID string
+		if msg.Track != nil {
+			trackID = msg.Track.Encoded
+		}
+
+		switch msg.Type {
+		case "TrackStartEvent":
+			t.h.handleNodeEvent(t, TrackStartEvent{
+				GuildID: msg.GuildID,
+				TrackID: trackID,
+			})
+		case "TrackEndEvent":
+			t.h.handleNodeEvent(t, TrackEndEvent{
+				GuildID: msg.GuildID,
+				TrackID: trackID,
+				Reason:  msg.Reason,
+			})
+		case "TrackExceptionEvent":
+			var errMsg string
+			if msg.Exception != nil {
+				errMsg = msg.Exception.Message
+			}
+			t.h.handleNodeEvent(t, TrackExceptionEvent{
+				GuildID: msg.GuildID,
+				TrackID: trackID,
+				Error:   errMsg,
+			})
+		case "TrackStuckEvent":
+			t.h.handleNodeEvent(t, TrackStuckEvent{
+				GuildID:   msg.GuildID,
+				TrackID:   trackID,
+				Threshold: time.Duration(msg.ThresholdMs) * time.Millisecond,
+			})
+		case "WebSocketClosedEvent":
+			t.h.handleNodeEvent(t, WebSocketClosedEvent{
+				GuildID:  msg.GuildID,
+				Code:     msg.Code,
+				Reason:   msg.Reason,
+				ByRemote: msg.ByRemote,
+			})
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package lavalink
+
+import (
+	"sync"
+	"time"
+)
+
+// guildPlayer holds the state of a guild player which is
+// required to restore the player on another node.
+type guildPlayer struct {
+	mtx sync.Mutex
+
+	node INode
+
+	voice   *VoiceServer
+	volume  uint16
+	filters Filters
+	paused  bool
+
+	track          *Track
+	position       time.Duration
+	positionUpdate time.Time
+}
+
+func (t *guildPlayer) setTrack(tr *Track) {
+	t.track = tr
+	t.setPosition(0)
+}
+
+func (t *guildPlayer) setPosition(position time.Duration) {
+	t.position = position
+	t.positionUpdate = time.Now()
+}
+
+func (t *guildPlayer) currentPosition() time.Duration {
+	if t.paused {
+		return t.position
+	}
+	return t.position + time.Since(t.positionUpdate)
+}
@@ -0,0 +1,22 @@
+package lavalink
+
+import "time"
+
+// Track is a track loaded by a Lavalink node. The ID is
+// the encoded representation of the track which is used
+// to play it.
+type Track struct {
+	ID   string
+	Info TrackInfo
+}
+
+type TrackInfo struct {
+	Identifier string
+	Title      string
+	Author     string
+	URI        string
+	SourceName string
+	Length     time.Duration
+	Seekable   bool
+	Stream     bool
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekroTJA/ratelimit"
+	"github.com/zekrotja/yuri69/pkg/errs"
 	"github.com/zekrotja/yuri69/pkg/util"
 )
 
@@ -17,8 +18,9 @@ var (
 type SortOrder string
 
 const (
-	SortOrderName    = SortOrder("name")
-	SortOrderCreated = SortOrder("created")
+	SortOrderName     = SortOrder("name")
+	SortOrderCreated  = SortOrder("created")
+	SortOrderDuration = SortOrder("duration")
 )
 
 type StatusModel struct {
@@ -39,11 +41,51 @@ type AuthLoginResponse struct {
 type CreateSoundRequest struct {
 	Sound
 
-	UploadId string     `json:"upload_id"`
-	YouTube  *YouTubeDL `json:"youtube"`
+	UploadId string       `json:"upload_id"`
+	Source   *SoundSource `json:"source"`
+	TTS      *TTS         `json:"tts"`
+	// Deprecated: use Source instead, which uses the YouTube
+	// provider for YouTube URLs.
+	YouTube *YouTubeDL `json:"youtube"`
 
 	Normalize bool `json:"normalize"`
 	Overdrive bool `json:"overdrive"`
+	// Presets are the names of the processing presets
+	// which are applied in order.
+	Presets []string `json:"presets"`
+}
+
+// PresetNames returns the processing presets of the request
+// including the overdrive preset when requested.
+func (t CreateSoundRequest) PresetNames() []string {
+	if t.Overdrive && !util.Contains(t.Presets, "overdrive") {
+		return append(t.Presets, "overdrive")
+	}
+	return t.Presets
+}
+
+// SoundSource is a remote source, like a YouTube video or
+// an audio file on the web, from which a sound is created.
+type SoundSource struct {
+	// Provider is the name of the source provider. When
+	// empty, the first provider supporting the URL is used.
+	Provider         string  `json:"provider"`
+	URL              string  `json:"url"`
+	StartTimeSeconds float64 `json:"start_time_seconds"`
+	EndTimeSeconds   float64 `json:"end_time_seconds"`
+}
+
+func (t SoundSource) Check() error {
+	if t.URL == "" {
+		return errs.WrapUserError("source URL is empty")
+	}
+	if t.StartTimeSeconds < 0 || t.EndTimeSeconds < 0 {
+		return errs.WrapUserError("start and end time must not be negative")
+	}
+	if t.EndTimeSeconds > 0 && t.StartTimeSeconds > t.EndTimeSeconds {
+		return errs.WrapUserError("'end_time_seconds' must be larger than 'start_time_seconds'")
+	}
+	return nil
 }
 
 type YouTubeDL struct {
@@ -52,19 +94,117 @@ type YouTubeDL struct {
 	EndTimeSeconds   float64 `json:"end_time_seconds"`
 }
 
+type TTS struct {
+	Text  string `json:"text"`
+	Voice string `json:"voice"`
+}
+
+type SayRequest struct {
+	TTS
+
+	Normalize bool     `json:"normalize"`
+	Effects   []string `json:"effects"`
+}
+
 type UpdateSoundRequest struct {
 	Sound
 }
 
+// EditSoundRequest re-renders a sound from its original
+// source with the given trim range, presets and
+// normalization.
+type EditSoundRequest struct {
+	StartTimeSeconds float64  `json:"start_time_seconds"`
+	EndTimeSeconds   float64  `json:"end_time_seconds"`
+	Normalize        bool     `json:"normalize"`
+	Presets          []string `json:"presets"`
+}
+
+func (t EditSoundRequest) Check() error {
+	if t.StartTimeSeconds < 0 || t.EndTimeSeconds < 0 {
+		return errs.WrapUserError("'start_time_seconds' and 'end_time_seconds' must not be negative")
+	}
+	if t.EndTimeSeconds > 0 && t.StartTimeSeconds >= t.EndTimeSeconds {
+		return errs.WrapUserError("'end_time_seconds' must be larger than 'start_time_seconds'")
+	}
+	return nil
+}
+
 type SoundUploadResponse struct {
 	UploadId string    `json:"upload_id"`
 	Deadline time.Time `json:"deadline"`
 }
 
+type ChunkedUploadRequest struct {
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+func (t ChunkedUploadRequest) Check() error {
+	if t.Size <= 0 {
+		return errs.WrapUserError("'size' must be larger than 0")
+	}
+	if !checksumRx.MatchString(t.Checksum) {
+		return errs.WrapUserError("'checksum' must be a hex encoded SHA-256 hash")
+	}
+	return nil
+}
+
+type ChunkedUploadResponse struct {
+	UploadId  string    `json:"upload_id"`
+	Offset    int64     `json:"offset"`
+	Size      int64     `json:"size"`
+	Completed bool      `json:"completed"`
+	Deadline  time.Time `json:"deadline"`
+}
+
 type SetVolumeRequest struct {
 	Volume int `json:"volume"`
 }
 
+type QueueEntry struct {
+	Id      string    `json:"id"`
+	Ident   string    `json:"ident"`
+	UserID  string    `json:"user_id"`
+	Added   time.Time `json:"added"`
+	Effects []string  `json:"effects,omitempty"`
+	Delay   int       `json:"delay,omitempty"`
+	Volume  int       `json:"volume,omitempty"`
+	Macro   string    `json:"macro,omitempty"`
+}
+
+type NowPlaying struct {
+	Ident    string    `json:"ident"`
+	UserID   string    `json:"user_id,omitempty"`
+	Started  time.Time `json:"started"`
+	Position int64     `json:"position"`
+	Length   int64     `json:"length"`
+	Paused   bool      `json:"paused"`
+	Effects  []string  `json:"effects,omitempty"`
+}
+
+type PlayerState struct {
+	Joined     bool        `json:"joined"`
+	NowPlaying *NowPlaying `json:"now_playing"`
+}
+
+type PlayRequest struct {
+	Effects []string `json:"effects"`
+}
+
+type SeekRequest struct {
+	Position int64 `json:"position"`
+}
+
+type QueueModeRequest struct {
+	Mode QueueMode `json:"mode"`
+}
+
+type QueueMoveRequest struct {
+	Id       string `json:"id"`
+	Position int    `json:"position"`
+}
+
 type FastTrigger struct {
 	FastTrigger string `json:"fast_trigger"`
 }
@@ -119,12 +259,19 @@ func UserSlimFromUser(u discordgo.User) UserSlim {
 
 type SoundImportError struct {
 	Uid   string `json:"uid"`
+	File  string `json:"file,omitempty"`
 	Error string `json:"error"`
 }
 
 type ImportResult struct {
+	// DryRun is true when the archive has only been checked
+	// and no sounds have been imported.
+	DryRun     bool               `json:"dry_run"`
 	Successful []string           `json:"successful"`
 	Failed     []SoundImportError `json:"failed"`
+	// Sounds contains the metadata of the imported sounds
+	// including the uids and tags inferred from file paths.
+	Sounds []Sound `json:"sounds"`
 }
 
 type TwitchState struct {
@@ -165,3 +312,41 @@ func GuildFromGuild(g *discordgo.Guild) Guild {
 		IconURL: g.IconURL(""),
 	}
 }
+
+type JobType string
+
+const (
+	JobTypeCreateSound  = JobType("createsound")
+	JobTypeEditSound    = JobType("editsound")
+	JobTypeImportSounds = JobType("importsounds")
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   = JobStatus("pending")
+	JobStatusRunning   = JobStatus("running")
+	JobStatusSucceeded = JobStatus("succeeded")
+	JobStatusFailed    = JobStatus("failed")
+	JobStatusCanceled  = JobStatus("canceled")
+)
+
+// Job is a long running task like transcoding a sound which
+// is executed in the background.
+type Job struct {
+	Id       string     `json:"id"`
+	Type     JobType    `json:"type"`
+	UserID   string     `json:"user_id"`
+	Status   JobStatus  `json:"status"`
+	Progress float64    `json:"progress"`
+	Error    string     `json:"error,omitempty"`
+	Result   any        `json:"result,omitempty"`
+	Created  time.Time  `json:"created"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+func (t Job) IsFinished() bool {
+	return t.Status == JobStatusSucceeded ||
+		t.Status == JobStatusFailed ||
+		t.Status == JobStatusCanceled
+}
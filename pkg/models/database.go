@@ -1,24 +1,49 @@
 package models
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/zekrotja/yuri69/pkg/cron"
 	"github.com/zekrotja/yuri69/pkg/errs"
 	"github.com/zekrotja/yuri69/pkg/util"
 )
 
 var (
-	uidRx = regexp.MustCompile(`^[a-z0-9_.-]{1,30}$`)
+	uidRx      = regexp.MustCompile(`^[a-z0-9_.-]{1,30}$`)
+	checksumRx = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
 )
 
 type Sound struct {
-	Uid         string    `json:"uid"`
-	DisplayName string    `json:"display_name"`
-	Created     time.Time `json:"created_date"`
-	Creator     UserSlim  `json:"creator"`
-	Tags        []string  `json:"tags"`
+	Uid         string     `json:"uid"`
+	DisplayName string     `json:"display_name"`
+	Created     time.Time  `json:"created_date"`
+	Creator     UserSlim   `json:"creator"`
+	Tags        []string   `json:"tags"`
+	Audio       *AudioInfo `json:"audio,omitempty"`
+}
+
+// AudioInfo holds the properties of the audio of a sound.
+type AudioInfo struct {
+	// Duration is the duration in seconds.
+	Duration   float64 `json:"duration"`
+	SampleRate int     `json:"sample_rate"`
+	// Loudness is the integrated loudness in LUFS.
+	Loudness float64 `json:"loudness"`
+	// Peaks is the downsampled waveform of the sound, where
+	// each peak is in the range of [0, 100].
+	Peaks []int `json:"peaks"`
+}
+
+// Duration returns the duration of the sound in seconds or
+// 0 when the sound has not been analyzed.
+func (t Sound) Duration() float64 {
+	if t.Audio == nil {
+		return 0
+	}
+	return t.Audio.Duration
 }
 
 func (t Sound) String() string {
@@ -48,6 +73,98 @@ func (t *Sound) Sanitize() {
 	util.ApplyToAll(t.Tags, strings.ToLower)
 }
 
+type SoundChange string
+
+const (
+	SoundChangeEdit     = SoundChange("edit")
+	SoundChangeRollback = SoundChange("rollback")
+)
+
+// SoundVersion is a previous state of a sound, which has been
+// replaced by the change made by Creator at Created.
+type SoundVersion struct {
+	Id      string      `json:"id"`
+	Sound   Sound       `json:"sound"`
+	Created time.Time   `json:"created_date"`
+	Creator UserSlim    `json:"creator"`
+	Change  SoundChange `json:"change"`
+}
+
+const (
+	MacroMaxSteps  = 20
+	MacroMaxDelay  = 60_000
+	MacroMaxVolume = 1000
+)
+
+// MacroStep is a single sound played by a macro. Delay is the
+// time in milliseconds which is waited after the previous step
+// has ended. Volume overrides the guild volume for the step
+// unless it is 0.
+type MacroStep struct {
+	Ident  string `json:"ident"`
+	Delay  int    `json:"delay"`
+	Volume int    `json:"volume"`
+}
+
+// Macro is an ordered sequence of sounds which can be played
+// with its uid anywhere a sound ident is accepted.
+type Macro struct {
+	Uid         string      `json:"uid"`
+	DisplayName string      `json:"display_name"`
+	Created     time.Time   `json:"created_date"`
+	Creator     UserSlim    `json:"creator"`
+	Steps       []MacroStep `json:"steps"`
+}
+
+func (t Macro) String() string {
+	if t.DisplayName != "" {
+		return t.DisplayName
+	}
+	return t.Uid
+}
+
+func (t Macro) Check() error {
+	if t.Uid == "" {
+		return errs.WrapUserError("uid must be specified")
+	}
+
+	if !uidRx.MatchString(t.Uid) {
+		return errs.WrapUserError("malformed uid")
+	}
+
+	if len(t.Steps) == 0 {
+		return errs.WrapUserError("macro must have at least one step")
+	}
+
+	if len(t.Steps) > MacroMaxSteps {
+		return errs.WrapUserError(
+			fmt.Sprintf("macro must not have more than %d steps", MacroMaxSteps))
+	}
+
+	for _, step := range t.Steps {
+		if step.Ident == "" {
+			return errs.WrapUserError("step ident must be specified")
+		}
+		if step.Delay < 0 || step.Delay > MacroMaxDelay {
+			return errs.WrapUserError(
+				fmt.Sprintf("step delay must be between 0 and %d milliseconds", MacroMaxDelay))
+		}
+		if step.Volume < 0 || step.Volume > MacroMaxVolume {
+			return errs.WrapUserError(
+				fmt.Sprintf("step volume must be between 0 and %d", MacroMaxVolume))
+		}
+	}
+
+	return nil
+}
+
+func (t *Macro) Sanitize() {
+	t.Uid = strings.ToLower(t.Uid)
+	for i := range t.Steps {
+		t.Steps[i].Ident = strings.ToLower(t.Steps[i].Ident)
+	}
+}
+
 type GuildFilters struct {
 	Include []string `json:"include"`
 	Exclude []string `json:"exclude"`
@@ -68,12 +185,237 @@ func (t *GuildFilters) Sanitize() {
 	util.ApplyToAll(t.Exclude, strings.ToLower)
 }
 
+const RandomMaxWeight = 100
+
+// RandomWeights controls which sounds are favoured when
+// playing a random sound. Each weight is a bonus which
+// multiplies the chance of a sound by 1 + weight. When all
+// weights are 0, every sound is equally likely.
+type RandomWeights struct {
+	// Favorites favours the favorites of the user who
+	// plays the random sound.
+	Favorites float64 `json:"favorites"`
+	// RarelyPlayed favours sounds which have been played
+	// less often in the guild.
+	RarelyPlayed float64 `json:"rarely_played"`
+	// Tags favours sounds which have the given tags.
+	Tags map[string]float64 `json:"tags"`
+}
+
+func (t RandomWeights) Check() error {
+	if t.Favorites < 0 || t.Favorites > RandomMaxWeight {
+		return errs.WrapUserError(
+			fmt.Sprintf("favorites must be in range [0, %d]", RandomMaxWeight))
+	}
+	if t.RarelyPlayed < 0 || t.RarelyPlayed > RandomMaxWeight {
+		return errs.WrapUserError(
+			fmt.Sprintf("rarely_played must be in range [0, %d]", RandomMaxWeight))
+	}
+	for tag, w := range t.Tags {
+		if w < 0 || w > RandomMaxWeight {
+			return errs.WrapUserError(
+				fmt.Sprintf("weight of tag '%s' must be in range [0, %d]", tag, RandomMaxWeight))
+		}
+	}
+	return nil
+}
+
+func (t *RandomWeights) Sanitize() {
+	tags := make(map[string]float64, len(t.Tags))
+	for tag, w := range t.Tags {
+		tags[strings.ToLower(tag)] = w
+	}
+	t.Tags = tags
+}
+
+// IsWeighted returns true when any weight is set.
+func (t RandomWeights) IsWeighted() bool {
+	if t.Favorites > 0 || t.RarelyPlayed > 0 {
+		return true
+	}
+	for _, w := range t.Tags {
+		if w > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Weight returns the relative chance of the sound to be
+// picked. plays is the number of times the sound has been
+// played and maxPlays is the maximum of that over all sounds.
+func (t RandomWeights) Weight(sound Sound, favorite bool, plays, maxPlays int) float64 {
+	weight := 1.0
+	if favorite {
+		weight *= 1 + t.Favorites
+	}
+	if maxPlays > 0 {
+		weight *= 1 + t.RarelyPlayed*(1-float64(plays)/float64(maxPlays))
+	}
+	for _, tag := range sound.Tags {
+		weight *= 1 + t.Tags[tag]
+	}
+	return weight
+}
+
+type QueueMode string
+
+const (
+	QueueModeInterrupt = QueueMode("interrupt")
+	QueueModeEnqueue   = QueueMode("enqueue")
+)
+
+func (t QueueMode) Check() error {
+	if t != QueueModeInterrupt && t != QueueModeEnqueue {
+		return errs.WrapUserError("invalid queue mode")
+	}
+	return nil
+}
+
+// GuildAutoLeave overrides the auto leave timeouts of the
+// player configuration for a guild. Timeouts are given in
+// seconds, where 0 falls back to the configured default and
+// a negative value disables the timeout.
+type GuildAutoLeave struct {
+	Timeout     int `json:"timeout"`
+	IdleTimeout int `json:"idle_timeout"`
+}
+
+func (t GuildAutoLeave) Check() error {
+	if t.Timeout < -1 || t.IdleTimeout < -1 {
+		return errs.WrapUserError("timeouts must be -1 (disabled), 0 (default) or a number of seconds")
+	}
+	return nil
+}
+
+// UserSounds holds the sounds which are played when a user
+// enters or leaves the voice channel of the player.
+type UserSounds struct {
+	Entrance string `json:"entrance"`
+	Exit     string `json:"exit"`
+}
+
+// Merge returns the sounds of t where the empty values
+// are replaced by the values of fallback.
+func (t UserSounds) Merge(fallback UserSounds) UserSounds {
+	if t.Entrance == "" {
+		t.Entrance = fallback.Entrance
+	}
+	if t.Exit == "" {
+		t.Exit = fallback.Exit
+	}
+	return t
+}
+
+// FastTriggers binds sounds or macros to the fast trigger
+// gestures of a user.
+type FastTriggers struct {
+	Mute   string `json:"mute"`
+	Deafen string `json:"deafen"`
+	Double string `json:"double"`
+}
+
+// Merge returns the fast triggers of t where the empty
+// values are replaced by the values of fallback.
+func (t FastTriggers) Merge(fallback FastTriggers) FastTriggers {
+	if t.Mute == "" {
+		t.Mute = fallback.Mute
+	}
+	if t.Deafen == "" {
+		t.Deafen = fallback.Deafen
+	}
+	if t.Double == "" {
+		t.Double = fallback.Double
+	}
+	return t
+}
+
+// Get returns the ident bound to the given gesture.
+func (t FastTriggers) Get(gesture string) string {
+	switch gesture {
+	case "mute":
+		return t.Mute
+	case "deafen":
+		return t.Deafen
+	case "double":
+		return t.Double
+	default:
+		return ""
+	}
+}
+
+// GuildUserSounds controls whether the entrance and exit
+// sounds of users are played in a guild and how long a
+// user has to wait until their next sound is played.
+type GuildUserSounds struct {
+	Enabled         bool `json:"enabled"`
+	CooldownSeconds int  `json:"cooldown_seconds"`
+}
+
+func (t GuildUserSounds) Check() error {
+	if t.CooldownSeconds < 0 {
+		return errs.WrapUserError("cooldown_seconds must not be negative")
+	}
+	return nil
+}
+
 type PlaybackLogEntry struct {
 	Id        string    `json:"id"`
 	Ident     string    `json:"ident"`
 	GuildID   string    `json:"guild_id"`
 	UserID    string    `json:"user_id"`
 	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source,omitempty"`
+}
+
+// Schedule plays a sound or macro in a voice channel either
+// once at the given time or recurring on a cron expression.
+type Schedule struct {
+	Id             string     `json:"id"`
+	GuildID        string     `json:"guild_id"`
+	ChannelID      string     `json:"channel_id"`
+	Ident          string     `json:"ident"`
+	Cron           string     `json:"cron,omitempty"`
+	At             *time.Time `json:"at,omitempty"`
+	Timezone       string     `json:"timezone,omitempty"`
+	OnlyIfOccupied bool       `json:"only_if_occupied"`
+	Enabled        bool       `json:"enabled"`
+	Created        time.Time  `json:"created_date"`
+	Creator        UserSlim   `json:"creator"`
+	LastRun        *time.Time `json:"last_run,omitempty"`
+	NextRun        *time.Time `json:"next_run,omitempty"`
+}
+
+func (t Schedule) Check() error {
+	if t.Ident == "" {
+		return errs.WrapUserError("ident must be specified")
+	}
+
+	if (t.Cron == "") == (t.At == nil) {
+		return errs.WrapUserError("either cron or at must be specified")
+	}
+
+	if t.Cron != "" {
+		if _, err := cron.Parse(t.Cron); err != nil {
+			return errs.WrapUserError(err)
+		}
+	}
+
+	if _, err := t.Location(); err != nil {
+		return errs.WrapUserError("invalid timezone")
+	}
+
+	return nil
+}
+
+// Location returns the location of the timezone in which
+// the cron expression of the schedule is evaluated. When
+// no timezone is set, the local timezone is used.
+func (t Schedule) Location() (*time.Location, error) {
+	if t.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(t.Timezone)
 }
 
 type TwitchSettings struct {
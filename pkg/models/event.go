@@ -7,11 +7,23 @@ import (
 )
 
 const (
-	EventSoundCreated       = "soundcreated"
-	EventSoundUpdated       = "soundupdated"
-	EventSoundDeleted       = "sounddeleted"
-	EventVolumeUpdated      = "volumeupdated"
-	EventGuildFilterUpdated = "guildfilterupdated"
+	EventSoundCreated              = "soundcreated"
+	EventSoundUpdated              = "soundupdated"
+	EventSoundDeleted              = "sounddeleted"
+	EventMacroCreated              = "macrocreated"
+	EventMacroUpdated              = "macroupdated"
+	EventMacroDeleted              = "macrodeleted"
+	EventVolumeUpdated             = "volumeupdated"
+	EventGuildFilterUpdated        = "guildfilterupdated"
+	EventGuildAutoLeaveUpdated     = "guildautoleaveupdated"
+	EventGuildUserSoundsUpdated    = "guildusersoundsupdated"
+	EventGuildRandomWeightsUpdated = "guildrandomweightsupdated"
+	EventQueueUpdated              = "queueupdated"
+	EventQueueModeUpdated          = "queuemodeupdated"
+	EventPlayerPaused              = "playerpaused"
+	EventPlayerResumed             = "playerresumed"
+	EventPlayerSeeked              = "playerseeked"
+	EventJobUpdated                = "jobupdated"
 
 	EventSenderController = "controller"
 	EventSenderPlayer     = "player"
@@ -43,9 +55,12 @@ type GuildInfo struct {
 }
 
 type EventVoiceJoinPayload struct {
-	Volume  int          `json:"volume,omitempty"`
-	Filters GuildFilters `json:"filters,omitempty"`
-	Guild   GuildInfo    `json:"guild,omitempty"`
+	Volume     int          `json:"volume,omitempty"`
+	Filters    GuildFilters `json:"filters,omitempty"`
+	Guild      GuildInfo    `json:"guild,omitempty"`
+	QueueMode  QueueMode    `json:"queue_mode,omitempty"`
+	Queue      []QueueEntry `json:"queue,omitempty"`
+	NowPlaying *NowPlaying  `json:"now_playing,omitempty"`
 }
 
 type EventStatePayload struct {
@@ -0,0 +1,110 @@
+// Package ogg implements a minimal reader for the packets
+// of an Ogg container as specified in RFC 3533.
+package ogg
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	pageHeaderSize = 27
+	capturePattern = "OggS"
+)
+
+var ErrInvalidPage = errors.New("invalid ogg page")
+
+// PacketReader reads the packets of the first logical
+// bitstream of an Ogg container. Pages of other logical
+// bitstreams are skipped.
+type PacketReader struct {
+	r io.Reader
+
+	serial    uint32
+	hasSerial bool
+
+	segments []byte
+	data     []byte
+	packet   []byte
+}
+
+// NewPacketReader returns a new PacketReader reading
+// from r.
+func NewPacketReader(r io.Reader) *PacketReader {
+	return &PacketReader{r: r}
+}
+
+// ReadPacket returns the next packet of the bitstream.
+// When no more packets are available, io.EOF is returned.
+func (t *PacketReader) ReadPacket() ([]byte, error) {
+	for {
+		for len(t.segments) > 0 {
+			n := int(t.segments[0])
+			t.segments = t.segments[1:]
+			t.packet = append(t.packet, t.data[:n]...)
+			t.data = t.data[n:]
+
+			// A lacing value of 255 indicates that the packet
+			// continues in the next segment.
+			if n < 255 {
+				packet := t.packet
+				t.packet = nil
+				return packet, nil
+			}
+		}
+
+		if err := t.readPage(); err != nil {
+			if err == io.EOF && len(t.packet) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+}
+
+func (t *PacketReader) readPage() error {
+	var header [pageHeaderSize]byte
+	if _, err := io.ReadFull(t.r, header[:]); err != nil {
+		return err
+	}
+
+	if string(header[:4]) != capturePattern {
+		return ErrInvalidPage
+	}
+
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(t.r, segments); err != nil {
+		return unexpectedEOF(err)
+	}
+
+	size := 0
+	for _, s := range segments {
+		size += int(s)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(t.r, data); err != nil {
+		return unexpectedEOF(err)
+	}
+
+	serial := binary.LittleEndian.Uint32(header[14:18])
+	if !t.hasSerial {
+		t.serial = serial
+		t.hasSerial = true
+	}
+	if serial != t.serial {
+		return nil
+	}
+
+	t.segments = segments
+	t.data = data
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package ogg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func page(serial uint32, segments []byte, data []byte) []byte {
+	header := make([]byte, pageHeaderSize)
+	copy(header, capturePattern)
+	binary.LittleEndian.PutUint32(header[14:18], serial)
+	header[26] = byte(len(segments))
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.Write(segments)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestReadPacket(t *testing.T) {
+	long := bytes.Repeat([]byte{'x'}, 300)
+
+	var buf bytes.Buffer
+	buf.Write(page(1, []byte{3, 2}, []byte("abcde")))
+	// Packet spanning two pages.
+	buf.Write(page(1, []byte{255}, long[:255]))
+	buf.Write(page(2, []byte{1}, []byte("z")))
+	buf.Write(page(1, []byte{45, 0}, long[255:]))
+
+	r := NewPacketReader(&buf)
+
+	p, err := r.ReadPacket()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("abc"), p)
+
+	p, err = r.ReadPacket()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("de"), p)
+
+	p, err = r.ReadPacket()
+	assert.Nil(t, err)
+	assert.Equal(t, long, p)
+
+	p, err = r.ReadPacket()
+	assert.Nil(t, err)
+	assert.Empty(t, p)
+
+	_, err = r.ReadPacket()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadPacketErrors(t *testing.T) {
+	r := NewPacketReader(bytes.NewReader([]byte("NotAnOggPageHeaderAtAll....")))
+	_, err := r.ReadPacket()
+	assert.ErrorIs(t, err, ErrInvalidPage)
+
+	data := page(1, []byte{255}, bytes.Repeat([]byte{'x'}, 255))
+	r = NewPacketReader(bytes.NewReader(data))
+	_, err = r.ReadPacket()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	r = NewPacketReader(bytes.NewReader(data[:30]))
+	_, err = r.ReadPacket()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
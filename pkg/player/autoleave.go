@@ -0,0 +1,212 @@
+package player
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/database/dberrors"
+)
+
+// autoLeaveTimers holds the timers of a guild which make
+// the player leave the voice channel either when no users
+// are left in the channel or when nothing has been played
+// for a while.
+type autoLeaveTimers struct {
+	mtx   sync.Mutex
+	empty *time.Timer
+	idle  *time.Timer
+}
+
+// start starts a timer in the given slot which executes f
+// after d has passed. When a timer is already running in
+// the slot, nothing happens and false is returned.
+func (t *autoLeaveTimers) start(slot **time.Timer, d time.Duration, f func()) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if *slot != nil {
+		return false
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		t.mtx.Lock()
+		current := *slot == timer
+		if current {
+			*slot = nil
+		}
+		t.mtx.Unlock()
+
+		if current {
+			f()
+		}
+	})
+	*slot = timer
+
+	return true
+}
+
+// cancel stops the timer in the given slot. When no timer
+// was running, false is returned.
+func (t *autoLeaveTimers) cancel(slot **time.Timer) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if *slot == nil {
+		return false
+	}
+
+	(*slot).Stop()
+	*slot = nil
+	return true
+}
+
+func (t *autoLeaveTimers) stop() {
+	t.cancel(&t.empty)
+	t.cancel(&t.idle)
+}
+
+// --- Internal stuff ---
+
+func (t *Player) getAutoLeaveTimers(guildID string) *autoLeaveTimers {
+	timers, _ := t.autoLeaveTimers.LoadOrStore(guildID, &autoLeaveTimers{})
+	return timers
+}
+
+// autoLeave starts the auto leave timer of the guild when
+// no users are left in the voice channel of the player.
+func (t *Player) autoLeave(e *discordgo.VoiceState) {
+	vc, ok := t.vcs.Load(e.GuildID)
+	if !ok || vc.ChannelID != e.ChannelID {
+		return
+	}
+	vConns, err := t.getChannelVoiceConnections(e.GuildID, e.ChannelID)
+	if err != nil || vConns != 0 {
+		return
+	}
+
+	timeout, _ := t.getAutoLeaveTimeouts(e.GuildID)
+	if timeout < 0 {
+		return
+	}
+
+	timers := t.getAutoLeaveTimers(e.GuildID)
+	started := timers.start(&timers.empty, timeout, func() {
+		t.leaveAfterTimeout(e.GuildID, "empty")
+	})
+	if started {
+		logrus.
+			WithField("guildID", e.GuildID).
+			WithField("timeout", timeout).
+			Debug("Trigger autoleave timer")
+	}
+}
+
+// cancelAutoLeave stops the auto leave timer of the guild
+// when users are present in the voice channel of the player.
+func (t *Player) cancelAutoLeave(e *discordgo.VoiceState) {
+	timers, ok := t.autoLeaveTimers.Load(e.GuildID)
+	if !ok {
+		return
+	}
+
+	vc, ok := t.vcs.Load(e.GuildID)
+	if !ok || vc.ChannelID != e.ChannelID {
+		return
+	}
+	vConns, err := t.getChannelVoiceConnections(e.GuildID, e.ChannelID)
+	if err != nil || vConns == 0 {
+		return
+	}
+
+	if timers.cancel(&timers.empty) {
+		logrus.WithField("guildID", e.GuildID).Debug("Clear autoleave timer")
+	}
+}
+
+// setIdle starts the idle timer of the guild when nothing
+// is played and stops it as soon as the playback starts.
+func (t *Player) setIdle(guildID string, idle bool) {
+	if !t.HasPlayer(guildID) {
+		return
+	}
+
+	timers := t.getAutoLeaveTimers(guildID)
+
+	if !idle {
+		if timers.cancel(&timers.idle) {
+			logrus.WithField("guildID", guildID).Debug("Clear idle timer")
+		}
+		return
+	}
+
+	_, idleTimeout := t.getAutoLeaveTimeouts(guildID)
+	if idleTimeout <= 0 {
+		return
+	}
+
+	started := timers.start(&timers.idle, idleTimeout, func() {
+		t.leaveAfterTimeout(guildID, "idle")
+	})
+	if started {
+		logrus.
+			WithField("guildID", guildID).
+			WithField("timeout", idleTimeout).
+			Debug("Trigger idle timer")
+	}
+}
+
+func (t *Player) stopAutoLeave(guildID string) {
+	if timers, ok := t.autoLeaveTimers.LoadAndDelete(guildID); ok {
+		timers.stop()
+	}
+}
+
+func (t *Player) leaveAfterTimeout(guildID, reason string) {
+	logrus.
+		WithField("guildID", guildID).
+		WithField("reason", reason).
+		Debug("Leaving voice channel after timeout")
+
+	err := t.backend.Leave(guildID)
+	if err != nil {
+		logrus.
+			WithError(err).
+			WithField("guildID", guildID).
+			Error("Leaving voice channel failed")
+	}
+}
+
+// getAutoLeaveTimeouts returns the auto leave and idle
+// timeouts of the guild. The configured timeouts are used
+// unless they are overridden for the guild. A negative
+// timeout means that it is disabled.
+func (t *Player) getAutoLeaveTimeouts(guildID string) (timeout, idleTimeout time.Duration) {
+	timeout, idleTimeout = t.autoLeaveTimeout, t.idleTimeout
+
+	s, err := t.db.GetGuildAutoLeave(guildID)
+	if err != nil {
+		if err != dberrors.ErrNotFound {
+			logrus.
+				WithError(err).
+				WithField("guildID", guildID).
+				Error("Getting guild auto leave settings failed")
+		}
+		return timeout, idleTimeout
+	}
+
+	return overrideTimeout(timeout, s.Timeout), overrideTimeout(idleTimeout, s.IdleTimeout)
+}
+
+func overrideTimeout(def time.Duration, seconds int) time.Duration {
+	switch {
+	case seconds < 0:
+		return -1
+	case seconds == 0:
+		return def
+	default:
+		return time.Duration(seconds) * time.Second
+	}
+}
@@ -0,0 +1,47 @@
+package player
+
+import (
+	"time"
+
+	"github.com/zekrotja/yuri69/pkg/lavalink"
+)
+
+const (
+	BackendLavalink = "lavalink"
+	BackendNative   = "native"
+)
+
+// Backend connects to the voice channels of guilds and
+// plays audio in them.
+//
+// Events of the playback are passed to the event handler
+// of the backend as lavalink event types. The handler locks
+// the state of the guild, which is held while the player
+// calls the backend, so the backend must not wait for the
+// handler when it is called.
+type Backend interface {
+	// Join connects to the given voice channel.
+	Join(guildID, channelID string) error
+	// Leave disconnects from the voice channel of the guild.
+	Leave(guildID string) error
+
+	// Play plays the stored sound with the given ident or,
+	// when url is not empty, the audio of the given URL.
+	Play(guildID, ident, url string) (lavalink.Track, error)
+	Stop(guildID string) error
+	Destroy(guildID string) error
+	SetPaused(guildID string, paused bool) error
+	Seek(guildID string, position time.Duration) error
+	SetFilters(guildID string, filters lavalink.Filters) error
+	SetVolume(guildID string, volume uint16) error
+
+	// RestorePlayer restores the state of the guild player
+	// after the connection to the backend has been lost.
+	RestorePlayer(guildID string) error
+
+	// TrackIdent returns the sound ident or URL of a played
+	// track. An empty string is returned if it is unknown.
+	TrackIdent(trackID string) string
+
+	Close() error
+}
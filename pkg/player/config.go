@@ -7,8 +7,40 @@ import (
 )
 
 type PlayerConfig struct {
-	Hostname        string
+	// Backend is either "lavalink" or "native".
+	Backend  string
+	Hostname string
+
+	// FastTriggerTime is the maximum time between muting
+	// (or deafening) and unmuting oneself to be recognised
+	// as a fast trigger gesture.
 	FastTriggerTime time.Duration
+	// DoubleTriggerTime is the time within which a second
+	// mute toggle has to follow the first one to be
+	// recognised as a double toggle. 0 disables double
+	// toggles.
+	DoubleTriggerTime time.Duration
+
+	// AutoLeaveTimeout is the time after which the player
+	// leaves a voice channel where no users are left.
+	AutoLeaveTimeout time.Duration
+	// IdleTimeout is the time after which the player leaves
+	// the voice channel when nothing has been played, even
+	// when users are still present. 0 disables it.
+	IdleTimeout time.Duration
+	Effects     map[string]lavalink.Filters
+
+	FileServer FileServerConfig
+	Lavalink   lavalink.LavalinkConfig
+}
 
-	Lavalink lavalink.LavalinkConfig
+type FileServerConfig struct {
+	// BindAddress is the address the file server, which
+	// serves the sounds to Lavalink, listens on.
+	BindAddress string
+	// SigningKey is used to sign the URLs of the served
+	// sounds. A random key is generated when empty.
+	SigningKey string
+	// URLLifetime is the duration a signed URL is valid.
+	URLLifetime time.Duration
 }
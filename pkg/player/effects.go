@@ -0,0 +1,74 @@
+package player
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/zekrotja/yuri69/pkg/lavalink"
+)
+
+// Effects returns the names of all configured effect presets.
+func (t *Player) Effects() []string {
+	names := make([]string, 0, len(t.effects))
+	for name := range t.effects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckEffects returns ErrUnknownEffect when one of the
+// given names is not a configured effect preset.
+func (t *Player) CheckEffects(names []string) error {
+	_, err := t.resolveEffects(names)
+	return err
+}
+
+// --- Internal stuff ---
+
+func (t *Player) setEffects(effects map[string]lavalink.Filters) {
+	t.effects = make(map[string]lavalink.Filters, len(effects))
+	for name, filters := range effects {
+		t.effects[strings.ToLower(name)] = filters
+	}
+}
+
+// resolveEffects merges the filters of the given effect
+// presets in the given order into one set of filters.
+func (t *Player) resolveEffects(names []string) (lavalink.Filters, error) {
+	var filters lavalink.Filters
+	for _, name := range names {
+		effect, ok := t.effects[strings.ToLower(name)]
+		if !ok {
+			return lavalink.Filters{}, ErrUnknownEffect
+		}
+		filters = filters.Merge(effect)
+	}
+	return filters, nil
+}
+
+// applyEffects sets the filters of the given effect presets
+// on the guild player. Filters of previously played sounds
+// are reset when no effects are given.
+func (t *Player) applyEffects(guildID string, names []string) error {
+	filters, err := t.resolveEffects(names)
+	if err != nil {
+		return err
+	}
+
+	s := t.getState(guildID)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if filters.IsEmpty() && !s.filtered {
+		return nil
+	}
+
+	if err = t.backend.SetFilters(guildID, filters); err != nil {
+		return err
+	}
+
+	s.filtered = !filters.IsEmpty()
+	return nil
+}
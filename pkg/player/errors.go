@@ -3,5 +3,13 @@ package player
 import "errors"
 
 var (
-	ErrNoGuildPlayer = errors.New("no player for this guild")
+	ErrNoGuildPlayer      = errors.New("no player for this guild")
+	ErrQueueEntryNotFound = errors.New("queue entry not found")
+	ErrNotPlaying         = errors.New("nothing is currently playing")
+	ErrNotSeekable        = errors.New("current track is not seekable")
+	ErrSeekOutOfRange     = errors.New("seek position is out of range")
+	ErrUnknownEffect      = errors.New("unknown effect")
+	ErrUnsupportedSource  = errors.New("source is not supported by the player backend")
+	ErrUnsupportedFilters = errors.New("filters are not supported by the player backend")
+	ErrNotOpus            = errors.New("sound is not opus encoded")
 )
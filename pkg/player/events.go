@@ -14,15 +14,24 @@ const (
 	EventVoiceInit   = EventType("voiceinit")
 	EventVoiceDeinit = EventType("voicedeinit")
 
+	EventUserEnter = EventType("userenter")
+	EventUserExit  = EventType("userexit")
+
 	EventFastTrigger = EventType("fasttrigger")
 
+	EventQueueUpdated = EventType("queueupdated")
+
+	EventPlayerRecovered = EventType("playerrecovered")
+
 	EventError = EventType("error")
 )
 
 type Event struct {
-	Type    EventType `json:"type"`
-	Ident   string    `json:"ident,omitempty"`
-	GuildID string    `json:"guild_id,omitempty"`
-	UserID  string    `json:"user_id,omitempty"`
-	Err     error     `json:"error,omitempty"`
+	Type      EventType `json:"type"`
+	Ident     string    `json:"ident,omitempty"`
+	GuildID   string    `json:"guild_id,omitempty"`
+	ChannelID string    `json:"channel_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	Gesture   Gesture   `json:"gesture,omitempty"`
+	Err       error     `json:"error,omitempty"`
 }
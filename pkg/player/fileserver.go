@@ -0,0 +1,169 @@
+package player
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/sirupsen/logrus"
+	routing "github.com/zekrotja/ozzo-routing/v2"
+	"github.com/zekrotja/yuri69/pkg/cryptoutil"
+	"github.com/zekrotja/yuri69/pkg/static"
+	"github.com/zekrotja/yuri69/pkg/storage"
+)
+
+// fileServer serves the stored sounds to Lavalink. Sounds
+// are only served with a valid signature, which is part of
+// the URLs created by SoundURL and expires after the
+// configured URL lifetime.
+type fileServer struct {
+	st storage.IStorage
+
+	bindAddress string
+	baseURL     string
+	key         []byte
+	urlLifetime time.Duration
+
+	router *routing.Router
+	server *http.Server
+}
+
+func newFileServer(c PlayerConfig, st storage.IStorage) (*fileServer, error) {
+	var (
+		t   fileServer
+		err error
+	)
+
+	t.st = st
+	t.bindAddress = c.FileServer.BindAddress
+	t.urlLifetime = c.FileServer.URLLifetime
+
+	if t.urlLifetime <= 0 {
+		return nil, fmt.Errorf("file server URL lifetime must be larger than 0")
+	}
+
+	hostname := c.Hostname
+	if hostname == "" {
+		hostname, err = os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, port, err := net.SplitHostPort(t.bindAddress)
+	if err != nil {
+		return nil, err
+	}
+	t.baseURL = fmt.Sprintf("http://%s", net.JoinHostPort(hostname, port))
+
+	if c.FileServer.SigningKey != "" {
+		t.key = []byte(c.FileServer.SigningKey)
+	} else {
+		t.key, err = cryptoutil.GetRandByteArray(32)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t.router = routing.New()
+	t.router.Get("/file/<id>", t.handleGetFile)
+
+	return &t, nil
+}
+
+func (t *fileServer) ListenAndServeBlocking() error {
+	t.server = &http.Server{
+		Addr:    t.bindAddress,
+		Handler: t.router,
+	}
+	return t.server.ListenAndServe()
+}
+
+// SoundURL returns a signed URL to the stored sound with
+// the given ident.
+func (t *fileServer) SoundURL(ident string) string {
+	expires := strconv.FormatInt(time.Now().Add(t.urlLifetime).Unix(), 10)
+
+	q := url.Values{}
+	q.Set("expires", expires)
+	q.Set("signature", t.sign(ident, expires))
+
+	return fmt.Sprintf("%s/file/%s?%s", t.baseURL, url.PathEscape(ident), q.Encode())
+}
+
+// --- Internal stuff ---
+
+func (t *fileServer) sign(ident, expires string) string {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(ident))
+	mac.Write([]byte{0})
+	mac.Write([]byte(expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (t *fileServer) verify(ident, expires, signature string) bool {
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresUnix {
+		return false
+	}
+
+	expected, err := hex.DecodeString(t.sign(ident, expires))
+	if err != nil {
+		return false
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(expected, actual)
+}
+
+func (t *fileServer) handleGetFile(ctx *routing.Context) error {
+	id := ctx.Param("id")
+
+	if !t.verify(id, ctx.Query("expires"), ctx.Query("signature")) {
+		return ctx.WriteWithStatus("", http.StatusForbidden)
+	}
+
+	r, _, err := t.st.GetObject(static.SoundBucket(id), id)
+	if err != nil {
+		return ctx.WriteWithStatus("", http.StatusNotFound)
+	}
+	defer r.Close()
+
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			logrus.WithError(err).Error("Reading file failed")
+			return ctx.WriteWithStatus("", http.StatusInternalServerError)
+		}
+		rs = bytes.NewReader(data)
+	}
+
+	mime, err := mimetype.DetectReader(rs)
+	if err != nil {
+		logrus.WithError(err).Error("Detecting file type failed")
+		return ctx.WriteWithStatus("", http.StatusInternalServerError)
+	}
+	if _, err = rs.Seek(0, io.SeekStart); err != nil {
+		logrus.WithError(err).Error("Seeking file failed")
+		return ctx.WriteWithStatus("", http.StatusInternalServerError)
+	}
+
+	ctx.Response.Header().Set("Content-Type", mime.String())
+	http.ServeContent(ctx.Response, ctx.Request, id, time.Time{}, rs)
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package player
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zekrotja/yuri69/pkg/static"
+	"github.com/zekrotja/yuri69/pkg/storage"
+)
+
+func newTestFileServer(t *testing.T, lifetime time.Duration) *fileServer {
+	st, err := storage.NewFile(storage.FileConfig{BasePath: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := append([]byte("OggS"), bytes.Repeat([]byte{0}, 100)...)
+	err = st.PutObject(static.BucketSounds, "sound", bytes.NewReader(data), int64(len(data)), "audio/ogg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := newFileServer(PlayerConfig{
+		Hostname: "yuri69",
+		FileServer: FileServerConfig{
+			BindAddress: "0.0.0.0:6969",
+			SigningKey:  "key",
+			URLLifetime: lifetime,
+		},
+	}, st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fs
+}
+
+func request(fs *fileServer, soundURL string, header http.Header) *httptest.ResponseRecorder {
+	u, _ := url.Parse(soundURL)
+	req := httptest.NewRequest(http.MethodGet, u.RequestURI(), nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	rec := httptest.NewRecorder()
+	fs.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSoundURL(t *testing.T) {
+	fs := newTestFileServer(t, time.Minute)
+
+	u := fs.SoundURL("sound")
+	assert.True(t, strings.HasPrefix(u, "http://yuri69:6969/file/sound?"))
+
+	rec := request(fs, u, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/ogg", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+	assert.Equal(t, 104, rec.Body.Len())
+}
+
+func TestSoundURLRange(t *testing.T) {
+	fs := newTestFileServer(t, time.Minute)
+
+	rec := request(fs, fs.SoundURL("sound"), http.Header{"Range": {"bytes=0-3"}})
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "bytes 0-3/104", rec.Header().Get("Content-Range"))
+
+	body, _ := io.ReadAll(rec.Body)
+	assert.Equal(t, []byte("OggS"), body)
+}
+
+func TestSoundURLInvalidSignature(t *testing.T) {
+	fs := newTestFileServer(t, time.Minute)
+
+	// Signature of another sound.
+	u, _ := url.Parse(fs.SoundURL("other"))
+	u.Path = "/file/sound"
+	rec := request(fs, u.String(), nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = request(fs, "http://yuri69:6969/file/sound", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	u, _ = url.Parse(fs.SoundURL("sound"))
+	q := u.Query()
+	q.Set("expires", "9999999999")
+	u.RawQuery = q.Encode()
+	rec = request(fs, u.String(), nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestSoundURLExpired(t *testing.T) {
+	fs := newTestFileServer(t, time.Nanosecond)
+
+	u := fs.SoundURL("sound")
+	time.Sleep(1100 * time.Millisecond)
+
+	rec := request(fs, u, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
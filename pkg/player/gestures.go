@@ -0,0 +1,141 @@
+package player
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekrotja/yuri69/pkg/generic"
+)
+
+type Gesture string
+
+const (
+	// GestureMuteToggle is muting and unmuting oneself
+	// within the fast trigger time.
+	GestureMuteToggle = Gesture("mute")
+	// GestureDeafenToggle is deafening and undeafening
+	// oneself within the fast trigger time.
+	GestureDeafenToggle = Gesture("deafen")
+	// GestureDoubleToggle is performing two mute toggles
+	// within the double trigger time.
+	GestureDoubleToggle = Gesture("double")
+)
+
+// Gestures contains all recognised gestures.
+var Gestures = []Gesture{GestureMuteToggle, GestureDeafenToggle, GestureDoubleToggle}
+
+// gestureDetector recognises fast trigger gestures from the
+// voice state updates of users.
+type gestureDetector struct {
+	toggleTime       time.Duration
+	doubleToggleTime time.Duration
+	emit             func(guildID, userID string, g Gesture)
+
+	states generic.SyncMap[string, *gestureState]
+}
+
+type gestureState struct {
+	mtx     sync.Mutex
+	muteOn  time.Time
+	deafOn  time.Time
+	pending *time.Timer
+}
+
+func newGestureDetector(
+	toggleTime, doubleToggleTime time.Duration,
+	emit func(guildID, userID string, g Gesture),
+) *gestureDetector {
+	return &gestureDetector{
+		toggleTime:       toggleTime,
+		doubleToggleTime: doubleToggleTime,
+		emit:             emit,
+	}
+}
+
+// update processes the transition of a voice state of a user
+// from before to after at the given time.
+func (t *gestureDetector) update(before, after *discordgo.VoiceState, now time.Time) {
+	if before == nil || after == nil || after.ChannelID == "" {
+		return
+	}
+
+	key := after.GuildID + ":" + after.UserID
+	s, _ := t.states.LoadOrStore(key, &gestureState{})
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// Deafening oneself also mutes oneself, so mute changes
+	// are only considered when the deafen state is unchanged.
+	switch {
+	case before.SelfDeaf != after.SelfDeaf:
+		if after.SelfDeaf {
+			s.deafOn = now
+			return
+		}
+		if t.isToggle(s.deafOn, now) {
+			t.emit(after.GuildID, after.UserID, GestureDeafenToggle)
+		}
+		s.deafOn = time.Time{}
+
+	case before.SelfMute != after.SelfMute:
+		if after.SelfMute {
+			s.muteOn = now
+			return
+		}
+		isToggle := t.isToggle(s.muteOn, now)
+		s.muteOn = time.Time{}
+		if !isToggle {
+			return
+		}
+		t.onMuteToggle(s, after.GuildID, after.UserID)
+	}
+}
+
+// reset discards the state of the user in the guild,
+// including pending gestures.
+func (t *gestureDetector) reset(guildID, userID string) {
+	s, ok := t.states.LoadAndDelete(guildID + ":" + userID)
+	if !ok {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.pending != nil {
+		s.pending.Stop()
+		s.pending = nil
+	}
+}
+
+func (t *gestureDetector) isToggle(on, now time.Time) bool {
+	return !on.IsZero() && now.Sub(on) <= t.toggleTime
+}
+
+// onMuteToggle emits a mute toggle when no second toggle
+// follows within the double toggle time. Otherwise, a
+// double toggle is emitted instead.
+func (t *gestureDetector) onMuteToggle(s *gestureState, guildID, userID string) {
+	if t.doubleToggleTime <= 0 {
+		t.emit(guildID, userID, GestureMuteToggle)
+		return
+	}
+
+	if s.pending != nil && s.pending.Stop() {
+		s.pending = nil
+		t.emit(guildID, userID, GestureDoubleToggle)
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(t.doubleToggleTime, func() {
+		s.mtx.Lock()
+		if s.pending == timer {
+			s.pending = nil
+		}
+		s.mtx.Unlock()
+		t.emit(guildID, userID, GestureMuteToggle)
+	})
+	s.pending = timer
+}
@@ -0,0 +1,101 @@
+package player
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+type gestureRecorder struct {
+	mtx      sync.Mutex
+	gestures []Gesture
+}
+
+func (t *gestureRecorder) emit(_, _ string, g Gesture) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.gestures = append(t.gestures, g)
+}
+
+func (t *gestureRecorder) get() []Gesture {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return append([]Gesture(nil), t.gestures...)
+}
+
+func vs(mute, deaf bool) *discordgo.VoiceState {
+	return &discordgo.VoiceState{
+		GuildID:   "guild",
+		ChannelID: "channel",
+		UserID:    "user",
+		SelfMute:  mute,
+		SelfDeaf:  deaf,
+	}
+}
+
+func TestGestureMuteToggle(t *testing.T) {
+	var r gestureRecorder
+	d := newGestureDetector(time.Second, 0, r.emit)
+	now := time.Now()
+
+	d.update(vs(false, false), vs(true, false), now)
+	d.update(vs(true, false), vs(false, false), now.Add(500*time.Millisecond))
+	assert.Equal(t, []Gesture{GestureMuteToggle}, r.get())
+
+	d.update(vs(false, false), vs(true, false), now.Add(2*time.Second))
+	d.update(vs(true, false), vs(false, false), now.Add(4*time.Second))
+	assert.Equal(t, []Gesture{GestureMuteToggle}, r.get())
+}
+
+func TestGestureDeafenToggle(t *testing.T) {
+	var r gestureRecorder
+	d := newGestureDetector(time.Second, 0, r.emit)
+	now := time.Now()
+
+	d.update(vs(false, false), vs(true, true), now)
+	d.update(vs(true, true), vs(false, false), now.Add(500*time.Millisecond))
+	assert.Equal(t, []Gesture{GestureDeafenToggle}, r.get())
+
+	// Undeafening while muted before keeps the user muted.
+	d.update(vs(true, false), vs(true, true), now.Add(time.Second))
+	d.update(vs(true, true), vs(true, false), now.Add(3*time.Second))
+	assert.Equal(t, []Gesture{GestureDeafenToggle}, r.get())
+}
+
+func TestGestureDoubleToggle(t *testing.T) {
+	var r gestureRecorder
+	d := newGestureDetector(time.Second, 50*time.Millisecond, r.emit)
+	now := time.Now()
+
+	d.update(vs(false, false), vs(true, false), now)
+	d.update(vs(true, false), vs(false, false), now)
+	d.update(vs(false, false), vs(true, false), now)
+	d.update(vs(true, false), vs(false, false), now)
+	assert.Equal(t, []Gesture{GestureDoubleToggle}, r.get())
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []Gesture{GestureDoubleToggle}, r.get())
+
+	d.update(vs(false, false), vs(true, false), now)
+	d.update(vs(true, false), vs(false, false), now)
+	assert.Empty(t, r.get()[1:])
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []Gesture{GestureDoubleToggle, GestureMuteToggle}, r.get())
+}
+
+func TestGestureReset(t *testing.T) {
+	var r gestureRecorder
+	d := newGestureDetector(time.Second, 50*time.Millisecond, r.emit)
+	now := time.Now()
+
+	d.update(vs(false, false), vs(true, false), now)
+	d.update(vs(true, false), vs(false, false), now)
+	d.reset("guild", "user")
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, r.get())
+}
@@ -0,0 +1,80 @@
+package player
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zekroTJA/timedmap"
+	"github.com/zekrotja/yuri69/pkg/discord"
+	"github.com/zekrotja/yuri69/pkg/lavalink"
+)
+
+// lavalinkBackend plays sounds via Lavalink, which fetches
+// stored sounds from the file server of the player.
+type lavalinkBackend struct {
+	*lavalink.Lavalink
+
+	dc         *discord.Discord
+	files      *fileServer
+	trackCache *timedmap.TimedMap[string, string]
+}
+
+var _ Backend = (*lavalinkBackend)(nil)
+
+func newLavalinkBackend(
+	c PlayerConfig,
+	dc *discord.Discord,
+	files *fileServer,
+	eventHandler func(any),
+) (*lavalinkBackend, error) {
+	var (
+		t   lavalinkBackend
+		err error
+	)
+
+	t.dc = dc
+	t.files = files
+	t.trackCache = timedmap.New[string, string](5 * time.Minute)
+
+	t.Lavalink, err = lavalink.New(c.Lavalink, dc, eventHandler)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (t *lavalinkBackend) Join(guildID, channelID string) error {
+	return t.dc.Session().ChannelVoiceJoinManual(guildID, channelID, false, true)
+}
+
+func (t *lavalinkBackend) Leave(guildID string) error {
+	return t.dc.Session().ChannelVoiceJoinManual(guildID, "", false, true)
+}
+
+func (t *lavalinkBackend) Play(guildID, ident, url string) (lavalink.Track, error) {
+	if url == "" {
+		url = t.files.SoundURL(ident)
+	}
+
+	tr, err := t.Lavalink.Play(guildID, url)
+	if tr.ID != "" {
+		if ident == "" {
+			ident = url
+		}
+		t.trackCache.Set(tr.Info.URI, ident, tr.Info.Length+30*time.Second)
+	}
+	return tr, err
+}
+
+func (t *lavalinkBackend) TrackIdent(trackID string) string {
+	info, err := t.DecodeTrackId(trackID)
+	if err != nil {
+		logrus.WithError(err).
+			WithField("trackID", trackID).
+			Error("Failed decoding track id")
+		return ""
+	}
+
+	return t.trackCache.GetValue(info.URI)
+}
@@ -0,0 +1,519 @@
+package player
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/xid"
+	"github.com/sirupsen/logrus"
+	"github.com/zekroTJA/timedmap"
+	"github.com/zekrotja/yuri69/pkg/discord"
+	"github.com/zekrotja/yuri69/pkg/generic"
+	"github.com/zekrotja/yuri69/pkg/lavalink"
+	"github.com/zekrotja/yuri69/pkg/ogg"
+	"github.com/zekrotja/yuri69/pkg/static"
+	"github.com/zekrotja/yuri69/pkg/storage"
+)
+
+const (
+	nativeUpdateInterval   = 5 * time.Second
+	nativeTranscodeTimeout = 1 * time.Minute
+)
+
+// nativeBackend streams the stored Ogg/Opus sounds directly
+// over the voice connection of discordgo. Because the audio
+// is not re-encoded, the volume of the player can not be
+// changed and filters are not supported.
+//
+// Sounds which have been stored with Vorbis by earlier
+// versions are transcoded to Opus each time they are loaded.
+type nativeBackend struct {
+	dc         *discord.Discord
+	st         storage.IStorage
+	ffmpegExec string
+
+	eventHandler   func(any)
+	players        generic.SyncMap[string, *nativePlayer]
+	trackCache     *timedmap.TimedMap[string, string]
+	updateInterval time.Duration
+
+	// Events are queued and passed to the event handler by
+	// dispatchEvents, because the handlers lock the state of
+	// the guild, which might be locked while the player is
+	// waiting for the streaming goroutine.
+	eventsMtx    sync.Mutex
+	events       []any
+	eventsSignal chan struct{}
+	closed       chan struct{}
+}
+
+var _ Backend = (*nativeBackend)(nil)
+
+type nativePlayer struct {
+	mtx    sync.Mutex
+	paused bool
+	track  *nativeTrack
+}
+
+// nativeTrack holds the Opus packets of a loaded sound and
+// the channels to control the goroutine streaming it.
+type nativeTrack struct {
+	lavalink.Track
+
+	packets [][]byte
+	offsets []time.Duration
+
+	stop  chan string
+	pause chan bool
+	seek  chan time.Duration
+	done  chan struct{}
+}
+
+func newNativeBackend(dc *discord.Discord, st storage.IStorage, ffmpegExec string, eventHandler func(any)) *nativeBackend {
+	t := &nativeBackend{
+		dc:             dc,
+		st:             st,
+		ffmpegExec:     ffmpegExec,
+		eventHandler:   eventHandler,
+		trackCache:     timedmap.New[string, string](5 * time.Minute),
+		updateInterval: nativeUpdateInterval,
+		eventsSignal:   make(chan struct{}, 1),
+		closed:         make(chan struct{}),
+	}
+
+	go t.dispatchEvents()
+
+	return t
+}
+
+func (t *nativeBackend) Join(guildID, channelID string) error {
+	_, err := t.dc.Session().ChannelVoiceJoin(guildID, channelID, false, true)
+	return err
+}
+
+func (t *nativeBackend) Leave(guildID string) error {
+	vc := t.voiceConnection(guildID)
+	if vc == nil {
+		return nil
+	}
+	return vc.Disconnect()
+}
+
+func (t *nativeBackend) Play(guildID, ident, url string) (lavalink.Track, error) {
+	if url != "" {
+		return lavalink.Track{}, ErrUnsupportedSource
+	}
+
+	vc := t.voiceConnection(guildID)
+	if vc == nil {
+		return lavalink.Track{}, ErrNoGuildPlayer
+	}
+
+	tr, err := t.loadTrack(ident)
+	if err != nil {
+		return lavalink.Track{}, err
+	}
+
+	p := t.getPlayer(guildID)
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.stopTrack("replaced")
+	p.track = tr
+
+	t.trackCache.Set(tr.ID, ident, tr.Info.Length+30*time.Second)
+	go t.stream(guildID, vc, tr, p.paused)
+
+	return tr.Track, nil
+}
+
+func (t *nativeBackend) Stop(guildID string) error {
+	p, ok := t.players.Load(guildID)
+	if !ok {
+		return nil
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.stopTrack("stopped")
+	return nil
+}
+
+func (t *nativeBackend) Destroy(guildID string) error {
+	p, ok := t.players.LoadAndDelete(guildID)
+	if !ok {
+		return nil
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.stopTrack("cleanup")
+	return nil
+}
+
+func (t *nativeBackend) SetPaused(guildID string, paused bool) error {
+	p := t.getPlayer(guildID)
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.paused = paused
+	if p.track != nil {
+		select {
+		case p.track.pause <- paused:
+		case <-p.track.done:
+		}
+	}
+	return nil
+}
+
+func (t *nativeBackend) Seek(guildID string, position time.Duration) error {
+	p, ok := t.players.Load(guildID)
+	if !ok {
+		return nil
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.track != nil {
+		select {
+		case p.track.seek <- position:
+		case <-p.track.done:
+		}
+	}
+	return nil
+}
+
+func (t *nativeBackend) SetFilters(guildID string, filters lavalink.Filters) error {
+	if !filters.IsEmpty() {
+		return ErrUnsupportedFilters
+	}
+	return nil
+}
+
+// SetVolume is a no-op because the volume of the stored
+// sounds can not be changed without re-encoding them.
+func (t *nativeBackend) SetVolume(guildID string, volume uint16) error {
+	return nil
+}
+
+// RestorePlayer is a no-op because discordgo re-establishes
+// lost voice connections on its own.
+func (t *nativeBackend) RestorePlayer(guildID string) error {
+	return nil
+}
+
+func (t *nativeBackend) TrackIdent(trackID string) string {
+	return t.trackCache.GetValue(trackID)
+}
+
+func (t *nativeBackend) Close() error {
+	t.players.Range(func(guildID string, _ *nativePlayer) bool {
+		t.Destroy(guildID)
+		return true
+	})
+	close(t.closed)
+	return nil
+}
+
+// --- Internal stuff ---
+
+func (t *nativeBackend) getPlayer(guildID string) *nativePlayer {
+	p, _ := t.players.LoadOrStore(guildID, &nativePlayer{})
+	return p
+}
+
+func (t *nativeBackend) voiceConnection(guildID string) *discordgo.VoiceConnection {
+	s := t.dc.Session()
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.VoiceConnections[guildID]
+}
+
+// loadTrack reads all Opus packets of the stored sound
+// into memory, so that the sound can be seeked.
+func (t *nativeBackend) loadTrack(ident string) (*nativeTrack, error) {
+	r, _, err := t.st.GetObject(static.SoundBucket(ident), ident)
+	if err != nil {
+		return nil, err
+	}
+	packets, offsets, length, err := readOpusPackets(r)
+	r.Close()
+
+	if err == ErrNotOpus && t.ffmpegExec != "" {
+		packets, offsets, length, err = t.transcodeTrack(ident)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tr := &nativeTrack{
+		Track: lavalink.Track{
+			ID: xid.New().String(),
+			Info: lavalink.TrackInfo{
+				Identifier: ident,
+				Title:      ident,
+				URI:        ident,
+				SourceName: BackendNative,
+				Length:     length,
+				Seekable:   true,
+			},
+		},
+		packets: packets,
+		offsets: offsets,
+		stop:    make(chan string),
+		pause:   make(chan bool),
+		seek:    make(chan time.Duration),
+		done:    make(chan struct{}),
+	}
+
+	return tr, nil
+}
+
+// transcodeTrack transcodes the stored sound to Opus and
+// reads its packets.
+func (t *nativeBackend) transcodeTrack(ident string) ([][]byte, []time.Duration, time.Duration, error) {
+	r, _, err := t.st.GetObject(static.SoundBucket(ident), ident)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), nativeTranscodeTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.ffmpegExec,
+		"-hide_banner", "-f", "ogg", "-i", "pipe:", "-map", "0:a:0",
+		"-c:a", "libopus", "-ar", "48000", "-ac", "2", "-frame_duration", "20",
+		"-f", "ogg", "pipe:")
+	cmd.Stdin = r
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err = cmd.Run(); err != nil {
+		logrus.
+			WithError(err).
+			WithField("ident", ident).
+			WithField("stderr", stderr.String()).
+			Error("Transcoding sound to Opus failed")
+		return nil, nil, 0, ErrNotOpus
+	}
+
+	return readOpusPackets(&stdout)
+}
+
+// readOpusPackets reads the audio packets of the Ogg Opus
+// stream and their offsets. ErrNotOpus is returned when the
+// stream is not encoded with Opus.
+func readOpusPackets(r io.Reader) (packets [][]byte, offsets []time.Duration, length time.Duration, err error) {
+	pr := ogg.NewPacketReader(r)
+
+	// The first two packets of an Ogg Opus stream are the
+	// identification and comment headers.
+	head, err := pr.ReadPacket()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if !bytes.HasPrefix(head, []byte("OpusHead")) {
+		return nil, nil, 0, ErrNotOpus
+	}
+	if _, err = pr.ReadPacket(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	for {
+		packet, err := pr.ReadPacket()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if len(packet) == 0 {
+			continue
+		}
+		packets = append(packets, packet)
+		offsets = append(offsets, length)
+		length += opusPacketDuration(packet)
+	}
+
+	return packets, offsets, length, nil
+}
+
+// stream sends the packets of the track to the voice
+// connection until the track has finished or has been
+// stopped. The end event is emitted after the track has
+// been marked as done, so that event handlers can start
+// playing the next track.
+func (t *nativeBackend) stream(guildID string, vc *discordgo.VoiceConnection, tr *nativeTrack, paused bool) {
+	t.emit(lavalink.TrackStartEvent{
+		GuildID: guildID,
+		TrackID: tr.ID,
+	})
+
+	reason := t.sendPackets(guildID, vc, tr, paused)
+	close(tr.done)
+
+	t.emit(lavalink.TrackEndEvent{
+		GuildID: guildID,
+		TrackID: tr.ID,
+		Reason:  reason,
+	})
+}
+
+func (t *nativeBackend) sendPackets(guildID string, vc *discordgo.VoiceConnection, tr *nativeTrack, paused bool) string {
+	if err := vc.Speaking(true); err != nil {
+		logrus.WithError(err).WithField("guildID", guildID).Warn("Setting speaking state failed")
+	}
+	defer vc.Speaking(false)
+
+	ticker := time.NewTicker(t.updateInterval)
+	defer ticker.Stop()
+
+	i := 0
+	for i < len(tr.packets) {
+		send := vc.OpusSend
+		if paused {
+			send = nil
+		}
+
+		select {
+		case reason := <-tr.stop:
+			return reason
+		case paused = <-tr.pause:
+		case position := <-tr.seek:
+			i = tr.packetAt(position)
+		case <-ticker.C:
+			t.emit(lavalink.PlayerUpdateEvent{
+				GuildID:   guildID,
+				Position:  tr.offsets[i],
+				Connected: true,
+			})
+		case send <- tr.packets[i]:
+			i++
+		}
+	}
+
+	return "finished"
+}
+
+// emit queues the event for the event handler. It never
+// blocks, so that the streaming goroutine keeps reacting to
+// the player while the handlers are running.
+func (t *nativeBackend) emit(e any) {
+	if t.eventHandler == nil {
+		return
+	}
+
+	t.eventsMtx.Lock()
+	t.events = append(t.events, e)
+	t.eventsMtx.Unlock()
+
+	select {
+	case t.eventsSignal <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchEvents passes the queued events to the event
+// handler in the order they were emitted until the backend
+// is closed.
+func (t *nativeBackend) dispatchEvents() {
+	for {
+		select {
+		case <-t.eventsSignal:
+		case <-t.closed:
+			return
+		}
+
+		for {
+			t.eventsMtx.Lock()
+			events := t.events
+			t.events = nil
+			t.eventsMtx.Unlock()
+
+			if len(events) == 0 {
+				break
+			}
+			for _, e := range events {
+				t.eventHandler(e)
+			}
+		}
+	}
+}
+
+// stopTrack stops the currently played track and waits
+// until it is no more streamed.
+//
+// The player must be locked by the caller.
+func (t *nativePlayer) stopTrack(reason string) {
+	if t.track == nil {
+		return
+	}
+
+	select {
+	case t.track.stop <- reason:
+	case <-t.track.done:
+	}
+	<-t.track.done
+	t.track = nil
+}
+
+// packetAt returns the index of the packet which is
+// played at the given position.
+func (t *nativeTrack) packetAt(position time.Duration) int {
+	i := sort.Search(len(t.offsets), func(i int) bool {
+		return t.offsets[i] > position
+	})
+	if i > 0 {
+		i--
+	}
+	return i
+}
+
+// opusPacketDuration returns the duration of the audio
+// encoded in the given Opus packet as specified in
+// RFC 6716, Section 3.1.
+func opusPacketDuration(packet []byte) time.Duration {
+	if len(packet) == 0 {
+		return 0
+	}
+
+	toc := packet[0]
+	config := toc >> 3
+
+	var frame time.Duration
+	switch {
+	case config < 12: // SILK
+		frame = []time.Duration{10, 20, 40, 60}[config%4] * time.Millisecond
+	case config < 16: // Hybrid
+		frame = []time.Duration{10, 20}[config%2] * time.Millisecond
+	default: // CELT
+		frame = []time.Duration{2500, 5000, 10000, 20000}[config%4] * time.Microsecond
+	}
+
+	var frames int
+	switch toc & 0x3 {
+	case 0:
+		frames = 1
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0
+		}
+		frames = int(packet[1] & 0x3f)
+	}
+
+	return frame * time.Duration(frames)
+}
@@ -0,0 +1,167 @@
+package player
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/zekrotja/eventbus"
+	"github.com/zekrotja/yuri69/pkg/database/nuts"
+	"github.com/zekrotja/yuri69/pkg/lavalink"
+	"github.com/zekrotja/yuri69/pkg/models"
+	"github.com/zekrotja/yuri69/pkg/static"
+	"github.com/zekrotja/yuri69/pkg/storage"
+)
+
+// newNativeTestTrack returns a track of the given number of
+// 20ms packets.
+func newNativeTestTrack(packets int) *nativeTrack {
+	tr := &nativeTrack{
+		Track: lavalink.Track{
+			ID: "track",
+			Info: lavalink.TrackInfo{
+				Length:   time.Duration(packets) * 20 * time.Millisecond,
+				Seekable: true,
+			},
+		},
+		stop:  make(chan string),
+		pause: make(chan bool),
+		seek:  make(chan time.Duration),
+		done:  make(chan struct{}),
+	}
+	for i := 0; i < packets; i++ {
+		// CELT, 20ms, one frame
+		tr.packets = append(tr.packets, []byte{31 << 3})
+		tr.offsets = append(tr.offsets, time.Duration(i)*20*time.Millisecond)
+	}
+	return tr
+}
+
+// oggStream returns an Ogg stream containing each packet in
+// a separate page.
+func oggStream(packets ...[]byte) []byte {
+	var buf bytes.Buffer
+	for _, packet := range packets {
+		header := make([]byte, 27)
+		copy(header, "OggS")
+		header[26] = 1
+		buf.Write(header)
+		buf.WriteByte(byte(len(packet)))
+		buf.Write(packet)
+	}
+	return buf.Bytes()
+}
+
+func TestNativeLoadTrackTranscodesVorbis(t *testing.T) {
+	dir := t.TempDir()
+	st, err := storage.NewFile(storage.FileConfig{BasePath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vorbis := oggStream([]byte("\x01vorbis"), []byte("\x03vorbis"), []byte{1})
+	err = st.PutObject(static.BucketSounds, "sound", bytes.NewReader(vorbis), int64(len(vorbis)), static.SoundsMime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = newNativeBackend(nil, st, "", nil).loadTrack("sound")
+	assert.ErrorIs(t, err, ErrNotOpus)
+
+	// The fake ffmpeg outputs an Opus stream of two 20ms
+	// packets regardless of its input.
+	opus := filepath.Join(dir, "opus.ogg")
+	err = os.WriteFile(opus, oggStream([]byte("OpusHead"), []byte("OpusTags"), []byte{31 << 3}, []byte{31 << 3}), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	err = os.WriteFile(ffmpeg, []byte("#!/bin/sh\ncat > /dev/null\ncat "+opus+"\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := newNativeBackend(nil, st, ffmpeg, nil).loadTrack("sound")
+	if assert.Nil(t, err) {
+		assert.Len(t, tr.packets, 2)
+		assert.Equal(t, 40*time.Millisecond, tr.Info.Length)
+	}
+
+	// The stored sound is not changed.
+	r, _, err := st.GetObject(static.BucketSounds, "sound")
+	if assert.Nil(t, err) {
+		data, _ := io.ReadAll(r)
+		r.Close()
+		assert.Equal(t, vorbis, data)
+	}
+}
+
+func TestNativeSeekAndPauseWhileUpdating(t *testing.T) {
+	db, err := nuts.NewNuts(nuts.NutsConfig{Location: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err = db.SetGuildAutoLeave("guild", models.GuildAutoLeave{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var p Player
+	p.EventBus = eventbus.New[Event](100)
+	p.db = db
+	p.vcs.Store("guild", voiceConnection{GuildID: "guild", ChannelID: "channel"})
+
+	backend := newNativeBackend(nil, nil, "", p.handleEvent)
+	backend.updateInterval = time.Millisecond
+	p.backend = backend
+
+	vc := &discordgo.VoiceConnection{OpusSend: make(chan []byte)}
+	stopSending := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-vc.OpusSend:
+				time.Sleep(time.Millisecond)
+			case <-stopSending:
+				return
+			}
+		}
+	}()
+	defer close(stopSending)
+	defer backend.Close()
+
+	tr := newNativeTestTrack(100_000)
+	p.getState("guild").setCurrent(models.QueueEntry{Ident: "sound"}, tr.Track)
+	backend.getPlayer("guild").track = tr
+	go backend.stream("guild", vc, tr, false)
+
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 200; i++ {
+			if err := p.Seek("guild", time.Duration(i)*time.Second); err != nil {
+				done <- err
+				return
+			}
+			if err := p.setPaused("guild", i%2 == 0); err != nil {
+				done <- err
+				return
+			}
+			time.Sleep(100 * time.Microsecond)
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("seeking and pausing blocked")
+	}
+}
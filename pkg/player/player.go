@@ -3,21 +3,18 @@ package player
 import (
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/lukasl-dev/waterlink/v2/event"
 	"github.com/sirupsen/logrus"
-	"github.com/zekroTJA/timedmap"
 	"github.com/zekrotja/eventbus"
-	routing "github.com/zekrotja/ozzo-routing/v2"
+	"github.com/zekrotja/yuri69/pkg/database"
 	"github.com/zekrotja/yuri69/pkg/discord"
 	"github.com/zekrotja/yuri69/pkg/generic"
 	"github.com/zekrotja/yuri69/pkg/lavalink"
-	"github.com/zekrotja/yuri69/pkg/static"
+	"github.com/zekrotja/yuri69/pkg/models"
 	"github.com/zekrotja/yuri69/pkg/storage"
 	"github.com/zekrotja/yuri69/pkg/util"
 )
@@ -25,20 +22,23 @@ import (
 type Player struct {
 	*eventbus.EventBus[Event]
 
-	dc *discord.Discord
-	st storage.IStorage
-	ll *lavalink.Lavalink
+	dc      *discord.Discord
+	st      storage.IStorage
+	db      database.IDatabase
+	backend Backend
 
-	hostname string
+	effects map[string]lavalink.Filters
 
 	vcs     generic.SyncMap[string, voiceConnection]
+	states  generic.SyncMap[string, *guildState]
 	waiters util.Waiters[string]
 
-	router *routing.Router
-	server *http.Server
+	files    *fileServer
+	gestures *gestureDetector
 
-	autoLeaveTimer *time.Timer
-	trackCache     *timedmap.TimedMap[string, string]
+	autoLeaveTimeout time.Duration
+	idleTimeout      time.Duration
+	autoLeaveTimers  generic.SyncMap[string, *autoLeaveTimers]
 }
 
 type voiceConnection struct {
@@ -50,6 +50,7 @@ func NewPlayer(
 	c PlayerConfig,
 	dc *discord.Discord,
 	st storage.IStorage,
+	db database.IDatabase,
 ) (*Player, error) {
 
 	var (
@@ -57,29 +58,40 @@ func NewPlayer(
 		err error
 	)
 
-	t.hostname = c.Hostname
-	if t.hostname == "" {
-		var err error
-		t.hostname, err = os.Hostname()
-		if err != nil {
-			return nil, err
-		}
-	}
+	t.autoLeaveTimeout = c.AutoLeaveTimeout
+	t.idleTimeout = c.IdleTimeout
 
 	t.EventBus = eventbus.New[Event](100)
-	t.trackCache = timedmap.New[string, string](5 * time.Minute)
 
 	t.dc = dc
 	t.st = st
+	t.db = db
 
-	t.ll, err = lavalink.New(c.Lavalink, dc, t.handleEvent)
+	t.files, err = newFileServer(c, st)
 	if err != nil {
 		return nil, err
 	}
 
-	t.router = routing.New()
-	t.router.Get("/file/<id>", t.handleGetFile)
+	switch strings.ToLower(c.Backend) {
+	case "", BackendLavalink:
+		t.setEffects(c.Effects)
+		t.backend, err = newLavalinkBackend(c, dc, t.files, t.handleEvent)
+	case BackendNative:
+		// Effects require re-encoding the audio, which is
+		// not done by the native backend.
+		t.setEffects(nil)
+		// Sounds which are not encoded with Opus can only be
+		// played when ffmpeg is available.
+		ffmpegExec, _ := exec.LookPath("ffmpeg")
+		t.backend = newNativeBackend(dc, st, ffmpegExec, t.handleEvent)
+	default:
+		err = fmt.Errorf("unsupported player backend: %s", c.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
 
+	t.gestures = newGestureDetector(c.FastTriggerTime, c.DoubleTriggerTime, t.onGesture)
 	t.dc.Session().AddHandler(t.handleVoiceUpdate)
 
 	t.SubscribeFunc(func(e Event) {
@@ -101,40 +113,72 @@ func NewPlayer(
 }
 
 func (t *Player) ListenAndServeBlocking() error {
-	t.server = &http.Server{
-		Addr:    "0.0.0.0:6969",
-		Handler: t.router,
-	}
-	return t.server.ListenAndServe()
+	return t.files.ListenAndServeBlocking()
 }
 
 func (t *Player) Init(guildID, channelID string) error {
 	vc, ok := t.vcs.Load(guildID)
 	if !ok || vc.ChannelID != channelID {
-		err := t.dc.Session().ChannelVoiceJoinManual(guildID, channelID, false, true)
-		if err != nil {
+		if err := t.backend.Join(guildID, channelID); err != nil {
 			return err
 		}
-		t.waiters.CreateAndWait(guildID)
+		// The native backend returns after the voice state
+		// has already been received.
+		if vc, ok = t.vcs.Load(guildID); !ok || vc.ChannelID != channelID {
+			t.waiters.CreateAndWait(guildID)
+		}
 	}
 	return nil
 }
 
-func (t *Player) PlaySound(guildID, channelID, ident string) error {
-	return t.Play(guildID, channelID,
-		fmt.Sprintf("http://%s:6969/file/%s", t.hostname, ident), ident)
+// Play plays the given entry immediately, replacing the currently
+// played sound. The queue of the guild is kept untouched.
+//
+// When url is empty, the entries ident is played as stored sound.
+func (t *Player) Play(guildID string, entry models.QueueEntry, url string) error {
+	return t.playItem(guildID, queueItem{
+		QueueEntry: entry,
+		url:        url,
+	})
 }
 
-func (t *Player) Play(guildID, channelID, url, ident string) error {
-	track, err := t.ll.Play(guildID, url)
-	if track.ID != "" {
-		if ident == "" {
-			ident = url
+// PlayAll plays the given entries of stored sounds one after
+// another, replacing the currently played sound. The entries
+// are played before the other entries in the queue.
+func (t *Player) PlayAll(guildID string, entries []models.QueueEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	items := make([]queueItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, queueItem{QueueEntry: entry})
+	}
+
+	q := t.getState(guildID)
+
+	q.mtx.Lock()
+	q.items = append(items[1:len(items):len(items)], q.items...)
+	wasPlaying := q.trackID != ""
+	if items[0].Delay > 0 {
+		// The current sound is stopped so that nothing
+		// is played while waiting for the first entry.
+		q.resetCurrent()
+		q.playing = true
+	}
+	q.mtx.Unlock()
+
+	if len(items) > 1 {
+		t.publishQueueUpdate(guildID)
+	}
+
+	if items[0].Delay > 0 && wasPlaying {
+		if err := t.backend.Stop(guildID); err != nil {
+			return err
 		}
-		t.trackCache.Set(track.Info.URI, ident,
-			time.Duration(track.Info.Length)*time.Microsecond+30*time.Second)
 	}
-	return err
+
+	return t.startItem(guildID, items[0])
 }
 
 func (t *Player) Destroy(guildID string) error {
@@ -143,7 +187,7 @@ func (t *Player) Destroy(guildID string) error {
 		return ErrNoGuildPlayer
 	}
 
-	return t.dc.Session().ChannelVoiceJoinManual(guildID, "", false, true)
+	return t.backend.Leave(guildID)
 }
 
 func (t *Player) Stop(guildID string) error {
@@ -152,7 +196,24 @@ func (t *Player) Stop(guildID string) error {
 		return ErrNoGuildPlayer
 	}
 
-	return t.ll.Stop(guildID)
+	// The state is created when missing, so that stopping
+	// succeeds while the bot is connected but nothing has
+	// been played yet.
+	q := t.getState(guildID)
+	q.mtx.Lock()
+	q.items = nil
+	canceled := q.cancelPending()
+	if canceled {
+		q.resetCurrent()
+	}
+	q.mtx.Unlock()
+
+	t.publishQueueUpdate(guildID)
+	if canceled {
+		t.setIdle(guildID, true)
+	}
+
+	return t.backend.Stop(guildID)
 }
 
 func (t *Player) SetVolume(guildID string, volume uint16) error {
@@ -161,11 +222,30 @@ func (t *Player) SetVolume(guildID string, volume uint16) error {
 		return ErrNoGuildPlayer
 	}
 
-	return t.ll.SetVolume(guildID, volume)
+	s := t.getState(guildID)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.volume = volume
+	s.volumeSet = true
+
+	// The volume of an entry which overrides the guild
+	// volume is kept until the entry has ended.
+	if s.trackID != "" && s.current.Volume > 0 {
+		return nil
+	}
+
+	if err := t.backend.SetVolume(guildID, volume); err != nil {
+		return err
+	}
+
+	s.appliedVolume = volume
+	return nil
 }
 
 func (t *Player) Close() error {
-	return t.ll.Close()
+	return t.backend.Close()
 }
 
 func (t *Player) HasPlayer(guildID string) bool {
@@ -175,26 +255,6 @@ func (t *Player) HasPlayer(guildID string) bool {
 
 // --- Internal stuff ---
 
-func (t *Player) handleGetFile(ctx *routing.Context) error {
-	id := ctx.Param("id")
-
-	r, _, err := t.st.GetObject(static.BucketSounds, id)
-	if err != nil {
-		return ctx.WriteWithStatus("", http.StatusBadRequest)
-	}
-	defer r.Close()
-
-	ctx.Response.WriteHeader(http.StatusOK)
-
-	_, err = io.Copy(ctx.Response, r)
-	if err != nil {
-		logrus.WithError(err).Error("Writing file to response body failed")
-		return ctx.WriteWithStatus("", http.StatusInternalServerError)
-	}
-
-	return nil
-}
-
 func (t *Player) handleVoiceUpdate(_ *discordgo.Session, e *discordgo.VoiceStateUpdate) {
 	if e.BeforeUpdate == nil && e.ChannelID != "" {
 		t.onVoiceJoin(e)
@@ -204,6 +264,7 @@ func (t *Player) handleVoiceUpdate(_ *discordgo.Session, e *discordgo.VoiceState
 		t.onVoiceLeave(e)
 	}
 
+	t.checkUserPresence(e)
 	t.checkFastTrigger(e)
 }
 
@@ -217,6 +278,9 @@ func (t *Player) initVs(guildID, channelID string) {
 		GuildID: guildID,
 	})
 	t.waiters.BroadcastAndRemove(guildID)
+
+	np, playing := t.NowPlaying(guildID)
+	t.setIdle(guildID, !playing || np.Paused)
 }
 
 func (t *Player) onVoiceJoin(e *discordgo.VoiceStateUpdate) {
@@ -254,7 +318,13 @@ func (t *Player) onVoiceMove(e *discordgo.VoiceStateUpdate) {
 func (t *Player) onVoiceLeave(e *discordgo.VoiceStateUpdate) {
 	if e.UserID == t.dc.Session().State.User.ID {
 		t.vcs.Delete(e.GuildID)
-		t.ll.Destroy(e.GuildID)
+		if q, ok := t.states.LoadAndDelete(e.GuildID); ok {
+			q.mtx.Lock()
+			q.cancelPending()
+			q.mtx.Unlock()
+		}
+		t.stopAutoLeave(e.GuildID)
+		t.backend.Destroy(e.GuildID)
 		t.Publish(Event{
 			Type:    EventVoiceLeave,
 			GuildID: e.GuildID,
@@ -264,6 +334,7 @@ func (t *Player) onVoiceLeave(e *discordgo.VoiceStateUpdate) {
 			WithField("chanID", e.ChannelID).
 			Debug("Voice state removed")
 	} else {
+		t.gestures.reset(e.GuildID, e.UserID)
 		t.Publish(Event{
 			Type:    EventVoiceDeinit,
 			GuildID: e.GuildID,
@@ -273,42 +344,43 @@ func (t *Player) onVoiceLeave(e *discordgo.VoiceStateUpdate) {
 	}
 }
 
-func (t *Player) autoLeave(e *discordgo.VoiceState) {
-	if t.autoLeaveTimer == nil {
-		vc, ok := t.vcs.Load(e.GuildID)
-		if !ok || vc.ChannelID != e.ChannelID {
-			return
-		}
-		vConns, err := t.getChannelVoiceConnections(e.GuildID, e.ChannelID)
-		if err != nil {
-			return
-		}
-		if vConns == 0 {
-			logrus.WithField("guildID", e.GuildID).Debug("Trigger autoleave timer")
-			t.autoLeaveTimer = time.AfterFunc(5*time.Second, func() {
-				t.dc.Session().ChannelVoiceJoinManual(e.GuildID, "", false, true)
-				t.autoLeaveTimer = nil
-			})
-		}
+// checkUserPresence publishes EventUserEnter or EventUserExit
+// when a user enters or leaves the voice channel the player
+// is connected to.
+func (t *Player) checkUserPresence(e *discordgo.VoiceStateUpdate) {
+	if e.UserID == t.dc.Session().State.User.ID {
+		return
 	}
-}
 
-func (t *Player) cancelAutoLeave(e *discordgo.VoiceState) {
-	if t.autoLeaveTimer != nil {
-		vc, ok := t.vcs.Load(e.GuildID)
-		if !ok || vc.ChannelID != e.ChannelID {
-			return
-		}
-		vConns, err := t.getChannelVoiceConnections(e.GuildID, e.ChannelID)
-		if err != nil {
-			return
-		}
-		if vConns != 0 {
-			logrus.WithField("guildID", e.GuildID).Debug("Clear autoleave timer")
-			t.autoLeaveTimer.Stop()
-			t.autoLeaveTimer = nil
-		}
+	vc, ok := t.vcs.Load(e.GuildID)
+	if !ok {
+		return
+	}
+
+	var beforeChannelID string
+	if e.BeforeUpdate != nil {
+		beforeChannelID = e.BeforeUpdate.ChannelID
+	}
+	if beforeChannelID == e.ChannelID {
+		return
 	}
+
+	var typ EventType
+	switch vc.ChannelID {
+	case e.ChannelID:
+		typ = EventUserEnter
+	case beforeChannelID:
+		typ = EventUserExit
+	default:
+		return
+	}
+
+	t.Publish(Event{
+		Type:      typ,
+		GuildID:   e.GuildID,
+		ChannelID: vc.ChannelID,
+		UserID:    e.UserID,
+	})
 }
 
 func (t *Player) checkFastTrigger(e *discordgo.VoiceStateUpdate) {
@@ -316,18 +388,16 @@ func (t *Player) checkFastTrigger(e *discordgo.VoiceStateUpdate) {
 		return
 	}
 
-	if e.BeforeUpdate != nil && !e.BeforeUpdate.SelfMute && e.SelfMute {
-		time.AfterFunc(1500*time.Millisecond, func() {
-			vs, ok := t.dc.FindUserVS(e.UserID)
-			if ok && !vs.SelfMute {
-				t.Publish(Event{
-					Type:    EventFastTrigger,
-					GuildID: vs.GuildID,
-					UserID:  vs.UserID,
-				})
-			}
-		})
-	}
+	t.gestures.update(e.BeforeUpdate, e.VoiceState, time.Now())
+}
+
+func (t *Player) onGesture(guildID, userID string, g Gesture) {
+	t.Publish(Event{
+		Type:    EventFastTrigger,
+		GuildID: guildID,
+		UserID:  userID,
+		Gesture: g,
+	})
 }
 
 func (t *Player) getChannelVoiceConnections(guildID, channelID string) (int, error) {
@@ -347,67 +417,70 @@ func (t *Player) getChannelVoiceConnections(guildID, channelID string) (int, err
 	return n, nil
 }
 
-func (t *Player) getIdentFromCache(trackID string) string {
-	info, err := t.ll.DecodeTrackId(trackID)
-	if err != nil {
-		logrus.WithError(err).
-			WithField("trackID", trackID).
-			Error("Failed decoding track id")
-		return ""
-	}
-
-	return t.trackCache.GetValue(info.URI)
-}
-
 func (t *Player) handleEvent(e any) {
 	switch et := e.(type) {
-	// case event.PlayerUpdate:
-	// case event.Stats:
+	case lavalink.PlayerUpdateEvent:
+		t.onPlayerUpdate(et.GuildID, et.Position)
 
-	case event.WebSocketClosed:
-		logrus.Warn("Lavalink websokcet connection closed. Trying reconnect ...")
-		// TODO: Try reconnect
+	case lavalink.ReconnectedEvent:
+		if !et.Resumed {
+			t.recoverPlayers()
+		}
 
-	case event.TrackException:
-		ident := t.getIdentFromCache(et.TrackID)
+	case lavalink.WebSocketClosedEvent:
+		logrus.
+			WithField("guildID", et.GuildID).
+			WithField("code", et.Code).
+			WithField("reason", et.Reason).
+			WithField("byRemote", et.ByRemote).
+			Warn("Voice websocket connection closed")
+
+	case lavalink.TrackExceptionEvent:
+		ident := t.backend.TrackIdent(et.TrackID)
 		if ident == "" {
 			return
 		}
 		t.Publish(Event{
 			Type:    EventPlayException,
 			Ident:   ident,
-			GuildID: et.GuildID.String(),
+			GuildID: et.GuildID,
 			Err:     errors.New(et.Error),
 		})
-	case event.TrackStuck:
-		ident := t.getIdentFromCache(et.TrackID)
+	case lavalink.TrackStuckEvent:
+		ident := t.backend.TrackIdent(et.TrackID)
 		if ident == "" {
 			return
 		}
 		t.Publish(Event{
 			Type:    EventPlayStuck,
 			Ident:   ident,
-			GuildID: et.GuildID.String(),
+			GuildID: et.GuildID,
 		})
-	case event.TrackStart:
-		ident := t.getIdentFromCache(et.TrackID)
+	case lavalink.TrackStartEvent:
+		ident := t.backend.TrackIdent(et.TrackID)
 		if ident == "" {
 			return
 		}
 		t.Publish(Event{
 			Type:    EventPlayStart,
 			Ident:   ident,
-			GuildID: et.GuildID.String(),
+			GuildID: et.GuildID,
 		})
-	case event.TrackEnd:
-		ident := t.getIdentFromCache(et.TrackID)
+	case lavalink.TrackEndEvent:
+		// Replaced tracks must not advance the queue because
+		// the replacing track might already be reported as
+		// the current one.
+		if !strings.EqualFold(et.Reason, "replaced") {
+			t.onTrackEnd(et.GuildID, et.TrackID)
+		}
+		ident := t.backend.TrackIdent(et.TrackID)
 		if ident == "" {
 			return
 		}
 		t.Publish(Event{
 			Type:    EventPlayEnd,
 			Ident:   ident,
-			GuildID: et.GuildID.String(),
+			GuildID: et.GuildID,
 		})
 	}
 }
@@ -0,0 +1,336 @@
+package player
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/models"
+)
+
+type queueItem struct {
+	models.QueueEntry
+
+	url string
+}
+
+func (t *guildState) entries() []models.QueueEntry {
+	entries := make([]models.QueueEntry, 0, len(t.items))
+	for _, item := range t.items {
+		entries = append(entries, item.QueueEntry)
+	}
+	return entries
+}
+
+func (t *guildState) indexOf(id string) int {
+	for i, item := range t.items {
+		if item.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Enqueue adds the given entry to the queue of the guild. If currently
+// nothing is played in the guild, the entry is played immediately.
+//
+// When url is empty, the entries ident is played as stored sound.
+func (t *Player) Enqueue(guildID string, entry models.QueueEntry, url string) error {
+	if !t.HasPlayer(guildID) {
+		return ErrNoGuildPlayer
+	}
+
+	item := queueItem{QueueEntry: entry, url: url}
+	q := t.getState(guildID)
+
+	q.mtx.Lock()
+	if !q.playing {
+		q.playing = true
+		q.mtx.Unlock()
+		return t.startItem(guildID, item)
+	}
+	q.items = append(q.items, item)
+	q.mtx.Unlock()
+
+	t.publishQueueUpdate(guildID)
+	return nil
+}
+
+// EnqueueAll adds the given entries of stored sounds to the
+// queue of the guild. If currently nothing is played in the
+// guild, the first entry is played immediately.
+func (t *Player) EnqueueAll(guildID string, entries []models.QueueEntry) error {
+	if !t.HasPlayer(guildID) {
+		return ErrNoGuildPlayer
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	items := make([]queueItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, queueItem{QueueEntry: entry})
+	}
+
+	q := t.getState(guildID)
+
+	q.mtx.Lock()
+	if q.playing {
+		q.items = append(q.items, items...)
+		q.mtx.Unlock()
+		t.publishQueueUpdate(guildID)
+		return nil
+	}
+	q.playing = true
+	q.items = append(q.items, items[1:]...)
+	q.mtx.Unlock()
+
+	if len(items) > 1 {
+		t.publishQueueUpdate(guildID)
+	}
+
+	return t.startItem(guildID, items[0])
+}
+
+// Queue returns the entries currently waiting in the queue of the guild.
+func (t *Player) Queue(guildID string) []models.QueueEntry {
+	q, ok := t.states.Load(guildID)
+	if !ok {
+		return []models.QueueEntry{}
+	}
+
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	return q.entries()
+}
+
+// MoveQueueEntry moves the entry with the given ID to the given position
+// in the queue. Positions out of range are clamped to the bounds of the queue.
+func (t *Player) MoveQueueEntry(guildID, id string, position int) error {
+	q, ok := t.states.Load(guildID)
+	if !ok {
+		return ErrNoGuildPlayer
+	}
+
+	q.mtx.Lock()
+	i := q.indexOf(id)
+	if i == -1 {
+		q.mtx.Unlock()
+		return ErrQueueEntryNotFound
+	}
+
+	item := q.items[i]
+	q.items = append(q.items[:i], q.items[i+1:]...)
+
+	if position < 0 {
+		position = 0
+	}
+	if position > len(q.items) {
+		position = len(q.items)
+	}
+
+	q.items = append(q.items[:position], append([]queueItem{item}, q.items[position:]...)...)
+	q.mtx.Unlock()
+
+	t.publishQueueUpdate(guildID)
+	return nil
+}
+
+// RemoveQueueEntry removes the entry with the given ID from the queue.
+func (t *Player) RemoveQueueEntry(guildID, id string) error {
+	q, ok := t.states.Load(guildID)
+	if !ok {
+		return ErrNoGuildPlayer
+	}
+
+	q.mtx.Lock()
+	i := q.indexOf(id)
+	if i == -1 {
+		q.mtx.Unlock()
+		return ErrQueueEntryNotFound
+	}
+	q.items = append(q.items[:i], q.items[i+1:]...)
+	q.mtx.Unlock()
+
+	t.publishQueueUpdate(guildID)
+	return nil
+}
+
+// ClearQueue removes all waiting entries from the queue. The
+// currently playing sound is not affected.
+func (t *Player) ClearQueue(guildID string) error {
+	q, ok := t.states.Load(guildID)
+	if !ok {
+		return ErrNoGuildPlayer
+	}
+
+	q.mtx.Lock()
+	q.items = nil
+	q.mtx.Unlock()
+
+	t.publishQueueUpdate(guildID)
+	return nil
+}
+
+// Skip stops the currently playing sound so that the next entry
+// in the queue is played.
+func (t *Player) Skip(guildID string) error {
+	if !t.HasPlayer(guildID) {
+		return ErrNoGuildPlayer
+	}
+
+	q := t.getState(guildID)
+
+	q.mtx.Lock()
+	playing := q.playing
+	pending := q.cancelPending()
+	q.mtx.Unlock()
+
+	if !playing || pending {
+		t.playNext(guildID)
+		return nil
+	}
+
+	return t.backend.Stop(guildID)
+}
+
+// --- Internal stuff ---
+
+// startItem plays the given item after its delay has passed.
+// While waiting, the guild is considered to be playing, so
+// that other entries are queued after the item.
+func (t *Player) startItem(guildID string, item queueItem) error {
+	if item.Delay <= 0 {
+		return t.playItem(guildID, item)
+	}
+
+	q := t.getState(guildID)
+
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	q.cancelPending()
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Duration(item.Delay)*time.Millisecond, func() {
+		q.mtx.Lock()
+		current := q.pending == timer
+		if current {
+			q.pending = nil
+		}
+		q.mtx.Unlock()
+
+		if !current {
+			return
+		}
+
+		if err := t.playItem(guildID, item); err != nil {
+			logrus.
+				WithError(err).
+				WithField("guildID", guildID).
+				WithField("ident", item.Ident).
+				Error("Playing delayed sound failed")
+			t.playNext(guildID)
+		}
+	})
+	q.pending = timer
+
+	return nil
+}
+
+func (t *Player) playItem(guildID string, item queueItem) error {
+	q := t.getState(guildID)
+
+	// Lavalink keeps the paused state of the player across
+	// tracks, so a paused player must be resumed first.
+	q.mtx.Lock()
+	q.cancelPending()
+	paused := q.paused
+	q.mtx.Unlock()
+	if paused {
+		if err := t.backend.SetPaused(guildID, false); err != nil {
+			return err
+		}
+	}
+
+	if err := t.applyEffects(guildID, item.Effects); err != nil {
+		return err
+	}
+
+	if err := t.applyVolume(guildID, item.QueueEntry); err != nil {
+		return err
+	}
+
+	tr, err := t.backend.Play(guildID, item.Ident, item.url)
+
+	q.mtx.Lock()
+	if err != nil {
+		q.resetCurrent()
+	} else {
+		q.setCurrent(item.QueueEntry, tr)
+	}
+	q.mtx.Unlock()
+
+	t.setIdle(guildID, err != nil)
+	return err
+}
+
+func (t *Player) playNext(guildID string) {
+	q, ok := t.states.Load(guildID)
+	if !ok {
+		return
+	}
+
+	for {
+		q.mtx.Lock()
+		if len(q.items) == 0 {
+			q.resetCurrent()
+			q.mtx.Unlock()
+			t.setIdle(guildID, true)
+			return
+		}
+		item := q.items[0]
+		q.items = q.items[1:]
+		q.playing = true
+		q.mtx.Unlock()
+
+		t.publishQueueUpdate(guildID)
+
+		err := t.startItem(guildID, item)
+		if err == nil {
+			return
+		}
+
+		logrus.
+			WithError(err).
+			WithField("guildID", guildID).
+			WithField("ident", item.Ident).
+			Error("Playing queued sound failed")
+	}
+}
+
+// onTrackEnd advances the queue of the guild when the
+// ended track is the one which was played last. Tracks
+// which have been replaced by another one are ignored.
+func (t *Player) onTrackEnd(guildID, trackID string) {
+	q, ok := t.states.Load(guildID)
+	if !ok {
+		return
+	}
+
+	q.mtx.Lock()
+	isCurrent := q.trackID == trackID
+	q.mtx.Unlock()
+
+	if isCurrent {
+		t.playNext(guildID)
+	}
+}
+
+func (t *Player) publishQueueUpdate(guildID string) {
+	t.Publish(Event{
+		Type:    EventQueueUpdated,
+		GuildID: guildID,
+	})
+}
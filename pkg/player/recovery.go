@@ -0,0 +1,71 @@
+package player
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/database/dberrors"
+)
+
+const defaultVolume = 50
+
+// recoverPlayers restores the Lavalink players of all guilds
+// the bot is currently connected to after the Lavalink session
+// has been lost. Therefore, the last voice server update is
+// replayed and the volume is restored.
+func (t *Player) recoverPlayers() {
+	t.vcs.Range(func(guildID string, _ voiceConnection) bool {
+		err := t.recoverPlayer(guildID)
+		if err != nil {
+			logrus.
+				WithError(err).
+				WithField("guildID", guildID).
+				Error("Recovering player failed")
+			t.Publish(Event{
+				Type:    EventError,
+				GuildID: guildID,
+				Err:     err,
+			})
+			return true
+		}
+
+		logrus.WithField("guildID", guildID).Info("Player recovered")
+		t.Publish(Event{
+			Type:    EventPlayerRecovered,
+			GuildID: guildID,
+		})
+		return true
+	})
+}
+
+func (t *Player) recoverPlayer(guildID string) error {
+	if err := t.backend.RestorePlayer(guildID); err != nil {
+		return err
+	}
+
+	s := t.getState(guildID)
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// The backend restores the last applied volume, which
+	// might be the volume of the current queue entry. The
+	// guild volume is only restored when no volume has been
+	// applied yet.
+	if s.appliedVolume != 0 {
+		return nil
+	}
+
+	volume, err := t.db.GetGuildVolume(guildID)
+	if err == dberrors.ErrNotFound {
+		err = nil
+		volume = defaultVolume
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = t.backend.SetVolume(guildID, uint16(volume)); err != nil {
+		return err
+	}
+
+	s.appliedVolume = uint16(volume)
+	return nil
+}
@@ -0,0 +1,242 @@
+package player
+
+import (
+	"sync"
+	"time"
+
+	"github.com/zekrotja/yuri69/pkg/lavalink"
+	"github.com/zekrotja/yuri69/pkg/models"
+)
+
+type guildState struct {
+	mtx sync.Mutex
+
+	items   []queueItem
+	playing bool
+	trackID string
+	pending *time.Timer
+
+	volume        uint16
+	volumeSet     bool
+	appliedVolume uint16
+
+	current        models.QueueEntry
+	started        time.Time
+	length         time.Duration
+	seekable       bool
+	paused         bool
+	filtered       bool
+	position       time.Duration
+	positionUpdate time.Time
+}
+
+func (t *guildState) setCurrent(entry models.QueueEntry, tr lavalink.Track) {
+	now := time.Now()
+	t.playing = true
+	t.trackID = tr.ID
+	t.current = entry
+	t.started = now
+	t.length = tr.Info.Length
+	t.seekable = tr.Info.Seekable && !tr.Info.Stream
+	t.paused = false
+	t.position = 0
+	t.positionUpdate = now
+}
+
+// resetCurrent clears the currently played track. The paused
+// flag is kept because Lavalink retains the paused state of
+// the player even when no track is played.
+func (t *guildState) resetCurrent() {
+	t.playing = false
+	t.trackID = ""
+	t.current = models.QueueEntry{}
+	t.length = 0
+	t.seekable = false
+	t.position = 0
+}
+
+// cancelPending stops the timer of an entry which waits for
+// its delay to pass. When no entry was waiting, false is
+// returned.
+func (t *guildState) cancelPending() bool {
+	if t.pending == nil {
+		return false
+	}
+
+	t.pending.Stop()
+	t.pending = nil
+	return true
+}
+
+func (t *guildState) currentPosition() time.Duration {
+	position := t.position
+	if !t.paused {
+		position += time.Since(t.positionUpdate)
+	}
+	if t.length > 0 && position > t.length {
+		position = t.length
+	}
+	return position
+}
+
+func (t *guildState) setPosition(position time.Duration) {
+	t.position = position
+	t.positionUpdate = time.Now()
+}
+
+func (t *guildState) nowPlaying() (models.NowPlaying, bool) {
+	if !t.playing || t.trackID == "" {
+		return models.NowPlaying{}, false
+	}
+
+	return models.NowPlaying{
+		Ident:    t.current.Ident,
+		UserID:   t.current.UserID,
+		Started:  t.started,
+		Position: t.currentPosition().Milliseconds(),
+		Length:   t.length.Milliseconds(),
+		Paused:   t.paused,
+		Effects:  t.current.Effects,
+	}, true
+}
+
+// NowPlaying returns the sound which is currently played in the
+// given guild with its current playback position. If nothing is
+// played, false is returned.
+func (t *Player) NowPlaying(guildID string) (models.NowPlaying, bool) {
+	s, ok := t.states.Load(guildID)
+	if !ok {
+		return models.NowPlaying{}, false
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.nowPlaying()
+}
+
+// Pause pauses the playback in the given guild.
+func (t *Player) Pause(guildID string) error {
+	return t.setPaused(guildID, true)
+}
+
+// Resume resumes a previously paused playback in the given guild.
+func (t *Player) Resume(guildID string) error {
+	return t.setPaused(guildID, false)
+}
+
+// Seek sets the playback position of the currently played
+// sound in the given guild.
+func (t *Player) Seek(guildID string, position time.Duration) error {
+	if !t.HasPlayer(guildID) {
+		return ErrNoGuildPlayer
+	}
+
+	s := t.getState(guildID)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.playing {
+		return ErrNotPlaying
+	}
+	if !s.seekable {
+		return ErrNotSeekable
+	}
+	if position < 0 || position > s.length {
+		return ErrSeekOutOfRange
+	}
+
+	if err := t.backend.Seek(guildID, position); err != nil {
+		return err
+	}
+
+	s.setPosition(position)
+	return nil
+}
+
+// --- Internal stuff ---
+
+func (t *Player) setPaused(guildID string, paused bool) error {
+	if !t.HasPlayer(guildID) {
+		return ErrNoGuildPlayer
+	}
+
+	s := t.getState(guildID)
+
+	s.mtx.Lock()
+
+	if !s.playing {
+		s.mtx.Unlock()
+		return ErrNotPlaying
+	}
+	if s.paused == paused {
+		s.mtx.Unlock()
+		return nil
+	}
+
+	if err := t.backend.SetPaused(guildID, paused); err != nil {
+		s.mtx.Unlock()
+		return err
+	}
+
+	s.setPosition(s.currentPosition())
+	s.paused = paused
+	s.mtx.Unlock()
+
+	// The idle timeout is read from the database, so the
+	// timer is set after the state has been unlocked.
+	t.setIdle(guildID, paused)
+	return nil
+}
+
+// applyVolume sets the volume of the entry on the guild
+// player. Entries without a volume are played with the
+// volume set via SetVolume.
+func (t *Player) applyVolume(guildID string, entry models.QueueEntry) error {
+	s := t.getState(guildID)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	volume := s.volume
+	if entry.Volume > 0 {
+		volume = uint16(entry.Volume)
+	} else if !s.volumeSet {
+		return nil
+	}
+
+	if volume == s.appliedVolume {
+		return nil
+	}
+
+	if err := t.backend.SetVolume(guildID, volume); err != nil {
+		return err
+	}
+
+	s.appliedVolume = volume
+	return nil
+}
+
+func (t *Player) getState(guildID string) *guildState {
+	s, _ := t.states.LoadOrStore(guildID, &guildState{})
+	return s
+}
+
+// onPlayerUpdate synchronizes the tracked playback position
+// with the position reported by Lavalink.
+func (t *Player) onPlayerUpdate(guildID string, position time.Duration) {
+	s, ok := t.states.Load(guildID)
+	if !ok {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.playing {
+		return
+	}
+
+	s.setPosition(position)
+}
@@ -0,0 +1,131 @@
+// Package presets composes ffmpeg audio filter chains from
+// named processing presets which are applied when sounds
+// are created.
+package presets
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zekrotja/yuri69/pkg/errs"
+)
+
+var (
+	ErrInvalidPreset = errors.New("invalid preset")
+)
+
+var nameRx = regexp.MustCompile(`^[a-z0-9_-]{1,30}$`)
+
+// allowedFilters contains the ffmpeg audio filters which
+// can be used in presets. Filters which read or write files,
+// like amovie, are not allowed.
+var allowedFilters = map[string]struct{}{
+	"acompressor":   {},
+	"acrusher":      {},
+	"adelay":        {},
+	"aecho":         {},
+	"aemphasis":     {},
+	"afade":         {},
+	"aformat":       {},
+	"alimiter":      {},
+	"apad":          {},
+	"aphaser":       {},
+	"apulsator":     {},
+	"aresample":     {},
+	"areverse":      {},
+	"asetrate":      {},
+	"atempo":        {},
+	"atrim":         {},
+	"bass":          {},
+	"chorus":        {},
+	"dynaudnorm":    {},
+	"equalizer":     {},
+	"extrastereo":   {},
+	"flanger":       {},
+	"highpass":      {},
+	"loudnorm":      {},
+	"lowpass":       {},
+	"silenceremove": {},
+	"stereotools":   {},
+	"treble":        {},
+	"tremolo":       {},
+	"vibrato":       {},
+	"volume":        {},
+}
+
+// PresetsConfig maps preset names to ffmpeg audio filter
+// chains, e.g. "reverse" to "areverse".
+type PresetsConfig map[string]string
+
+// Presets holds the validated processing presets.
+type Presets struct {
+	filters map[string]string
+}
+
+// New validates the presets of the given config.
+func New(c PresetsConfig) (*Presets, error) {
+	t := Presets{
+		filters: make(map[string]string, len(c)),
+	}
+
+	for name, filter := range c {
+		name = strings.ToLower(name)
+		if !nameRx.MatchString(name) {
+			return nil, fmt.Errorf("%w: invalid name '%s'", ErrInvalidPreset, name)
+		}
+		if err := checkFilter(filter); err != nil {
+			return nil, fmt.Errorf("%w '%s': %s", ErrInvalidPreset, name, err.Error())
+		}
+		t.filters[name] = filter
+	}
+
+	return &t, nil
+}
+
+// Names returns the sorted names of all presets.
+func (t *Presets) Names() []string {
+	names := make([]string, 0, len(t.filters))
+	for name := range t.filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Filter returns the filter chain which applies the presets
+// with the given names in order.
+func (t *Presets) Filter(names ...string) (string, error) {
+	filters := make([]string, 0, len(names))
+	for _, name := range names {
+		filter, ok := t.filters[strings.ToLower(name)]
+		if !ok {
+			return "", errs.WrapUserError(fmt.Sprintf("preset '%s' does not exist", name))
+		}
+		filters = append(filters, filter)
+	}
+	return strings.Join(filters, ","), nil
+}
+
+// checkFilter checks that the filter is a simple chain of
+// allowed filters. Filter graphs with multiple in- or
+// outputs are not supported.
+func checkFilter(filter string) error {
+	if strings.TrimSpace(filter) == "" {
+		return errors.New("filter is empty")
+	}
+	if strings.ContainsAny(filter, ";[]") {
+		return errors.New("filter must be a simple filter chain")
+	}
+
+	for _, f := range strings.Split(filter, ",") {
+		name, _, _ := strings.Cut(strings.TrimSpace(f), "=")
+		if _, ok := allowedFilters[name]; !ok {
+			return fmt.Errorf("filter '%s' is not allowed", name)
+		}
+	}
+
+	return nil
+}
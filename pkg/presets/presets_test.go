@@ -0,0 +1,47 @@
+package presets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	p, err := New(PresetsConfig{
+		"Reverse": "areverse",
+		"fadeout": "areverse,afade=t=in:d=0.5,areverse",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"fadeout", "reverse"}, p.Names())
+
+	_, err = New(PresetsConfig{"read": "amovie=/etc/passwd"})
+	assert.ErrorIs(t, err, ErrInvalidPreset)
+
+	_, err = New(PresetsConfig{"graph": "asplit[a][b];[a][b]amix"})
+	assert.ErrorIs(t, err, ErrInvalidPreset)
+
+	_, err = New(PresetsConfig{"empty": " "})
+	assert.ErrorIs(t, err, ErrInvalidPreset)
+
+	_, err = New(PresetsConfig{"no spaces": "areverse"})
+	assert.ErrorIs(t, err, ErrInvalidPreset)
+}
+
+func TestFilter(t *testing.T) {
+	p, err := New(PresetsConfig{
+		"reverse": "areverse",
+		"speed":   "atempo=1.5",
+	})
+	assert.Nil(t, err)
+
+	f, err := p.Filter()
+	assert.Nil(t, err)
+	assert.Equal(t, "", f)
+
+	f, err = p.Filter("speed", "Reverse")
+	assert.Nil(t, err)
+	assert.Equal(t, "atempo=1.5,areverse", f)
+
+	_, err = p.Filter("reverse", "nope")
+	assert.NotNil(t, err)
+}
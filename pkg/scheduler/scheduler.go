@@ -0,0 +1,177 @@
+// Package scheduler runs the schedules stored in the
+// database when they are due.
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/cron"
+	"github.com/zekrotja/yuri69/pkg/database"
+	"github.com/zekrotja/yuri69/pkg/database/dberrors"
+	"github.com/zekrotja/yuri69/pkg/models"
+)
+
+// missedGracePeriod is the time after which a one-time
+// schedule is no more executed when it has been missed,
+// for example because the service was not running.
+const missedGracePeriod = time.Minute
+
+// Runner executes a due schedule.
+type Runner func(s models.Schedule) error
+
+// Scheduler checks the stored schedules at the start of
+// every minute and executes the due ones via its runner.
+type Scheduler struct {
+	db  database.IDatabase
+	run Runner
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(db database.IDatabase, run Runner) *Scheduler {
+	return &Scheduler{
+		db:   db,
+		run:  run,
+		stop: make(chan struct{}),
+	}
+}
+
+// Start starts the scheduler loop in a new goroutine.
+func (t *Scheduler) Start() {
+	t.wg.Add(1)
+	go t.loop()
+}
+
+// Close stops the scheduler loop and waits until the
+// currently executed schedules have finished.
+func (t *Scheduler) Close() error {
+	close(t.stop)
+	t.wg.Wait()
+	return nil
+}
+
+// NextRun returns the next time the schedule is executed
+// after the given time. When the schedule is disabled or
+// will never be executed again, false is returned.
+func NextRun(s models.Schedule, after time.Time) (time.Time, bool) {
+	if !s.Enabled {
+		return time.Time{}, false
+	}
+
+	if s.At != nil {
+		if s.LastRun != nil || after.Sub(*s.At) > missedGracePeriod {
+			return time.Time{}, false
+		}
+		return *s.At, true
+	}
+
+	expr, err := cron.Parse(s.Cron)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	loc, err := s.Location()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return expr.Next(after.In(loc))
+}
+
+// --- Internal stuff ---
+
+func (t *Scheduler) loop() {
+	defer t.wg.Done()
+
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+
+		select {
+		case <-t.stop:
+			timer.Stop()
+			return
+		case now = <-timer.C:
+			t.tick(now.Truncate(time.Minute))
+		}
+	}
+}
+
+func (t *Scheduler) tick(now time.Time) {
+	schedules, err := t.db.GetSchedules()
+	if err != nil && err != dberrors.ErrNotFound {
+		logrus.WithError(err).Error("Getting schedules failed")
+		return
+	}
+
+	for _, s := range schedules {
+		if !isDue(s, now) {
+			continue
+		}
+
+		t.wg.Add(1)
+		go func(s models.Schedule) {
+			defer t.wg.Done()
+			t.execute(s, now)
+		}(s)
+	}
+}
+
+func (t *Scheduler) execute(s models.Schedule, now time.Time) {
+	log := logrus.WithFields(logrus.Fields{
+		"id":      s.Id,
+		"guildid": s.GuildID,
+		"ident":   s.Ident,
+	})
+
+	if s.At != nil && now.Sub(*s.At) > missedGracePeriod {
+		log.Warn("Schedule has been missed")
+	} else {
+		log.Debug("Executing schedule")
+		if err := t.run(s); err != nil {
+			log.WithError(err).Error("Executing schedule failed")
+		}
+	}
+
+	// Only the last run is updated, so that the schedule is
+	// neither recreated nor overwritten when it has been
+	// removed or changed while it was played.
+	err := t.db.SetScheduleRun(s.Id, now, s.At != nil)
+	if err == dberrors.ErrNotFound {
+		log.Debug("Schedule has been removed during execution")
+	} else if err != nil {
+		log.WithError(err).Error("Updating schedule failed")
+	}
+}
+
+// isDue returns true when the schedule must be executed at
+// the given minute. Missed one-time schedules are due as
+// well, so that they are disabled after the next check.
+func isDue(s models.Schedule, now time.Time) bool {
+	if !s.Enabled {
+		return false
+	}
+
+	if s.LastRun != nil && !s.LastRun.Before(now) {
+		return false
+	}
+
+	if s.At != nil {
+		return s.LastRun == nil && !s.At.After(now)
+	}
+
+	expr, err := cron.Parse(s.Cron)
+	if err != nil {
+		return false
+	}
+
+	loc, err := s.Location()
+	if err != nil {
+		return false
+	}
+
+	return expr.Matches(now.In(loc))
+}
@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zekrotja/yuri69/pkg/database"
+	"github.com/zekrotja/yuri69/pkg/database/dberrors"
+	"github.com/zekrotja/yuri69/pkg/models"
+)
+
+func TestIsDueCron(t *testing.T) {
+	s := models.Schedule{
+		Cron:     "0 12 * * *",
+		Timezone: "UTC",
+		Enabled:  true,
+	}
+
+	noon := time.Date(2023, 5, 17, 12, 0, 0, 0, time.UTC)
+	assert.True(t, isDue(s, noon))
+	assert.False(t, isDue(s, noon.Add(time.Minute)))
+
+	s.LastRun = &noon
+	assert.False(t, isDue(s, noon))
+
+	s.LastRun = nil
+	s.Enabled = false
+	assert.False(t, isDue(s, noon))
+
+	s.Enabled = true
+	s.Timezone = "Europe/Berlin"
+	assert.False(t, isDue(s, noon))
+	assert.True(t, isDue(s, noon.Add(-2*time.Hour)))
+}
+
+func TestIsDueAt(t *testing.T) {
+	at := time.Date(2023, 5, 17, 12, 0, 0, 0, time.UTC)
+	s := models.Schedule{
+		At:      &at,
+		Enabled: true,
+	}
+
+	assert.False(t, isDue(s, at.Add(-time.Minute)))
+	assert.True(t, isDue(s, at))
+	assert.True(t, isDue(s, at.Add(time.Hour)))
+
+	s.LastRun = &at
+	assert.False(t, isDue(s, at.Add(time.Minute)))
+}
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2023, 5, 17, 11, 30, 0, 0, time.UTC)
+
+	next, ok := NextRun(models.Schedule{
+		Cron:     "0 12 * * *",
+		Timezone: "UTC",
+		Enabled:  true,
+	}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2023, 5, 17, 12, 0, 0, 0, time.UTC), next)
+
+	_, ok = NextRun(models.Schedule{Cron: "0 12 * * *"}, now)
+	assert.False(t, ok)
+
+	at := now.Add(time.Hour)
+	next, ok = NextRun(models.Schedule{At: &at, Enabled: true}, now)
+	assert.True(t, ok)
+	assert.Equal(t, at, next)
+
+	at = now.Add(-time.Hour)
+	_, ok = NextRun(models.Schedule{At: &at, Enabled: true}, now)
+	assert.False(t, ok)
+}
+
+type fakeDatabase struct {
+	database.IDatabase
+
+	mtx       sync.Mutex
+	schedules map[string]models.Schedule
+}
+
+func (t *fakeDatabase) PutSchedule(s models.Schedule) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.schedules[s.Id] = s
+	return nil
+}
+
+func (t *fakeDatabase) RemoveSchedule(id string) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	delete(t.schedules, id)
+	return nil
+}
+
+func (t *fakeDatabase) SetScheduleRun(id string, lastRun time.Time, disable bool) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	s, ok := t.schedules[id]
+	if !ok {
+		return dberrors.ErrNotFound
+	}
+	s.LastRun = &lastRun
+	if disable {
+		s.Enabled = false
+	}
+	t.schedules[id] = s
+	return nil
+}
+
+func TestExecuteRemovedDuringRun(t *testing.T) {
+	at := time.Date(2023, 5, 17, 12, 0, 0, 0, time.UTC)
+	s := models.Schedule{Id: "s1", At: &at, Enabled: true}
+	db := &fakeDatabase{schedules: map[string]models.Schedule{s.Id: s}}
+
+	sc := New(db, func(s models.Schedule) error {
+		return db.RemoveSchedule(s.Id)
+	})
+	sc.execute(s, at)
+
+	assert.Empty(t, db.schedules)
+}
+
+func TestExecuteChangedDuringRun(t *testing.T) {
+	now := time.Date(2023, 5, 17, 12, 0, 0, 0, time.UTC)
+	s := models.Schedule{Id: "s1", Ident: "foo", Cron: "0 12 * * *", Enabled: true}
+	db := &fakeDatabase{schedules: map[string]models.Schedule{s.Id: s}}
+
+	sc := New(db, func(s models.Schedule) error {
+		s.Ident = "bar"
+		s.Cron = "0 13 * * *"
+		s.Enabled = false
+		return db.PutSchedule(s)
+	})
+	sc.execute(s, now)
+
+	res := db.schedules[s.Id]
+	assert.Equal(t, "bar", res.Ident)
+	assert.Equal(t, "0 13 * * *", res.Cron)
+	assert.False(t, res.Enabled)
+	if assert.NotNil(t, res.LastRun) {
+		assert.Equal(t, now, *res.LastRun)
+	}
+}
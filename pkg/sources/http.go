@@ -0,0 +1,126 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zekrotja/yuri69/pkg/errs"
+)
+
+var errForbiddenAddress = errors.New("connections to private networks are not allowed")
+
+type HTTPConfig struct {
+	// AllowPrivateNetworks allows fetching audio from
+	// loopback and private network addresses.
+	AllowPrivateNetworks bool
+	// Timeout is the maximum time for establishing the
+	// connection and receiving the response headers.
+	Timeout time.Duration
+}
+
+// HTTP fetches audio files from direct HTTP(S) URLs.
+type HTTP struct {
+	client *http.Client
+}
+
+var _ Provider = (*HTTP)(nil)
+
+func NewHTTP(c HTTPConfig) *HTTP {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+	}
+	if !c.AllowPrivateNetworks {
+		// The address is checked after the name has been
+		// resolved, so that hosts can not resolve to private
+		// addresses to bypass the check.
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicIP(net.ParseIP(host)) {
+				return errForbiddenAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	if c.Timeout > 0 {
+		transport.ResponseHeaderTimeout = c.Timeout
+	}
+
+	return &HTTP{
+		client: &http.Client{Transport: transport},
+	}
+}
+
+func (t *HTTP) Name() string {
+	return ProviderHTTP
+}
+
+func (t *HTTP) Supports(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+func (t *HTTP) Fetch(ctx context.Context, u *url.URL, maxSize int64) (Audio, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Audio{}, err
+	}
+
+	res, err := t.client.Do(req)
+	if errors.Is(err, errForbiddenAddress) {
+		return Audio{}, errs.WrapUserError(errForbiddenAddress)
+	}
+	if err != nil {
+		return Audio{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return Audio{}, errs.WrapUserError(
+			fmt.Sprintf("source URL responded with status %d", res.StatusCode))
+	}
+
+	contentType := strings.ToLower(res.Header.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "text/") {
+		res.Body.Close()
+		return Audio{}, errs.WrapUserError("source URL does not point to an audio file")
+	}
+
+	if maxSize > 0 && res.ContentLength > maxSize {
+		res.Body.Close()
+		return Audio{}, errs.WrapUserError("the file exceeds the maximum upload size")
+	}
+
+	size := res.ContentLength
+	if size < 0 {
+		size = 0
+	}
+
+	return Audio{
+		ReadCloser: res.Body,
+		Size:       size,
+	}, nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return ip != nil &&
+		!ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast()
+}
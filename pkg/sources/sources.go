@@ -0,0 +1,156 @@
+// Package sources fetches the audio of sounds from remote
+// sources like YouTube videos or audio files on the web.
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/zekrotja/yuri69/pkg/errs"
+)
+
+const (
+	ProviderYouTube = "youtube"
+	ProviderHTTP    = "http"
+	ProviderYtDlp   = "ytdlp"
+)
+
+var (
+	ErrUnsupportedProvider = errors.New("unsupported source provider")
+)
+
+type SourcesConfig struct {
+	// Providers are the names of the enabled providers. When
+	// no provider is specified in a request, the first
+	// provider supporting the URL is used in this order.
+	// yt-dlp is only used when it is requested by name.
+	Providers []string
+	// HTTP configures the provider for direct audio URLs.
+	HTTP HTTPConfig
+	// YtDlp configures the provider using yt-dlp.
+	YtDlp YtDlpConfig
+}
+
+// Provider fetches the audio of a source URL.
+type Provider interface {
+	// Name returns the name of the provider.
+	Name() string
+
+	// Supports returns true when the provider can fetch the
+	// audio of the URL.
+	Supports(u *url.URL) bool
+
+	// Fetch opens the audio of the URL. The audio must be
+	// closed after reading.
+	Fetch(ctx context.Context, u *url.URL, maxSize int64) (Audio, error)
+}
+
+// explicitProvider is implemented by providers which are
+// only used when they are requested by name, because they
+// support every URL.
+type explicitProvider interface {
+	explicitOnly()
+}
+
+// Audio is the audio stream of a source.
+type Audio struct {
+	io.ReadCloser
+
+	// Size is the size of the stream in bytes or 0 when it
+	// is not known in advance.
+	Size int64
+	// Duration is the duration of the audio or 0 when it is
+	// not known in advance.
+	Duration time.Duration
+	// Type is the ffmpeg input format of the audio. When
+	// empty, the format is detected from the stream.
+	Type string
+}
+
+// Sources holds the enabled providers.
+type Sources struct {
+	providers []Provider
+}
+
+// New registers the providers enabled in the config.
+// Providers which require an executable which is not
+// installed are skipped.
+func New(c SourcesConfig) (*Sources, error) {
+	var t Sources
+
+	for _, name := range c.Providers {
+		var (
+			p   Provider
+			err error
+		)
+
+		switch strings.ToLower(name) {
+		case ProviderYouTube:
+			p = NewYouTube()
+		case ProviderHTTP:
+			p = NewHTTP(c.HTTP)
+		case ProviderYtDlp:
+			p, err = NewYtDlp(c.YtDlp)
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, name)
+		}
+
+		if err != nil {
+			logrus.WithError(err).WithField("provider", name).Warn("Source provider is disabled")
+			continue
+		}
+
+		t.providers = append(t.providers, p)
+	}
+
+	return &t, nil
+}
+
+// Names returns the names of the registered providers.
+func (t *Sources) Names() []string {
+	names := make([]string, 0, len(t.providers))
+	for _, p := range t.providers {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// Resolve returns the provider with the given name for the
+// URL. When name is empty, the first provider supporting the
+// URL which does not need to be requested by name is
+// returned.
+func (t *Sources) Resolve(name, rawURL string) (Provider, *url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, nil, errs.WrapUserError("source URL must be a valid HTTP(S) URL")
+	}
+
+	for _, p := range t.providers {
+		if name != "" && !strings.EqualFold(p.Name(), name) {
+			continue
+		}
+		if _, ok := p.(explicitProvider); ok && name == "" {
+			continue
+		}
+		if p.Supports(u) {
+			return p, u, nil
+		}
+		if name != "" {
+			return nil, nil, errs.WrapUserError(
+				fmt.Sprintf("source provider '%s' does not support the URL", p.Name()))
+		}
+	}
+
+	if name != "" {
+		return nil, nil, errs.WrapUserError(
+			fmt.Sprintf("source provider '%s' is not available", name))
+	}
+
+	return nil, nil, errs.WrapUserError("no source provider supports the URL")
+}
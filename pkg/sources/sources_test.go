@@ -0,0 +1,124 @@
+package sources
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	s, err := New(SourcesConfig{Providers: []string{"YouTube", "http"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{ProviderYouTube, ProviderHTTP}, s.Names())
+
+	_, err = New(SourcesConfig{Providers: []string{"vimeo"}})
+	assert.ErrorIs(t, err, ErrUnsupportedProvider)
+}
+
+func TestResolve(t *testing.T) {
+	s, err := New(SourcesConfig{Providers: []string{ProviderYouTube, ProviderHTTP}})
+	assert.Nil(t, err)
+
+	p, u, err := s.Resolve("", "https://youtu.be/dQw4w9WgXcQ")
+	assert.Nil(t, err)
+	assert.Equal(t, ProviderYouTube, p.Name())
+	assert.Equal(t, "youtu.be", u.Host)
+
+	p, _, err = s.Resolve("", "https://example.com/sound.mp3")
+	assert.Nil(t, err)
+	assert.Equal(t, ProviderHTTP, p.Name())
+
+	p, _, err = s.Resolve("HTTP", "https://www.youtube.com/watch?v=dQw4w9WgXcQ")
+	assert.Nil(t, err)
+	assert.Equal(t, ProviderHTTP, p.Name())
+
+	_, _, err = s.Resolve("youtube", "https://example.com/sound.mp3")
+	assert.NotNil(t, err)
+
+	_, _, err = s.Resolve("ytdlp", "https://example.com/sound.mp3")
+	assert.NotNil(t, err)
+
+	_, _, err = s.Resolve("", "file:///etc/passwd")
+	assert.NotNil(t, err)
+}
+
+func TestResolveYtDlp(t *testing.T) {
+	// yt-dlp is not required to be installed for resolving.
+	s := &Sources{providers: []Provider{NewYouTube(), &YtDlp{}, NewHTTP(HTTPConfig{})}}
+
+	p, _, err := s.Resolve("", "https://example.com/a.mp3")
+	assert.Nil(t, err)
+	assert.Equal(t, ProviderHTTP, p.Name())
+
+	p, _, err = s.Resolve("", "https://youtu.be/dQw4w9WgXcQ")
+	assert.Nil(t, err)
+	assert.Equal(t, ProviderYouTube, p.Name())
+
+	p, _, err = s.Resolve("ytdlp", "https://example.com/a.mp3")
+	assert.Nil(t, err)
+	assert.Equal(t, ProviderYtDlp, p.Name())
+
+	s = &Sources{providers: []Provider{&YtDlp{}}}
+	_, _, err = s.Resolve("", "https://example.com/a.mp3")
+	assert.NotNil(t, err)
+}
+
+func TestYtDlpPrivateNetworks(t *testing.T) {
+	// The address is checked before yt-dlp is started.
+	p := &YtDlp{exec: "/does/not/exist"}
+
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/a.mp3",
+		"http://localhost/a.mp3",
+		"http://[::1]/a.mp3",
+		"http://169.254.169.254/latest/meta-data",
+	} {
+		u, _ := url.Parse(rawURL)
+		_, err := p.Fetch(context.Background(), u, 0)
+		assert.ErrorContains(t, err, errForbiddenAddress.Error(), rawURL)
+	}
+
+	p.allowPrivate = true
+	u, _ := url.Parse("http://127.0.0.1:8080/a.mp3")
+	_, err := p.Fetch(context.Background(), u, 0)
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), errForbiddenAddress.Error())
+}
+
+func TestHTTPPrivateNetworks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/ogg")
+		w.Write([]byte("OggS"))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+
+	_, err := NewHTTP(HTTPConfig{}).Fetch(context.Background(), u, 0)
+	assert.NotNil(t, err)
+
+	audio, err := NewHTTP(HTTPConfig{AllowPrivateNetworks: true}).Fetch(context.Background(), u, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), audio.Size)
+	audio.Close()
+
+	_, err = NewHTTP(HTTPConfig{AllowPrivateNetworks: true}).Fetch(context.Background(), u, 2)
+	assert.NotNil(t, err)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	assert.True(t, isPublicIP(net.ParseIP("1.1.1.1")))
+	assert.True(t, isPublicIP(net.ParseIP("2606:4700:4700::1111")))
+	assert.False(t, isPublicIP(net.ParseIP("127.0.0.1")))
+	assert.False(t, isPublicIP(net.ParseIP("10.0.0.1")))
+	assert.False(t, isPublicIP(net.ParseIP("192.168.1.1")))
+	assert.False(t, isPublicIP(net.ParseIP("169.254.169.254")))
+	assert.False(t, isPublicIP(net.ParseIP("::1")))
+	assert.False(t, isPublicIP(net.ParseIP("0.0.0.0")))
+	assert.False(t, isPublicIP(nil))
+}
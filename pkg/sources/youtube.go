@@ -0,0 +1,77 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/kkdai/youtube/v2"
+	"github.com/zekrotja/yuri69/pkg/errs"
+)
+
+var youtubeHosts = []string{
+	"youtube.com",
+	"www.youtube.com",
+	"m.youtube.com",
+	"music.youtube.com",
+	"youtu.be",
+}
+
+// YouTube fetches the audio of YouTube videos.
+type YouTube struct {
+	client youtube.Client
+}
+
+var _ Provider = (*YouTube)(nil)
+
+func NewYouTube() *YouTube {
+	return &YouTube{}
+}
+
+func (t *YouTube) Name() string {
+	return ProviderYouTube
+}
+
+func (t *YouTube) Supports(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	for _, h := range youtubeHosts {
+		if host == h {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *YouTube) Fetch(ctx context.Context, u *url.URL, maxSize int64) (Audio, error) {
+	video, err := t.client.GetVideoContext(ctx, u.String())
+	if err != nil {
+		return Audio{}, err
+	}
+
+	formats := video.Formats.WithAudioChannels()
+	if len(formats) == 0 {
+		return Audio{}, errs.WrapUserError("the provided video does not have any audio streams")
+	}
+	formats.Sort()
+	format := &formats[0]
+
+	mtyp := mimetype.Lookup(strings.SplitN(format.MimeType, ";", 2)[0])
+	if mtyp == nil {
+		return Audio{}, errs.WrapUserError(
+			fmt.Sprintf("could not match any mime type to the extracted stream (%s)", format.MimeType))
+	}
+
+	stream, size, err := t.client.GetStreamContext(ctx, video, format)
+	if err != nil {
+		return Audio{}, err
+	}
+
+	return Audio{
+		ReadCloser: stream,
+		Size:       size,
+		Duration:   video.Duration,
+		Type:       mtyp.Extension()[1:],
+	}, nil
+}
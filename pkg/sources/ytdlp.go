@@ -0,0 +1,174 @@
+package sources
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/zekrotja/yuri69/pkg/errs"
+)
+
+type YtDlpConfig struct {
+	// Executable overrides the path of the yt-dlp executable.
+	Executable string
+	// Format is the format selection passed to yt-dlp.
+	Format string
+	// AllowPrivateNetworks allows fetching audio from URLs
+	// with loopback and private network addresses.
+	AllowPrivateNetworks bool
+}
+
+// YtDlp fetches audio from all sites supported by a locally
+// installed yt-dlp. Because it supports every URL, it is
+// only used when it is requested by name.
+type YtDlp struct {
+	exec         string
+	format       string
+	allowPrivate bool
+}
+
+var _ Provider = (*YtDlp)(nil)
+
+// NewYtDlp returns the provider when the yt-dlp executable
+// is found.
+func NewYtDlp(c YtDlpConfig) (*YtDlp, error) {
+	execPath := c.Executable
+	if execPath == "" {
+		execPath = "yt-dlp"
+	}
+	execPath, err := exec.LookPath(execPath)
+	if err != nil {
+		return nil, errors.New("yt-dlp executable was not found")
+	}
+
+	format := c.Format
+	if format == "" {
+		format = "bestaudio/best"
+	}
+
+	return &YtDlp{
+		exec:         execPath,
+		format:       format,
+		allowPrivate: c.AllowPrivateNetworks,
+	}, nil
+}
+
+func (t *YtDlp) Name() string {
+	return ProviderYtDlp
+}
+
+func (t *YtDlp) Supports(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+func (t *YtDlp) explicitOnly() {}
+
+func (t *YtDlp) Fetch(ctx context.Context, u *url.URL, maxSize int64) (Audio, error) {
+	if !t.allowPrivate {
+		if err := checkPublicHost(ctx, u.Hostname()); err != nil {
+			return Audio{}, err
+		}
+	}
+
+	args := []string{
+		"--quiet", "--no-warnings", "--no-playlist", "--no-part",
+		"--format", t.format,
+		"--output", "-",
+	}
+	if maxSize > 0 {
+		args = append(args, "--max-filesize", strconv.FormatInt(maxSize, 10))
+	}
+	// The URL is separated from the options, so that it can
+	// not be interpreted as option.
+	args = append(args, "--", u.String())
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.exec, args...)
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return Audio{}, err
+	}
+
+	if err = cmd.Start(); err != nil {
+		return Audio{}, err
+	}
+
+	return Audio{
+		ReadCloser: &processReader{r: stdout, cmd: cmd, stderr: &stderr},
+	}, nil
+}
+
+// checkPublicHost returns a user error when the host resolves
+// to a non-public address. yt-dlp resolves the host again and
+// follows redirects on its own, so this only refuses URLs which
+// point to private networks directly.
+func checkPublicHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errs.WrapUserError("the host of the source URL could not be resolved")
+	}
+
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return errs.WrapUserError(errForbiddenAddress)
+		}
+	}
+
+	return nil
+}
+
+// processReader reads the output of a process. When the
+// output ends, it fails with the error output of the process
+// when the process did not exit successfully.
+type processReader struct {
+	r      io.Reader
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+	done   bool
+	err    error
+}
+
+func (t *processReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err == io.EOF {
+		if werr := t.wait(); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (t *processReader) Close() error {
+	if !t.done && t.cmd.Process != nil {
+		t.cmd.Process.Kill()
+	}
+	t.wait()
+	return nil
+}
+
+func (t *processReader) wait() error {
+	if t.done {
+		return t.err
+	}
+	t.done = true
+
+	err := t.cmd.Wait()
+	if t.cmd.ProcessState != nil && t.cmd.ProcessState.ExitCode() != 0 {
+		msg := strings.TrimSpace(t.stderr.String())
+		if msg == "" {
+			msg = "yt-dlp exited with code " + strconv.Itoa(t.cmd.ProcessState.ExitCode())
+		}
+		err = errors.New(msg)
+	}
+	t.err = err
+
+	return err
+}
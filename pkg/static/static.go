@@ -1,15 +1,37 @@
 package static
 
-import "github.com/gabriel-vasile/mimetype"
+import (
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
 
 const (
-	BucketSounds = "sounds"
-	BucketTemp   = "temp"
-	SoundsMime   = "audio/ogg"
+	BucketSounds    = "sounds"
+	BucketTemp      = "temp"
+	BucketOriginals = "originals"
+	BucketVersions  = "versions"
+	SoundsMime      = "audio/ogg"
+	OriginalsMime   = "application/octet-stream"
 )
 
+// TempSoundPrefix prefixes the idents of spoken sounds which
+// are not saved. The prefix contains characters which are
+// not allowed in sound uids, so that they can not collide.
+const TempSoundPrefix = "~say-"
+
 var SoundsMimeType mimetype.MIME
 
+// SoundBucket returns the bucket in which the sound with the
+// given ident is stored. Spoken sounds which are not saved
+// are kept in the temp bucket.
+func SoundBucket(ident string) string {
+	if strings.HasPrefix(ident, TempSoundPrefix) {
+		return BucketTemp
+	}
+	return BucketSounds
+}
+
 func init() {
 	typ := mimetype.Lookup(SoundsMime)
 	if typ == nil {
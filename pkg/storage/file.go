@@ -64,26 +64,34 @@ func (t *File) PutObject(
 	fd := path.Join(t.basePath, bucketName, objectName)
 
 	stat, err := os.Stat(fd)
-	var fh *os.File
-
-	if os.IsNotExist(err) {
-		fh, err = os.Create(fd)
-	}
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 	if stat != nil && stat.IsDir() {
 		return errors.New("given file dir is a location")
 	}
 
-	fh, err = os.Create(fd)
+	// The object is written to a temporary file first which
+	// then replaces the object, so that readers never see a
+	// partially written object.
+	fh, err := os.CreateTemp(path.Dir(fd), "."+objectName+".*")
 	if err != nil {
 		return err
 	}
-	defer fh.Close()
 
-	_, err = io.CopyN(fh, reader, objectSize)
-	return err
+	err = fh.Chmod(0644)
+	if err == nil {
+		_, err = io.CopyN(fh, reader, objectSize)
+	}
+	if closeErr := fh.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fh.Name())
+		return err
+	}
+
+	return os.Rename(fh.Name(), fd)
 }
 
 func (t *File) GetObject(bucketName string, objectName string) (io.ReadCloser, int64, error) {
@@ -0,0 +1,166 @@
+// Package tts synthesizes speech from text using a locally
+// installed text-to-speech engine.
+package tts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zekrotja/yuri69/pkg/errs"
+)
+
+const (
+	EngineEspeak = "espeak-ng"
+	EnginePiper  = "piper"
+)
+
+var (
+	ErrUnsupportedEngine = errors.New("unsupported tts engine")
+)
+
+var voiceRx = regexp.MustCompile(`^[a-zA-Z0-9_+-]{1,40}$`)
+
+type TTSConfig struct {
+	// Engine is either "espeak-ng" or "piper". When empty,
+	// the first engine found in PATH is used.
+	Engine string
+	// Executable overrides the path of the engine executable.
+	Executable string
+	// Voice is the default voice name for espeak-ng or the
+	// path to the voice model for piper.
+	Voice string
+	// MaxLength is the maximum number of characters which
+	// can be synthesized at once.
+	MaxLength int
+	// Timeout is the maximum time the engine may take to
+	// synthesize a text.
+	Timeout time.Duration
+}
+
+// Engine synthesizes speech from text.
+type Engine interface {
+	// Synthesize writes the speech of the given text as WAV
+	// audio to w. When voice is empty, the configured default
+	// voice is used. The engine is stopped when ctx is done.
+	Synthesize(ctx context.Context, text, voice string, w io.Writer) error
+
+	// MaxLength returns the maximum number of characters
+	// which can be synthesized at once.
+	MaxLength() int
+}
+
+// New returns the engine selected in the config. The engine
+// executable is looked up in PATH unless set explicitly.
+func New(c TTSConfig) (Engine, error) {
+	engine := strings.ToLower(c.Engine)
+
+	if engine == "" {
+		for _, name := range []string{EngineEspeak, EnginePiper} {
+			if _, err := exec.LookPath(name); err == nil {
+				engine = name
+				break
+			}
+		}
+		if engine == "" {
+			return nil, fmt.Errorf("no tts engine executable was found")
+		}
+	}
+
+	if engine != EngineEspeak && engine != EnginePiper {
+		return nil, ErrUnsupportedEngine
+	}
+
+	execPath := c.Executable
+	if execPath == "" {
+		execPath = engine
+	}
+	execPath, err := exec.LookPath(execPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s executable was not found", engine)
+	}
+
+	if engine == EnginePiper && c.Voice == "" {
+		return nil, fmt.Errorf("piper requires a voice model to be configured")
+	}
+
+	return &execEngine{
+		engine:    engine,
+		exec:      execPath,
+		voice:     c.Voice,
+		maxLength: c.MaxLength,
+		timeout:   c.Timeout,
+	}, nil
+}
+
+// --- Internal stuff ---
+
+type execEngine struct {
+	engine    string
+	exec      string
+	voice     string
+	maxLength int
+	timeout   time.Duration
+}
+
+func (t *execEngine) Synthesize(ctx context.Context, text, voice string, w io.Writer) error {
+	if voice != "" {
+		if t.engine == EnginePiper {
+			return errs.WrapUserError("voice selection is not supported by the tts engine")
+		}
+		if !voiceRx.MatchString(voice) {
+			return errs.WrapUserError("invalid voice")
+		}
+	} else {
+		voice = t.voice
+	}
+
+	var args []string
+	switch t.engine {
+	case EngineEspeak:
+		args = append(args, "--stdout", "--stdin")
+		if voice != "" {
+			args = append(args, "-v", voice)
+		}
+	case EnginePiper:
+		args = append(args, "--model", voice, "--output_file", "-")
+	}
+
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+
+	var bufStdErr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.exec, args...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = w
+	cmd.Stderr = &bufStdErr
+	// Child processes of the engine might keep the output
+	// open after it has been killed.
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return errs.WrapUserError("speech synthesis took too long")
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if cmd.ProcessState != nil && cmd.ProcessState.ExitCode() != 0 {
+		err = errors.New(bufStdErr.String())
+	}
+
+	return err
+}
+
+func (t *execEngine) MaxLength() int {
+	return t.maxLength
+}
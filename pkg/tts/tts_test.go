@@ -0,0 +1,91 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEngine creates an executable which writes its
+// arguments followed by the text read from stdin.
+func fakeEngine(t *testing.T) string {
+	p := filepath.Join(t.TempDir(), "engine")
+	err := os.WriteFile(p, []byte("#!/bin/sh\necho \"$@\"\ncat\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(TTSConfig{Engine: "festival", Executable: fakeEngine(t)})
+	assert.ErrorIs(t, err, ErrUnsupportedEngine)
+
+	_, err = New(TTSConfig{Engine: EngineEspeak, Executable: "/does/not/exist"})
+	assert.Error(t, err)
+
+	_, err = New(TTSConfig{Engine: EnginePiper, Executable: fakeEngine(t)})
+	assert.Error(t, err)
+
+	e, err := New(TTSConfig{Engine: EngineEspeak, Executable: fakeEngine(t), MaxLength: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 10, e.MaxLength())
+}
+
+func TestSynthesizeEspeak(t *testing.T) {
+	e, err := New(TTSConfig{Engine: EngineEspeak, Executable: fakeEngine(t), Voice: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = e.Synthesize(context.Background(), "hello", "", &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "--stdout --stdin -v en\nhello", buf.String())
+
+	buf.Reset()
+	err = e.Synthesize(context.Background(), "hello", "de", &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "--stdout --stdin -v de\nhello", buf.String())
+
+	err = e.Synthesize(context.Background(), "hello", "../../etc/passwd", &buf)
+	assert.Error(t, err)
+}
+
+func TestSynthesizePiper(t *testing.T) {
+	e, err := New(TTSConfig{Engine: EnginePiper, Executable: fakeEngine(t), Voice: "voice.onnx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = e.Synthesize(context.Background(), "hello", "", &buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "--model voice.onnx --output_file -\nhello", buf.String())
+
+	err = e.Synthesize(context.Background(), "hello", "other.onnx", &buf)
+	assert.Error(t, err)
+}
+
+func TestSynthesizeTimeout(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "engine")
+	err := os.WriteFile(p, []byte("#!/bin/sh\nexec sleep 10\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := New(TTSConfig{Engine: EngineEspeak, Executable: p, Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err = e.Synthesize(context.Background(), "hello", "", &bytes.Buffer{})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
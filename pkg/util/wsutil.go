@@ -19,3 +19,31 @@ func QueryInt(ctx *routing.Context, name string, def int) (int, error) {
 
 	return v, nil
 }
+
+func QueryBool(ctx *routing.Context, name string, def bool) (bool, error) {
+	vStr := ctx.Query(name)
+	if vStr == "" {
+		return def, nil
+	}
+
+	v, err := strconv.ParseBool(vStr)
+	if err != nil {
+		return false, err
+	}
+
+	return v, nil
+}
+
+func QueryFloat(ctx *routing.Context, name string, def float64) (float64, error) {
+	vStr := ctx.Query(name)
+	if vStr == "" {
+		return def, nil
+	}
+
+	v, err := strconv.ParseFloat(vStr, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return v, nil
+}
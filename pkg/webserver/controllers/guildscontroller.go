@@ -15,6 +15,12 @@ func NewGuildsController(r *routing.RouteGroup, ct *controller.Controller) {
 	t := guildsController{ct: ct}
 	r.Get("/filters", t.handleGetFilters)
 	r.Post("/filters", t.handleSetFilters)
+	r.Get("/autoleave", t.handleGetAutoLeave)
+	r.Post("/autoleave", t.handleSetAutoLeave)
+	r.Get("/randomweights", t.handleGetRandomWeights)
+	r.Post("/randomweights", t.handleSetRandomWeights)
+	r.Get("/usersounds", t.handleGetUserSounds)
+	r.Post("/usersounds", t.handleSetUserSounds)
 	return
 }
 
@@ -44,3 +50,84 @@ func (t *guildsController) handleSetFilters(ctx *routing.Context) error {
 
 	return ctx.Write(StatusOK)
 }
+
+func (t *guildsController) handleGetAutoLeave(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	s, err := t.ct.GetGuildAutoLeave(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(s)
+}
+
+func (t *guildsController) handleSetAutoLeave(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req GuildAutoLeave
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	err := t.ct.SetGuildAutoLeave(userid, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+func (t *guildsController) handleGetRandomWeights(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	w, err := t.ct.GetGuildRandomWeights(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(w)
+}
+
+func (t *guildsController) handleSetRandomWeights(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req RandomWeights
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	err := t.ct.SetGuildRandomWeights(userid, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+func (t *guildsController) handleGetUserSounds(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	s, err := t.ct.GetGuildUserSounds(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(s)
+}
+
+func (t *guildsController) handleSetUserSounds(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req GuildUserSounds
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	err := t.ct.SetGuildUserSounds(userid, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	routing "github.com/zekrotja/ozzo-routing/v2"
+	"github.com/zekrotja/yuri69/pkg/controller"
+)
+
+type jobsController struct {
+	ct *controller.Controller
+}
+
+func NewJobsController(r *routing.RouteGroup, ct *controller.Controller) {
+	t := jobsController{ct: ct}
+	r.Get("", t.handleList)
+	r.Get("/<id>", t.handleGet)
+	r.Post("/<id>/cancel", t.handleCancel)
+	return
+}
+
+func (t *jobsController) handleList(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	return ctx.Write(t.ct.ListJobs(userid))
+}
+
+func (t *jobsController) handleGet(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	job, err := t.ct.GetJob(ctx.Param("id"), userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(job)
+}
+
+func (t *jobsController) handleCancel(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	job, err := t.ct.CancelJob(ctx.Param("id"), userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(job)
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	routing "github.com/zekrotja/ozzo-routing/v2"
+	"github.com/zekrotja/yuri69/pkg/controller"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+)
+
+type macrosController struct {
+	ct *controller.Controller
+}
+
+func NewMacrosController(r *routing.RouteGroup, ct *controller.Controller) {
+	t := macrosController{ct: ct}
+	r.Get("", t.handleList)
+	r.Post("", t.handleCreate)
+	r.Get("/<id>", t.handleGet)
+	r.Post("/<id>", t.handleUpdate)
+	r.Delete("/<id>", t.handleDelete)
+	return
+}
+
+func (t *macrosController) handleList(ctx *routing.Context) error {
+	macros, err := t.ct.ListMacros(ctx.Query("order"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(macros)
+}
+
+func (t *macrosController) handleGet(ctx *routing.Context) error {
+	macro, err := t.ct.GetMacro(ctx.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(macro)
+}
+
+func (t *macrosController) handleCreate(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req Macro
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	macro, err := t.ct.CreateMacro(req, userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(macro)
+}
+
+func (t *macrosController) handleUpdate(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req Macro
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	req.Uid = ctx.Param("id")
+	macro, err := t.ct.UpdateMacro(req, userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(macro)
+}
+
+func (t *macrosController) handleDelete(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	err := t.ct.RemoveMacro(ctx.Param("id"), userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
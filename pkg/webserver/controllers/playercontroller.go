@@ -1,6 +1,11 @@
 package controllers
 
 import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
 	routing "github.com/zekrotja/ozzo-routing/v2"
 	"github.com/zekrotja/yuri69/pkg/controller"
 	"github.com/zekrotja/yuri69/pkg/errs"
@@ -20,8 +25,21 @@ func NewPlayerController(r *routing.RouteGroup, ct *controller.Controller) {
 	r.Post("/play/random", t.handlePlayRandom)
 	r.Post("/play/external", t.handlePlayExternal)
 	r.Post("/play/<ident>", t.handlePlay)
+	r.Post("/say", t.handleSay)
 	r.Post("/stop", t.handleStop)
+	r.Post("/pause", t.handlePause)
+	r.Post("/resume", t.handleResume)
+	r.Post("/seek", t.handleSeek)
 	r.Post("/volume", t.handleSetVolume)
+	r.Get("/state", t.handleGetState)
+	r.Get("/effects", t.handleGetEffects)
+	r.Get("/queue", t.handleGetQueue)
+	r.Delete("/queue", t.handleClearQueue)
+	r.Post("/queue/skip", t.handleSkipQueue)
+	r.Post("/queue/move", t.handleMoveQueueEntry)
+	r.Get("/queue/mode", t.handleGetQueueMode)
+	r.Post("/queue/mode", t.handleSetQueueMode)
+	r.Delete("/queue/<id>", t.handleRemoveQueueEntry)
 
 	return
 }
@@ -53,7 +71,17 @@ func (t *playerController) handlePlayRandom(ctx *routing.Context) error {
 	filterMust := util.SplitAndClean(ctx.Query("include"), ",")
 	filterNot := util.SplitAndClean(ctx.Query("exclude"), ",")
 
-	err := t.ct.PlayRandom(userid, filterMust, filterNot)
+	var req PlayRequest
+	if err := ctx.Read(&req); err != nil && err != io.EOF {
+		return errs.WrapUserError(err)
+	}
+
+	weights, err := getRandomWeights(ctx)
+	if err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	err = t.ct.PlayRandom(userid, filterMust, filterNot, req.Effects, weights)
 	if err != nil {
 		return err
 	}
@@ -65,7 +93,23 @@ func (t *playerController) handlePlayExternal(ctx *routing.Context) error {
 	userid, _ := ctx.Get("userid").(string)
 	ident := ctx.Query("url")
 
-	err := t.ct.Play(userid, ident)
+	err := t.ct.Play(userid, ident, nil)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+func (t *playerController) handleSay(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req SayRequest
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	err := t.ct.Say(userid, req)
 	if err != nil {
 		return err
 	}
@@ -77,7 +121,12 @@ func (t *playerController) handlePlay(ctx *routing.Context) error {
 	userid, _ := ctx.Get("userid").(string)
 	ident := ctx.Param("ident")
 
-	err := t.ct.Play(userid, ident)
+	var req PlayRequest
+	if err := ctx.Read(&req); err != nil && err != io.EOF {
+		return errs.WrapUserError(err)
+	}
+
+	err := t.ct.Play(userid, ident, req.Effects)
 	if err != nil {
 		return err
 	}
@@ -96,6 +145,48 @@ func (t *playerController) handleStop(ctx *routing.Context) error {
 	return ctx.Write(StatusOK)
 }
 
+func (t *playerController) handlePause(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	err := t.ct.Pause(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+func (t *playerController) handleResume(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	err := t.ct.Resume(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+func (t *playerController) handleSeek(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req SeekRequest
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	if req.Position < 0 {
+		return errs.WrapUserError("position must be a positive value")
+	}
+
+	err := t.ct.Seek(userid, req.Position)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
 func (t *playerController) handleSetVolume(ctx *routing.Context) error {
 	userid, _ := ctx.Get("userid").(string)
 
@@ -115,3 +206,150 @@ func (t *playerController) handleSetVolume(ctx *routing.Context) error {
 
 	return ctx.Write(StatusOK)
 }
+
+func (t *playerController) handleGetState(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	state, err := t.ct.GetPlayerState(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(state)
+}
+
+func (t *playerController) handleGetEffects(ctx *routing.Context) error {
+	return ctx.Write(t.ct.GetEffects())
+}
+
+func (t *playerController) handleGetQueue(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	queue, err := t.ct.GetQueue(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(queue)
+}
+
+func (t *playerController) handleClearQueue(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	err := t.ct.ClearQueue(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+func (t *playerController) handleSkipQueue(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	err := t.ct.SkipQueue(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+func (t *playerController) handleMoveQueueEntry(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req QueueMoveRequest
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	if req.Id == "" {
+		return errs.WrapUserError("id must be specified")
+	}
+
+	err := t.ct.MoveQueueEntry(userid, req.Id, req.Position)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+func (t *playerController) handleRemoveQueueEntry(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+	id := ctx.Param("id")
+
+	err := t.ct.RemoveQueueEntry(userid, id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+func (t *playerController) handleGetQueueMode(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	mode, err := t.ct.GetQueueMode(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(QueueModeRequest{Mode: mode})
+}
+
+func (t *playerController) handleSetQueueMode(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req QueueModeRequest
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	err := t.ct.SetQueueMode(userid, req.Mode)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+// getRandomWeights returns the random weights passed via the
+// query parameters "favorites", "rarely_played" and "tags",
+// where the latter is a list of tag:weight pairs. nil is
+// returned when none of them is given.
+func getRandomWeights(ctx *routing.Context) (*RandomWeights, error) {
+	if ctx.Query("favorites") == "" && ctx.Query("rarely_played") == "" && ctx.Query("tags") == "" {
+		return nil, nil
+	}
+
+	var (
+		w   RandomWeights
+		err error
+	)
+
+	w.Favorites, err = util.QueryFloat(ctx, "favorites", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	w.RarelyPlayed, err = util.QueryFloat(ctx, "rarely_played", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Tags = make(map[string]float64)
+	for _, pair := range util.SplitAndClean(ctx.Query("tags"), ",") {
+		tag, weightStr, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid tag weight '%s': must be tag:weight", pair)
+		}
+		weight, err := strconv.ParseFloat(weightStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag weight '%s': %s", pair, err.Error())
+		}
+		w.Tags[tag] = weight
+	}
+
+	return &w, nil
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	routing "github.com/zekrotja/ozzo-routing/v2"
+	"github.com/zekrotja/yuri69/pkg/controller"
+	"github.com/zekrotja/yuri69/pkg/errs"
+	. "github.com/zekrotja/yuri69/pkg/models"
+)
+
+type schedulesController struct {
+	ct *controller.Controller
+}
+
+func NewSchedulesController(r *routing.RouteGroup, ct *controller.Controller) {
+	t := schedulesController{ct: ct}
+	r.Get("", t.handleList)
+	r.Post("", t.handleCreate)
+	r.Get("/<id>", t.handleGet)
+	r.Post("/<id>", t.handleUpdate)
+	r.Delete("/<id>", t.handleDelete)
+	return
+}
+
+func (t *schedulesController) handleList(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	schedules, err := t.ct.ListSchedules(userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(schedules)
+}
+
+func (t *schedulesController) handleGet(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	s, err := t.ct.GetSchedule(userid, ctx.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(s)
+}
+
+func (t *schedulesController) handleCreate(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req Schedule
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	s, err := t.ct.CreateSchedule(userid, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(s)
+}
+
+func (t *schedulesController) handleUpdate(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	var req Schedule
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	req.Id = ctx.Param("id")
+	s, err := t.ct.UpdateSchedule(userid, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(s)
+}
+
+func (t *schedulesController) handleDelete(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	err := t.ct.RemoveSchedule(userid, ctx.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gabriel-vasile/mimetype"
@@ -16,6 +18,10 @@ import (
 	"github.com/zekrotja/yuri69/pkg/webserver/middleware"
 )
 
+// multipartOverhead is the size added to the maximum upload
+// size for the multipart encoding of upload requests.
+const multipartOverhead = 64 << 10
+
 type soundsController struct {
 	ct *controller.Controller
 }
@@ -24,7 +30,12 @@ func NewSoundsController(r *routing.RouteGroup, ct *controller.Controller) {
 	t := soundsController{ct: ct}
 	r.Get("", t.handleList)
 	r.Put("/upload", t.handleUpload)
+	r.Post("/upload/chunked", t.handleStartChunkedUpload)
+	r.Get("/upload/chunked/<id>", t.handleGetChunkedUpload)
+	r.Patch("/upload/chunked/<id>", t.handlePutUploadChunk)
 	r.Post("/create", t.handleCreate)
+	r.Get("/presets", t.handleGetPresets)
+	r.Get("/sources", t.handleGetSources)
 	r.Get("/downloadall",
 		middleware.RateLimit(1, 5*time.Minute, middleware.IdentityLookup("userid")),
 		t.handleGetDownloadAll)
@@ -32,6 +43,10 @@ func NewSoundsController(r *routing.RouteGroup, ct *controller.Controller) {
 	r.Get("/<id>", t.handleGet)
 	r.Get("/<id>/download", t.handleGetDownload)
 	r.Post("/<id>", t.handleUpdate)
+	r.Post("/<id>/edit", t.handleEdit)
+	r.Get("/<id>/versions", t.handleGetVersions)
+	r.Get("/<id>/versions/<version>/download", t.handleGetVersionDownload)
+	r.Post("/<id>/versions/<version>/rollback", t.handleRollback)
 	r.Delete("/<id>", t.handleDelete)
 	return
 }
@@ -80,10 +95,22 @@ func (t *soundsController) handleGetDownload(ctx *routing.Context) error {
 }
 
 func (t *soundsController) handleUpload(ctx *routing.Context) error {
+	if maxSize := t.ct.MaxUploadSize(); maxSize > 0 {
+		// Leave some room for the multipart boundaries and
+		// headers. The size of the file itself is checked by
+		// the controller.
+		ctx.Request.Body = http.MaxBytesReader(ctx.Response, ctx.Request.Body, maxSize+multipartOverhead)
+	}
+
 	f, fh, err := ctx.Request.FormFile("file")
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return errs.WrapUserError("the file exceeds the maximum upload size")
+		}
 		return errs.WrapUserError(err)
 	}
+	defer f.Close()
 
 	ct := ctx.Query("type", fh.Header.Get("Content-Type"))
 	if ct == "" {
@@ -101,6 +128,46 @@ func (t *soundsController) handleUpload(ctx *routing.Context) error {
 	})
 }
 
+func (t *soundsController) handleStartChunkedUpload(ctx *routing.Context) error {
+	var req ChunkedUploadRequest
+	err := ctx.Read(&req)
+	if err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	res, err := t.ct.StartChunkedUpload(req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(res)
+}
+
+func (t *soundsController) handleGetChunkedUpload(ctx *routing.Context) error {
+	res, err := t.ct.GetChunkedUpload(ctx.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	ctx.Response.Header().Set("Upload-Offset", strconv.FormatInt(res.Offset, 10))
+	return ctx.Write(res)
+}
+
+func (t *soundsController) handlePutUploadChunk(ctx *routing.Context) error {
+	offset, err := strconv.ParseInt(ctx.Request.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return errs.WrapUserError("invalid or missing 'Upload-Offset' header")
+	}
+
+	res, err := t.ct.PutUploadChunk(ctx.Param("id"), offset, ctx.Request.Body)
+	if err != nil {
+		return err
+	}
+
+	ctx.Response.Header().Set("Upload-Offset", strconv.FormatInt(res.Offset, 10))
+	return ctx.Write(res)
+}
+
 func (t *soundsController) handleCreate(ctx *routing.Context) error {
 	var req CreateSoundRequest
 	err := ctx.Read(&req)
@@ -110,17 +177,19 @@ func (t *soundsController) handleCreate(ctx *routing.Context) error {
 
 	req.Creator.ID, _ = ctx.Get("userid").(string)
 
-	var sound Sound
-	if req.YouTube != nil {
-		sound, err = t.ct.GetSoundFromYoutube(req)
+	var job Job
+	if req.Source != nil || req.YouTube != nil {
+		job, err = t.ct.GetSoundFromSource(req)
+	} else if req.TTS != nil {
+		job, err = t.ct.GetSoundFromTTS(req)
 	} else {
-		sound, err = t.ct.CreateSound(req)
+		job, err = t.ct.CreateSound(req)
 	}
 	if err != nil {
 		return err
 	}
 
-	return ctx.Write(sound)
+	return ctx.Write(job)
 }
 
 func (t *soundsController) handleUpdate(ctx *routing.Context) error {
@@ -142,6 +211,64 @@ func (t *soundsController) handleUpdate(ctx *routing.Context) error {
 	return ctx.Write(newSound)
 }
 
+func (t *soundsController) handleEdit(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+	id := ctx.Param("id")
+
+	var req EditSoundRequest
+	err := ctx.Read(&req)
+	if err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	job, err := t.ct.EditSound(id, req, userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(job)
+}
+
+func (t *soundsController) handleGetVersions(ctx *routing.Context) error {
+	versions, err := t.ct.ListSoundVersions(ctx.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(versions)
+}
+
+func (t *soundsController) handleGetVersionDownload(ctx *routing.Context) error {
+	uid := ctx.Param("id")
+	version := ctx.Param("version")
+
+	r, _, err := t.ct.GetSoundVersionReader(uid, version)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	ext := mimetype.Lookup(static.SoundsMime).Extension()
+	ctx.Response.Header().Set("Content-Type", static.SoundsMime)
+	ctx.Response.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"%s-%s%s\"", uid, version, ext))
+	ctx.Response.WriteHeader(http.StatusOK)
+
+	_, err = io.Copy(ctx.Response, r)
+	return err
+}
+
+func (t *soundsController) handleRollback(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+
+	sound, err := t.ct.RollbackSound(ctx.Param("id"), ctx.Param("version"), userid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(sound)
+}
+
 func (t *soundsController) handleDelete(ctx *routing.Context) error {
 	userid, _ := ctx.Get("userid").(string)
 	id := ctx.Param("id")
@@ -177,8 +304,16 @@ func (t *soundsController) handleGetDownloadAll(ctx *routing.Context) error {
 func (t *soundsController) handleImport(ctx *routing.Context) error {
 	userid, _ := ctx.Get("userid").(string)
 
+	if maxSize := t.ct.MaxImportSize(); maxSize > 0 {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Response, ctx.Request.Body, maxSize+multipartOverhead)
+	}
+
 	f, fh, err := ctx.Request.FormFile("file")
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return errs.WrapUserError("the archive exceeds the maximum import size")
+		}
 		return errs.WrapUserError(err)
 	}
 
@@ -187,10 +322,23 @@ func (t *soundsController) handleImport(ctx *routing.Context) error {
 		return errs.WrapUserError("no content type was specified")
 	}
 
-	res, err := t.ct.ImportSounds(userid, f, ct)
+	dryRun, err := util.QueryBool(ctx, "dryrun", false)
+	if err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	job, err := t.ct.ImportSounds(userid, f, ct, dryRun)
 	if err != nil {
 		return err
 	}
 
-	return ctx.Write(res)
+	return ctx.Write(job)
+}
+
+func (t *soundsController) handleGetPresets(ctx *routing.Context) error {
+	return ctx.Write(t.ct.GetPresets())
+}
+
+func (t *soundsController) handleGetSources(ctx *routing.Context) error {
+	return ctx.Write(t.ct.GetSources())
 }
@@ -15,6 +15,14 @@ func NewUsersController(r *routing.RouteGroup, ct *controller.Controller) {
 	t := usersController{ct: ct}
 	r.Get("/settings/fasttrigger", t.handleGetFastTrigger)
 	r.Post("/settings/fasttrigger", t.handleSetFastTrigger)
+	r.Get("/settings/fasttriggers", t.handleGetFastTriggers)
+	r.Post("/settings/fasttriggers", t.handleSetFastTriggers)
+	r.Get("/settings/fasttriggers/<guildid>", t.handleGetFastTriggers)
+	r.Post("/settings/fasttriggers/<guildid>", t.handleSetFastTriggers)
+	r.Get("/settings/sounds", t.handleGetSounds)
+	r.Post("/settings/sounds", t.handleSetSounds)
+	r.Get("/settings/sounds/<guildid>", t.handleGetSounds)
+	r.Post("/settings/sounds/<guildid>", t.handleSetSounds)
 	r.Get("/settings/favorites", t.handleGetFavorites)
 	r.Put("/settings/favorites/<ident>", t.handlePutFavorite)
 	r.Delete("/settings/favorites/<ident>", t.handleDeleteFavorite)
@@ -55,6 +63,64 @@ func (t *usersController) handleSetFastTrigger(ctx *routing.Context) error {
 	return ctx.Write(StatusOK)
 }
 
+func (t *usersController) handleGetFastTriggers(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+	guildid := ctx.Param("guildid")
+
+	triggers, err := t.ct.GetFastTriggers(userid, guildid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(triggers)
+}
+
+func (t *usersController) handleSetFastTriggers(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+	guildid := ctx.Param("guildid")
+
+	var req FastTriggers
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	err := t.ct.SetFastTriggers(userid, guildid, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
+func (t *usersController) handleGetSounds(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+	guildid := ctx.Param("guildid")
+
+	sounds, err := t.ct.GetUserSounds(userid, guildid)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(sounds)
+}
+
+func (t *usersController) handleSetSounds(ctx *routing.Context) error {
+	userid, _ := ctx.Get("userid").(string)
+	guildid := ctx.Param("guildid")
+
+	var req UserSounds
+	if err := ctx.Read(&req); err != nil {
+		return errs.WrapUserError(err)
+	}
+
+	err := t.ct.SetUserSounds(userid, guildid, req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Write(StatusOK)
+}
+
 func (t *usersController) handleGetFavorites(ctx *routing.Context) error {
 	userid, _ := ctx.Get("userid").(string)
 
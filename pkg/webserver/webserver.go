@@ -163,6 +163,9 @@ func (t *Webserver) registerRoutes(
 
 	gApi.Get("/auth/ota/token", t.authHandler.HandleGetOtaQR)
 	controllers.NewSoundsController(gApi.Group("/sounds"), t.ct)
+	controllers.NewJobsController(gApi.Group("/jobs"), t.ct)
+	controllers.NewMacrosController(gApi.Group("/macros"), t.ct)
+	controllers.NewSchedulesController(gApi.Group("/schedules"), t.ct)
 	controllers.NewPlayerController(gApi.Group("/players"), t.ct)
 	controllers.NewUsersController(gApi.Group("/users"), t.ct)
 	controllers.NewGuildsController(gApi.Group("/guilds"), t.ct)
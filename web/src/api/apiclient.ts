@@ -7,6 +7,7 @@ import {
   GuildFilters,
   GuildInfo,
   ImportSoundsResult,
+  Job,
   OTAToken,
   PlaybackLogEntry,
   PlaybackStats,
@@ -21,6 +22,7 @@ import {
   User,
 } from './models';
 import { buildQueryParams } from './util';
+import { JobError } from './errors';
 
 export class APIClient extends HttpClient {
   private _onWsEvent: (e: Event<any>) => void = () => {};
@@ -76,7 +78,7 @@ export class APIClient extends HttpClient {
     return this.req('PUT', 'sounds/upload', file);
   }
 
-  soundsCreate(sound: CreateSoundRequest): Promise<Sound> {
+  soundsCreate(sound: CreateSoundRequest): Promise<Job<Sound>> {
     return this.req('POST', 'sounds/create', sound);
   }
 
@@ -209,8 +211,33 @@ export class APIClient extends HttpClient {
     return this.req('DELETE', `admins/guilds/${id}`);
   }
 
-  soundsImport(file: File): Promise<ImportSoundsResult> {
-    return this.req('POST', 'sounds/import', file);
+  soundsImport(file: File, dryRun = false): Promise<Job<ImportSoundsResult>> {
+    return this.req('POST', `sounds/import?dryrun=${dryRun}`, file);
+  }
+
+  soundSources(): Promise<string[]> {
+    return this.req('GET', 'sounds/sources');
+  }
+
+  jobs(): Promise<Job[]> {
+    return this.req('GET', 'jobs');
+  }
+
+  job<T = any>(id: string): Promise<Job<T>> {
+    return this.req('GET', `jobs/${id}`);
+  }
+
+  cancelJob(id: string): Promise<Job> {
+    return this.req('POST', `jobs/${id}/cancel`);
+  }
+
+  async awaitJob<T = any>(job: Job<T>, intervalMs: number = 1000): Promise<Job<T>> {
+    while (job.status === 'pending' || job.status === 'running') {
+      await new Promise((r) => setTimeout(r, intervalMs));
+      job = await this.job<T>(job.id);
+    }
+    if (job.status !== 'succeeded') throw new JobError(job);
+    return job;
   }
 
   twitchPageState(): Promise<TwitchPageState> {
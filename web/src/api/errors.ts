@@ -1,4 +1,4 @@
-import { Status } from "./models";
+import { Job, Status } from "./models";
 
 export class APIError extends Error {
   constructor(private _res: Response, private _body?: Status) {
@@ -17,3 +17,13 @@ export class APIError extends Error {
     return this._body?.status ?? 0;
   }
 }
+
+export class JobError extends Error {
+  constructor(private _job: Job) {
+    super(_job.error ?? _job.status);
+  }
+
+  get job() {
+    return this._job;
+  }
+}
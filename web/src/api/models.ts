@@ -14,6 +14,7 @@ export enum EventType {
   VoiceLeave = 'voiceleave',
   VoiceInit = 'voiceinit',
   VoiceDeinit = 'voicedeinit',
+  JobUpdated = 'jobupdated',
   _Disconnected = '_disconnected',
   _Reconnected = '_reconnected',
 }
@@ -50,12 +51,21 @@ export type CreateSoundRequest = Sound & {
   normalize: boolean;
   overdrive: boolean;
   upload_id?: string;
+  source?: SoundSource;
+  /** @deprecated use source instead */
   youtube?: YouTubeDL;
 
   _start_time_str?: string; // pseudo prop
   _end_time_str?: string; // pseudo prop
 };
 
+export type SoundSource = {
+  provider?: string;
+  url: string;
+  start_time_seconds?: number;
+  end_time_seconds?: number;
+};
+
 export type YouTubeDL = {
   url: string;
   start_time_seconds?: number;
@@ -158,11 +168,28 @@ export type ApiKey = {
 };
 
 export type ImportSoundsResult = {
+  dry_run: boolean;
   successful?: string[];
   failed?: {
     uid: string;
+    file?: string;
     error: string;
   }[];
+  sounds?: Sound[];
+};
+
+export type JobStatus = 'pending' | 'running' | 'succeeded' | 'failed' | 'canceled';
+
+export type Job<T = any> = {
+  id: string;
+  type: string;
+  user_id: string;
+  status: JobStatus;
+  progress: number;
+  error?: string;
+  result?: T;
+  created: string;
+  finished?: string;
 };
 
 export type RateLimitParams = {
@@ -1,5 +1,5 @@
 import { useNavigate } from 'react-router';
-import { APIClient, APIError } from '../api';
+import { APIClient, APIError, JobError } from '../api';
 import { Embed } from '../components/Embed';
 import { ApiClientInstance } from '../instances';
 import { useStore } from '../store';
@@ -39,6 +39,14 @@ export const useApi = () => {
             6000,
           );
         }
+      } else if (e instanceof JobError) {
+        show(
+          <span>
+            <strong>Job {e.job.status}:</strong>&nbsp;{e.message}
+          </span>,
+          'error',
+          6000,
+        );
       } else {
         show(
           <span>
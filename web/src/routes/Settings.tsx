@@ -229,14 +229,14 @@ export const SettingsRoute: React.FC<Props> = ({}) => {
     setDownloadLock(Date.now() + 5 * 60 * 1000);
   };
 
-  const _onImport = () => {
+  const _onImport = (dryRun = false) => {
     if (!importFile) return;
 
-    setImportFile(undefined);
+    if (!dryRun) setImportFile(undefined);
     setImportProcessing(true);
     setImportResult(undefined);
-    fetch((c) => c.soundsImport(importFile))
-      .then(setImportResult)
+    fetch(async (c) => c.awaitJob(await c.soundsImport(importFile, dryRun)))
+      .then((job) => setImportResult(job.result))
       .catch()
       .finally(() => setImportProcessing(false));
   };
@@ -412,9 +412,14 @@ export const SettingsRoute: React.FC<Props> = ({}) => {
               {importProcessing || (
                 <div>
                   <FileDrop file={importFile} onFileInput={setImportFile} />
-                  <Button disabled={!importFile} onClick={_onImport}>
-                    Import
-                  </Button>
+                  <Flex gap="1em">
+                    <Button disabled={!importFile} onClick={() => _onImport(true)}>
+                      Check
+                    </Button>
+                    <Button disabled={!importFile} onClick={() => _onImport()}>
+                      Import
+                    </Button>
+                  </Flex>
                 </div>
               )}
               {importProcessing && (
@@ -425,11 +430,14 @@ export const SettingsRoute: React.FC<Props> = ({}) => {
               )}
               {importResult && (
                 <div>
-                  <p>Successful Imports: {importResult.successful?.length ?? 0}</p>
+                  <p>
+                    {importResult.dry_run ? 'Sounds to import' : 'Successful Imports'}:{' '}
+                    {importResult.successful?.length ?? 0}
+                  </p>
                   <ErrContainer>
                     {importResult.failed?.map((err) => (
                       <p>
-                        <strong>{err.uid}</strong>
+                        <strong>{err.uid || err.file}</strong>
                         <br />
                         <span>{err.error}</span>
                       </p>
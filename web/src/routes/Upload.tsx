@@ -60,7 +60,8 @@ export const UploadRoute: React.FC<Props> = ({}) => {
       const req = { ...sound };
       setState(1);
       if (youtubeUrl) {
-        req.youtube = {
+        req.source = {
+          provider: 'youtube',
           url: youtubeUrl,
           start_time_seconds: getSeconds(sound._start_time_str ?? '0'),
           end_time_seconds: getSeconds(sound._end_time_str ?? '0'),
@@ -70,7 +71,7 @@ export const UploadRoute: React.FC<Props> = ({}) => {
         req.upload_id = res.upload_id;
       }
       setState(2);
-      await fetch((c) => c.soundsCreate(req));
+      await fetch(async (c) => c.awaitJob(await c.soundsCreate(req)));
       nav(-1);
       show(
         <span>